@@ -33,19 +33,24 @@ type Argument struct {
 
 type Arguments []Argument
 
+// ArgumentMarshaling is the JSON representation of an argument, with the
+// components describing the fields of tuple types.
+type ArgumentMarshaling struct {
+	Name       string
+	Type       string
+	Components []ArgumentMarshaling
+	Indexed    bool
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface
 func (argument *Argument) UnmarshalJSON(data []byte) error {
-	var extarg struct {
-		Name    string
-		Type    string
-		Indexed bool
-	}
+	var extarg ArgumentMarshaling
 	err := json.Unmarshal(data, &extarg)
 	if err != nil {
 		return fmt.Errorf("argument json err: %v", err)
 	}
 
-	argument.Type, err = NewType(extarg.Type)
+	argument.Type, err = NewType(extarg.Type, extarg.Components)
 	if err != nil {
 		return err
 	}
@@ -117,7 +122,7 @@ func (arguments Arguments) unpackTuple(v interface{}, marshalledValues []interfa
 	var abi2struct map[string]string
 	if kind == reflect.Struct {
 		var err error
-		abi2struct, err = mapAbiToStructFields(arguments, value)
+		abi2struct, err = mapArgNamesToStructFields(arguments.names(), value)
 		if err != nil {
 			return err
 		}
@@ -162,36 +167,31 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues []interf
 	kind := elem.Kind()
 	reflectValue := reflect.ValueOf(marshalledValues[0])
 
-	var abi2struct map[string]string
-	if kind == reflect.Struct {
-		var err error
-		if abi2struct, err = mapAbiToStructFields(arguments, elem); err != nil {
+	arg := arguments.NonIndexed()[0]
+
+	// A lone tuple is unpacked directly into the struct, unless it's wrapped
+	// into a field named after the argument.
+	if kind == reflect.Struct && (arg.Type.T != TupleTy || elem.FieldByName(ToCamelCase(arg.Name)).IsValid()) {
+		abi2struct, err := mapArgNamesToStructFields(arguments.names(), elem)
+		if err != nil {
 			return err
 		}
-		arg := arguments.NonIndexed()[0]
 		if structField, ok := abi2struct[arg.Name]; ok {
 			return set(elem.FieldByName(structField), reflectValue, arg)
 		}
 		return nil
 	}
-
-	return set(elem, reflectValue, arguments.NonIndexed()[0])
+	return set(elem, reflectValue, arg)
 
 }
 
-// Computes the full size of an array;
-// i.e. counting nested arrays, which count towards size for unpacking.
-func getArraySize(arr *Type) int {
-	size := arr.Size
-	// Arrays can be nested, with each element being the same size
-	arr = arr.Elem
-	for arr.T == ArrayTy {
-		// Keep multiplying by elem.Size while the elem is an array.
-		size *= arr.Size
-		arr = arr.Elem
+// names returns the names of all the arguments.
+func (arguments Arguments) names() []string {
+	var names []string
+	for _, arg := range arguments {
+		names = append(names, arg.Name)
 	}
-	// Now we have the full array size, including its children.
-	return size
+	return names
 }
 
 // UnpackValues can be used to unpack ABI-encoded hexdata according to the ABI-specification,
@@ -202,7 +202,7 @@ func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
 	virtualArgs := 0
 	for index, arg := range arguments.NonIndexed() {
 		marshalledValue, err := toGoType((index+virtualArgs)*32, arg.Type, data)
-		if arg.Type.T == ArrayTy {
+		if (arg.Type.T == ArrayTy || arg.Type.T == TupleTy) && !isDynamicType(arg.Type) {
 			// If we have a static array, like [3]uint256, these are coded as
 			// just like uint256,uint256,uint256.
 			// This means that we need to add two 'virtual' arguments when
@@ -211,9 +211,12 @@ func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
 			// Array values nested multiple levels deep are also encoded inline:
 			// [2][3]uint256: uint256,uint256,uint256,uint256,uint256,uint256
 			//
-			// Calculate the full array size to get the correct offset for the next argument.
+			// The same holds for static tuples, which are encoded like their
+			// fields: (uint256,[2]uint256): uint256,uint256,uint256
+			//
+			// Calculate the full size to get the correct offset for the next argument.
 			// Decrement it by 1, as the normal index increment is still applied.
-			virtualArgs += getArraySize(&arg.Type) - 1
+			virtualArgs += getTypeSize(arg.Type)/32 - 1
 		}
 		if err != nil {
 			return nil, err
@@ -243,11 +246,7 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 	// input offset is the bytes offset for packed output
 	inputOffset := 0
 	for _, abiArg := range abiArgs {
-		if abiArg.Type.T == ArrayTy {
-			inputOffset += 32 * abiArg.Type.Size
-		} else {
-			inputOffset += 32
-		}
+		inputOffset += getTypeSize(abiArg.Type)
 	}
 	var ret []byte
 	for i, a := range args {
@@ -257,8 +256,8 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		// check for a slice type (string, bytes, slice)
-		if input.Type.requiresLengthPrefix() {
+		// check for dynamic types (string, bytes, slice, dynamic arrays and tuples)
+		if isDynamicType(input.Type) {
 			// calculate the offset
 			offset := inputOffset + len(variableInput)
 			// set the offset
@@ -277,14 +276,14 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 	return ret, nil
 }
 
-// capitalise makes the first character of a string upper case, also removing any
-// prefixing underscores from the variable names.
-func capitalise(input string) string {
-	for len(input) > 0 && input[0] == '_' {
-		input = input[1:]
-	}
-	if len(input) == 0 {
-		return ""
+// ToCamelCase converts an under-score string to a camel-case string, also
+// removing any prefixing underscores from the variable names.
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, s := range parts {
+		if len(s) > 0 {
+			parts[i] = strings.ToUpper(s[:1]) + s[1:]
+		}
 	}
-	return strings.ToUpper(input[:1]) + input[1:]
+	return strings.Join(parts, "")
 }
@@ -0,0 +1,367 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// forkRequestTimeout is the maximum time allowed for retrieving a piece of state
+// from the remote node.
+const forkRequestTimeout = 30 * time.Second
+
+// NewForkedBackend creates a new binding backend using a simulated blockchain
+// whose state is forked off a live chain at the given block (nil = latest). The
+// accounts, code and storage are retrieved lazily over RPC from the node at the
+// given URL, verified against the state root of the block and cached locally.
+//
+// The simulated chain starts with a fresh genesis block carrying the timestamp
+// of the forked block. The accounts in alloc override the remote ones.
+func NewForkedBackend(rawurl string, number *big.Int, alloc core.GenesisAlloc, gasLimit uint64) (*SimulatedBackend, error) {
+	client, err := rpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), forkRequestTimeout)
+	defer cancel()
+
+	header, err := ethclient.NewClient(client).HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve fork header: %v", err)
+	}
+	database := ethdb.NewMemDatabase()
+	source := &forkSource{
+		client:   client,
+		number:   header.Number,
+		root:     header.Root,
+		db:       database,
+		accounts: make(map[common.Address]*state.Account),
+		owners:   make(map[common.Hash]common.Address),
+		slots:    make(map[common.Address]map[common.Hash][]byte),
+		resolved: make(map[forkTrieID]map[string]struct{}),
+	}
+	stateDB := func(db ethdb.Database) state.Database {
+		return &forkStateDatabase{Database: state.NewDatabase(db), source: source}
+	}
+
+	genesis := core.Genesis{
+		Config:    params.AllEthashProtocolChanges,
+		Timestamp: header.Time.Uint64(),
+		GasLimit:  gasLimit,
+		Alloc:     alloc,
+	}
+	return newSimulatedBackend(database, stateDB, &genesis), nil
+}
+
+// forkSource retrieves the state of a remote chain at a fixed block, verifying
+// the responses against the state root of the block.
+type forkSource struct {
+	client *rpc.Client
+	number *big.Int       // Number of the forked block
+	root   common.Hash    // State root of the forked block
+	db     ethdb.Database // Local database to store the retrieved contract code into
+
+	accounts map[common.Address]*state.Account         // Remote accounts retrieved so far (nil = nonexistent)
+	owners   map[common.Hash]common.Address            // Account address preimages to resolve storage tries
+	slots    map[common.Address]map[common.Hash][]byte // Remote storage slots retrieved so far
+	resolved map[forkTrieID]map[string]struct{}        // Keys resolved in the committed local tries
+	lock     sync.Mutex
+}
+
+// forkTrieID identifies a committed local trie. Storage tries are keyed by their
+// owner too, since accounts with the same storage may have resolved different
+// keys. The account trie has no owner.
+type forkTrieID struct {
+	owner common.Hash
+	root  common.Hash
+}
+
+// forkProof is the subset of the eth_getProof response needed to verify the
+// remote state.
+type forkProof struct {
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	StorageProof []struct {
+		Proof []hexutil.Bytes `json:"proof"`
+	} `json:"storageProof"`
+}
+
+// verifyProof checks a Merkle proof against the given root and returns the
+// proven value of the key, or nil if the key does not exist.
+func verifyProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	db := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, _, err := trie.VerifyProof(root, crypto.Keccak256(key), db)
+	return value, err
+}
+
+// account retrieves the remote account with the given address, along with its
+// code. The storage root of the returned account is the remote one.
+func (s *forkSource) account(addr common.Address) (*state.Account, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if account, ok := s.accounts[addr]; ok {
+		return account, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), forkRequestTimeout)
+	defer cancel()
+
+	var proof forkProof
+	if err := s.client.CallContext(ctx, &proof, "eth_getProof", addr, []string{}, hexutil.EncodeBig(s.number)); err != nil {
+		return nil, err
+	}
+	blob, err := verifyProof(s.root, addr[:], proof.AccountProof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account proof for %x: %v", addr, err)
+	}
+	var account *state.Account
+	if blob != nil {
+		account = new(state.Account)
+		if err := rlp.DecodeBytes(blob, account); err != nil {
+			return nil, err
+		}
+		// Retrieve the code of contracts into the local database
+		if !bytes.Equal(account.CodeHash, crypto.Keccak256(nil)) {
+			code, err := ethclient.NewClient(s.client).CodeAt(ctx, addr, s.number)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(crypto.Keccak256(code), account.CodeHash) {
+				return nil, fmt.Errorf("code hash mismatch for %x", addr)
+			}
+			s.db.Put(account.CodeHash, code)
+		}
+	}
+	s.accounts[addr] = account
+	s.owners[crypto.Keccak256Hash(addr[:])] = addr
+	return account, nil
+}
+
+// storage retrieves a remote storage slot of the account with the given address
+// hash, returning it RLP encoded as stored in the trie.
+func (s *forkSource) storage(addrHash common.Hash, slot common.Hash) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Accounts are always resolved before their storage, if the owner is unknown
+	// or has no storage, there's nothing to retrieve
+	addr, ok := s.owners[addrHash]
+	account := s.accounts[addr]
+	if !ok || account == nil || account.Root == types.EmptyRootHash {
+		return nil, nil
+	}
+	if value, ok := s.slots[addr][slot]; ok {
+		return value, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), forkRequestTimeout)
+	defer cancel()
+
+	var proof forkProof
+	if err := s.client.CallContext(ctx, &proof, "eth_getProof", addr, []string{slot.Hex()}, hexutil.EncodeBig(s.number)); err != nil {
+		return nil, err
+	}
+	if len(proof.StorageProof) != 1 {
+		return nil, fmt.Errorf("missing storage proof for %x/%x", addr, slot)
+	}
+	value, err := verifyProof(account.Root, slot[:], proof.StorageProof[0].Proof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage proof for %x/%x: %v", addr, slot, err)
+	}
+	if s.slots[addr] == nil {
+		s.slots[addr] = make(map[common.Hash][]byte)
+	}
+	s.slots[addr][slot] = value
+	return value, nil
+}
+
+// resolvedKeys returns a copy of the keys resolved in the given committed trie.
+func (s *forkSource) resolvedKeys(id forkTrieID) map[string]struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	keys := make(map[string]struct{}, len(s.resolved[id]))
+	for key := range s.resolved[id] {
+		keys[key] = struct{}{}
+	}
+	return keys
+}
+
+// storeResolved records the keys resolved in a committed trie.
+func (s *forkSource) storeResolved(id forkTrieID, keys map[string]struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	known := s.resolved[id]
+	if known == nil {
+		known = make(map[string]struct{}, len(keys))
+		s.resolved[id] = known
+	}
+	for key := range keys {
+		known[key] = struct{}{}
+	}
+}
+
+// forkStateDatabase is a state database opening tries which resolve the keys
+// missing locally from the fork source.
+type forkStateDatabase struct {
+	state.Database
+	source *forkSource
+}
+
+// OpenTrie opens the main account trie, resolving missing accounts remotely.
+func (db *forkStateDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return db.newTrie(tr, common.Hash{}, root, db.fetchAccount), nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, resolving missing slots
+// remotely.
+func (db *forkStateDatabase) OpenStorageTrie(addrHash, root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenStorageTrie(addrHash, root)
+	if err != nil {
+		return nil, err
+	}
+	fetch := func(key []byte) ([]byte, error) {
+		return db.source.storage(addrHash, common.BytesToHash(key))
+	}
+	return db.newTrie(tr, addrHash, root, fetch), nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *forkStateDatabase) CopyTrie(t state.Trie) state.Trie {
+	tr := t.(*forkTrie)
+	cpy := *tr
+	cpy.Trie = db.Database.CopyTrie(tr.Trie)
+	cpy.resolved = make(map[string]struct{}, len(tr.resolved))
+	for key := range tr.resolved {
+		cpy.resolved[key] = struct{}{}
+	}
+	return &cpy
+}
+
+// newTrie wraps a local trie, restoring the keys resolved when it was committed.
+func (db *forkStateDatabase) newTrie(tr state.Trie, owner, root common.Hash, fetch func([]byte) ([]byte, error)) *forkTrie {
+	return &forkTrie{
+		Trie:     tr,
+		owner:    owner,
+		source:   db.source,
+		fetch:    fetch,
+		resolved: db.source.resolvedKeys(forkTrieID{owner, root}),
+	}
+}
+
+// fetchAccount retrieves a remote account and encodes it for the local trie. As
+// the remote storage trie is not available locally, the account's storage root
+// is replaced with an empty one, which in turn resolves its slots lazily.
+func (db *forkStateDatabase) fetchAccount(key []byte) ([]byte, error) {
+	account, err := db.source.account(common.BytesToAddress(key))
+	if err != nil || account == nil {
+		return nil, err
+	}
+	local := *account
+	local.Root = types.EmptyRootHash
+	return rlp.EncodeToBytes(&local)
+}
+
+// forkTrie is a state trie which resolves keys missing locally from the remote
+// state and caches them in the trie. The resolved keys are tracked outside of
+// the trie, so deleting them locally does not trigger retrieving them again.
+//
+// Note, the storage of accounts recreated after a self-destruct is resolved from
+// the remote state again.
+type forkTrie struct {
+	state.Trie
+	owner    common.Hash                      // Address hash of the storage trie's account
+	source   *forkSource                      // Fork source to store the resolved keys into
+	fetch    func(key []byte) ([]byte, error) // Retrieves the remote value of a key
+	resolved map[string]struct{}              // Keys resolved from the remote state or set locally
+}
+
+// TryGet returns the value for key stored in the trie, retrieving it from the
+// remote state if it was never resolved yet.
+func (t *forkTrie) TryGet(key []byte) ([]byte, error) {
+	if value, err := t.Trie.TryGet(key); value != nil || err != nil {
+		return value, err
+	}
+	if _, ok := t.resolved[string(key)]; ok {
+		return nil, nil
+	}
+	value, err := t.fetch(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) > 0 {
+		if err := t.Trie.TryUpdate(key, value); err != nil {
+			return nil, err
+		}
+	}
+	t.resolved[string(key)] = struct{}{}
+	return value, nil
+}
+
+// TryUpdate associates key with value in the trie, marking it as resolved.
+func (t *forkTrie) TryUpdate(key, value []byte) error {
+	if err := t.Trie.TryUpdate(key, value); err != nil {
+		return err
+	}
+	t.resolved[string(key)] = struct{}{}
+	return nil
+}
+
+// TryDelete removes any existing value for key from the trie, marking it as
+// resolved.
+func (t *forkTrie) TryDelete(key []byte) error {
+	if err := t.Trie.TryDelete(key); err != nil {
+		return err
+	}
+	t.resolved[string(key)] = struct{}{}
+	return nil
+}
+
+// Commit writes all nodes to the trie's database and records the resolved keys
+// of the committed trie, so they are known when it is opened again.
+func (t *forkTrie) Commit(onleaf trie.LeafCallback) (common.Hash, error) {
+	root, err := t.Trie.Commit(onleaf)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	t.source.storeResolved(forkTrieID{t.owner, root}, t.resolved)
+	return root, nil
+}
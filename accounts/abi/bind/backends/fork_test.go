@@ -0,0 +1,245 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// forkCounterCode is the runtime code of a contract incrementing and returning
+// the value in its storage slot 0 on every call.
+var forkCounterCode = common.FromHex("600054600101806000556000526020" + "6000f3")
+
+// ForkStubAPI is a minimal eth namespace serving the state of a simulated chain,
+// acting as the remote node to fork off.
+type ForkStubAPI struct {
+	chain *core.BlockChain
+	calls int // Number of proofs requested
+}
+
+func (api *ForkStubAPI) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	if number < 0 {
+		return api.chain.CurrentHeader(), nil
+	}
+	return api.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (api *ForkStubAPI) GetCode(address common.Address, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	statedb, err := api.chain.StateAt(api.chain.GetHeaderByNumber(uint64(number)).Root)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(address), nil
+}
+
+func (api *ForkStubAPI) GetProof(address common.Address, keys []string, number rpc.BlockNumber) (map[string]interface{}, error) {
+	api.calls++
+
+	statedb, err := api.chain.StateAt(api.chain.GetHeaderByNumber(uint64(number)).Root)
+	if err != nil {
+		return nil, err
+	}
+	accountProof, err := statedb.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	var storageProof []map[string]interface{}
+	for _, key := range keys {
+		proof, err := statedb.GetStorageProof(address, common.HexToHash(key))
+		if err != nil {
+			return nil, err
+		}
+		storageProof = append(storageProof, map[string]interface{}{"key": key, "proof": common.ToHexArray(proof)})
+	}
+	return map[string]interface{}{
+		"accountProof": common.ToHexArray(accountProof),
+		"storageProof": storageProof,
+	}, nil
+}
+
+// balance is a helper to retrieve the current balance of an account.
+func (b *SimulatedBackend) balance(ctx context.Context, addr common.Address) *big.Int {
+	balance, _ := b.BalanceAt(ctx, addr, nil)
+	return balance
+}
+
+// Tests that a simulated backend can be forked off a remote chain, lazily
+// retrieving its state and building new blocks on top.
+func TestForkedBackend(t *testing.T) {
+	var (
+		remoteKey, _ = crypto.GenerateKey()
+		remoteAddr   = crypto.PubkeyToAddress(remoteKey.PublicKey)
+		localKey, _  = crypto.GenerateKey()
+		localAddr    = crypto.PubkeyToAddress(localKey.PublicKey)
+		counter      = common.HexToAddress("0xc0ffee")
+		ctx          = context.Background()
+	)
+	// Create the remote chain: the counter is at 41, 42 and 43 in blocks 0, 1, 2
+	remote := NewSimulatedBackend(core.GenesisAlloc{
+		remoteAddr: {Balance: big.NewInt(1000000000000000000)},
+		counter:    {Balance: new(big.Int), Code: forkCounterCode, Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(41)), {1}: common.BigToHash(big.NewInt(7))}},
+	}, 10000000)
+
+	signer := types.HomesteadSigner{}
+	for i := uint64(0); i < 2; i++ {
+		tx, _ := types.SignTx(types.NewTransaction(i, counter, big.NewInt(0), 100000, big.NewInt(1), nil), signer, remoteKey)
+		if err := remote.SendTransaction(ctx, tx); err != nil {
+			t.Fatalf("failed to send remote transaction: %v", err)
+		}
+		remote.Commit()
+	}
+	stub := &ForkStubAPI{chain: remote.blockchain}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", stub); err != nil {
+		t.Fatalf("failed to register stub API: %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// Fork the remote chain at block 1 and check the state is retrieved
+	sim, err := NewForkedBackend(httpServer.URL, big.NewInt(1), core.GenesisAlloc{localAddr: {Balance: big.NewInt(1000000000000000000)}}, 10000000)
+	if err != nil {
+		t.Fatalf("failed to fork remote chain: %v", err)
+	}
+	if have, want := sim.blockchain.Genesis().Time(), remote.blockchain.GetHeaderByNumber(1).Time; have.Cmp(want) != 0 {
+		t.Errorf("genesis time mismatch: have %v, want %v", have, want)
+	}
+	if code, _ := sim.CodeAt(ctx, counter, nil); !bytes.Equal(code, forkCounterCode) {
+		t.Errorf("code mismatch: have %x, want %x", code, forkCounterCode)
+	}
+	forked, _ := remote.blockchain.StateAt(remote.blockchain.GetHeaderByNumber(1).Root)
+	if have, want := sim.balance(ctx, remoteAddr), forked.GetBalance(remoteAddr); have.Cmp(want) != 0 {
+		t.Errorf("balance mismatch: have %v, want %v", have, want)
+	}
+	if slot, _ := sim.StorageAt(ctx, counter, common.Hash{1}, nil); new(big.Int).SetBytes(slot).Uint64() != 7 {
+		t.Errorf("untouched slot mismatch: have %x, want 7", slot)
+	}
+	out, err := sim.CallContract(ctx, ethereum.CallMsg{To: &counter}, nil)
+	if err != nil {
+		t.Fatalf("failed to call counter: %v", err)
+	}
+	if have := new(big.Int).SetBytes(out).Uint64(); have != 43 {
+		t.Errorf("call result mismatch: have %d, want 43", have)
+	}
+	// Transact on top of the fork, roll back and adjust the time
+	tx, _ := types.SignTx(types.NewTransaction(0, counter, big.NewInt(0), 100000, big.NewInt(1), nil), signer, localKey)
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send local transaction: %v", err)
+	}
+	sim.Rollback()
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to resend local transaction: %v", err)
+	}
+	sim.Commit()
+
+	if slot, _ := sim.StorageAt(ctx, counter, common.Hash{}, nil); new(big.Int).SetBytes(slot).Uint64() != 43 {
+		t.Errorf("counter mismatch after transaction: have %x, want 43", slot)
+	}
+	if err := sim.AdjustTime(time.Hour); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	sim.Commit()
+
+	if slot, _ := sim.StorageAt(ctx, counter, common.Hash{}, nil); new(big.Int).SetBytes(slot).Uint64() != 43 {
+		t.Errorf("counter mismatch after time adjustment: have %x, want 43", slot)
+	}
+	// Ensure the remote state was cached and not retrieved again
+	calls := stub.calls
+	if calls == 0 {
+		t.Fatalf("no state retrieved from the remote node")
+	}
+	sim.StorageAt(ctx, counter, common.Hash{1}, nil)
+	sim.balance(ctx, remoteAddr)
+	if stub.calls != calls {
+		t.Errorf("cached state retrieved again: %d proofs requested, want %d", stub.calls, calls)
+	}
+}
+
+// Tests that keys deleted locally are not retrieved from the remote state again,
+// also after the trie is committed and reopened, and that the resolved keys are
+// not stored in the trie itself.
+func TestForkTrieDelete(t *testing.T) {
+	source := &forkSource{resolved: make(map[forkTrieID]map[string]struct{})}
+	db := &forkStateDatabase{Database: state.NewDatabase(ethdb.NewMemDatabase()), source: source}
+
+	fetches := 0
+	fetch := func(key []byte) ([]byte, error) {
+		fetches++
+		return []byte("remote"), nil
+	}
+	owner := common.Hash{1}
+	open := func(root common.Hash) *forkTrie {
+		tr, err := db.Database.OpenStorageTrie(owner, root)
+		if err != nil {
+			t.Fatalf("failed to open trie %x: %v", root, err)
+		}
+		return db.newTrie(tr, owner, root, fetch)
+	}
+	a, b := []byte("a"), []byte("b")
+
+	tr := open(types.EmptyRootHash)
+	for _, key := range [][]byte{a, b} {
+		if value, _ := tr.TryGet(key); string(value) != "remote" {
+			t.Fatalf("value mismatch for %q: have %q, want %q", key, value, "remote")
+		}
+	}
+	if err := tr.TryDelete(b); err != nil {
+		t.Fatalf("failed to delete key: %v", err)
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	// Reopen the committed trie, the deleted key must stay deleted
+	tr = open(root)
+	if value, _ := tr.TryGet(a); string(value) != "remote" {
+		t.Errorf("value mismatch after reopen: have %q, want %q", value, "remote")
+	}
+	if value, _ := tr.TryGet(b); value != nil {
+		t.Errorf("deleted key resolved again: %q", value)
+	}
+	if fetches != 2 {
+		t.Errorf("fetch count mismatch: have %d, want 2", fetches)
+	}
+	// The trie must only contain the remaining key
+	plain, _ := trie.NewSecure(common.Hash{}, trie.NewDatabase(ethdb.NewMemDatabase()), 0)
+	plain.Update(a, []byte("remote"))
+	if plain.Hash() != root {
+		t.Errorf("root mismatch: have %x, want %x", root, plain.Hash())
+	}
+	// The original trie has not resolved anything yet
+	tr = open(types.EmptyRootHash)
+	if value, _ := tr.TryGet(b); string(value) != "remote" {
+		t.Errorf("value mismatch in original trie: have %q, want %q", value, "remote")
+	}
+}
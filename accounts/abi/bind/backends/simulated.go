@@ -51,8 +51,9 @@ var errGasEstimationFailed = errors.New("gas required exceeds allowance or alway
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
 // the background. Its main purpose is to allow easily testing contract bindings.
 type SimulatedBackend struct {
-	database   ethdb.Database   // In memory database to store our testing data
-	blockchain *core.BlockChain // Ethereum blockchain to handle the consensus
+	database   ethdb.Database                      // In memory database to store our testing data
+	stateDB    func(ethdb.Database) state.Database // Opens the state databases on top of the database
+	blockchain *core.BlockChain                    // Ethereum blockchain to handle the consensus
 
 	mu           sync.Mutex
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
@@ -66,13 +67,21 @@ type SimulatedBackend struct {
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
 // for testing purposes.
 func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
-	database := ethdb.NewMemDatabase()
 	genesis := core.Genesis{Config: params.AllEthashProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
+	return newSimulatedBackend(ethdb.NewMemDatabase(), state.NewDatabase, &genesis)
+}
+
+// newSimulatedBackend creates a simulated blockchain on top of the given database,
+// starting from the given genesis. The state of the chain is accessed through the
+// state databases opened by stateDB.
+func newSimulatedBackend(database ethdb.Database, stateDB func(ethdb.Database) state.Database, genesis *core.Genesis) *SimulatedBackend {
 	genesis.MustCommit(database)
 	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, ethash.NewFaker(), vm.Config{}, nil)
+	blockchain.SetStateDatabase(stateDB(database))
 
 	backend := &SimulatedBackend{
 		database:   database,
+		stateDB:    stateDB,
 		blockchain: blockchain,
 		config:     genesis.Config,
 		events:     filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false),
@@ -102,13 +111,19 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) rollback() {
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(int, *core.BlockGen) {})
+	block := b.generateBlock(func(int, *core.BlockGen) {})
 	statedb, _ := b.blockchain.State()
 
-	b.pendingBlock = blocks[0]
+	b.pendingBlock = block
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), statedb.Database())
 }
 
+// generateBlock creates a new block on top of the current head.
+func (b *SimulatedBackend) generateBlock(gen func(int, *core.BlockGen)) *types.Block {
+	blocks, _ := core.GenerateChainWithState(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, b.stateDB(b.database), 1, gen)
+	return blocks[0]
+}
+
 // CodeAt returns the code associated with a certain account in the blockchain.
 func (b *SimulatedBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
@@ -306,7 +321,7 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
 
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+	block := b.generateBlock(func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
 			block.AddTxWithChain(b.blockchain, tx)
 		}
@@ -314,7 +329,7 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	})
 	statedb, _ := b.blockchain.State()
 
-	b.pendingBlock = blocks[0]
+	b.pendingBlock = block
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), statedb.Database())
 	return nil
 }
@@ -391,7 +406,7 @@ func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query ethere
 func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+	block := b.generateBlock(func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
 			block.AddTx(tx)
 		}
@@ -399,7 +414,7 @@ func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	})
 	statedb, _ := b.blockchain.State()
 
-	b.pendingBlock = blocks[0]
+	b.pendingBlock = block
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), statedb.Database())
 
 	return nil
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"go/format"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 	"unicode"
@@ -39,6 +40,8 @@ const (
 	LangGo Lang = iota
 	LangJava
 	LangObjC
+	LangTypeScript
+	LangPython
 )
 
 // Bind generates a Go wrapper around a contract ABI. This wrapper isn't meant
@@ -47,7 +50,10 @@ const (
 // manually maintain hard coded strings that break on runtime.
 func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
 	// Process each individual contract requested binding
-	contracts := make(map[string]*tmplContract)
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct) // Tuple types shared by all contracts
+	)
 
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
@@ -63,6 +69,11 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 			return r
 		}, abis[i])
 
+		// Generate the structs of all tuple types in a deterministic order
+		if err := bindStructs(evmABI, lang, structs); err != nil {
+			return "", err
+		}
+
 		// Extract the call and transact methods; events; and sort them alphabetically
 		var (
 			calls     = make(map[string]*tmplMethod)
@@ -131,15 +142,38 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		Structs:   structs,
 	}
 	buffer := new(bytes.Buffer)
 
 	funcs := map[string]interface{}{
-		"bindtype":      bindType[lang],
-		"bindtopictype": bindTopicType[lang],
-		"namedtype":     namedType[lang],
-		"capitalise":    capitalise,
-		"decapitalise":  decapitalise,
+		"bindtype": func(kind abi.Type) string {
+			return bindType[lang](kind, structs)
+		},
+		"bindtopictype": func(kind abi.Type) string {
+			return bindTopicType[lang](kind, structs)
+		},
+		"bindinputtype": func(kind abi.Type) string {
+			if binder, ok := bindInputType[lang]; ok {
+				return binder(kind, structs)
+			}
+			return bindType[lang](kind, structs)
+		},
+		"identifier": func(name string) string {
+			if normalizer, ok := identifier[lang]; ok {
+				return normalizer(name)
+			}
+			return name
+		},
+		"convert": func(kind abi.Type, expr string) string {
+			if converter, ok := convertValue[lang]; ok {
+				return converter(kind, expr, structs)
+			}
+			return expr
+		},
+		"namedtype":    namedType[lang],
+		"capitalise":   capitalise,
+		"decapitalise": decapitalise,
 	}
 	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource[lang]))
 	if err := tmpl.Execute(buffer, data); err != nil {
@@ -157,11 +191,70 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 	return buffer.String(), nil
 }
 
+// bindStructs collects the tuple types used by the methods and events of an ABI
+// into language specific struct definitions. Methods and events are visited in
+// alphabetical order to keep the generated struct names stable.
+func bindStructs(evmABI abi.ABI, lang Lang, structs map[string]*tmplStruct) error {
+	var args []abi.Argument
+
+	methods := make([]string, 0, len(evmABI.Methods))
+	for name := range evmABI.Methods {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+
+	args = append(args, evmABI.Constructor.Inputs...)
+	for _, name := range methods {
+		args = append(args, evmABI.Methods[name].Inputs...)
+		args = append(args, evmABI.Methods[name].Outputs...)
+	}
+	events := make([]string, 0, len(evmABI.Events))
+	for name := range evmABI.Events {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+
+	for _, name := range events {
+		args = append(args, evmABI.Events[name].Inputs...)
+	}
+	for _, arg := range args {
+		if !hasTuple(arg.Type) {
+			continue
+		}
+		if lang != LangGo && lang != LangTypeScript && lang != LangPython {
+			return fmt.Errorf("tuple argument %q is only supported in Go, TypeScript and Python bindings", arg.Name)
+		}
+		bindType[lang](arg.Type, structs)
+	}
+	return nil
+}
+
+// hasTuple reports whether a type is or contains a tuple.
+func hasTuple(kind abi.Type) bool {
+	switch kind.T {
+	case abi.TupleTy:
+		return true
+	case abi.ArrayTy, abi.SliceTy:
+		return hasTuple(*kind.Elem)
+	default:
+		return false
+	}
+}
+
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language types.
-var bindType = map[Lang]func(kind abi.Type) string{
-	LangGo:   bindTypeGo,
-	LangJava: bindTypeJava,
+var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:         bindTypeGo,
+	LangJava:       bindTypeJava,
+	LangTypeScript: bindTypeTypeScript,
+	LangPython:     bindTypePython,
+}
+
+// bindInputType is a set of type binders for languages which accept a wider set
+// of types for method arguments than they return (e.g. numbers or strings for big
+// integers). Languages without an entry use the plain type binder.
+var bindInputType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangTypeScript: bindInputTypeTypeScript,
 }
 
 // Helper function for the binding generators.
@@ -184,25 +277,47 @@ func wrapArray(stringKind string, innerLen int, innerMapping string) (string, []
 	return innerMapping, parts
 }
 
-// Translates the array sizes to a Go-lang declaration of a (nested) array of the inner type.
-// Simply returns the inner type if arraySizes is empty.
-func arrayBindingGo(inner string, arraySizes []string) string {
-	out := ""
-	//prepend all array sizes, from outer (end arraySizes) to inner (start arraySizes)
-	for i := len(arraySizes) - 1; i >= 0; i-- {
-		out += "[" + arraySizes[i] + "]"
+// bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
+// from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
+// mapped will use an upscaled type (e.g. *big.Int). Tuples are mapped to named
+// structs, which are added to the struct set if not yet present.
+func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return bindStructTypeGo(kind, structs)
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.Size) + bindTypeGo(*kind.Elem, structs)
+	case abi.SliceTy:
+		return "[]" + bindTypeGo(*kind.Elem, structs)
+	default:
+		_, mapped := bindUnnestedTypeGo(kind.String())
+		return mapped
 	}
-	out += inner
-	return out
 }
 
-// bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
-// from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
-// mapped will use an upscaled type (e.g. *big.Int).
-func bindTypeGo(kind abi.Type) string {
-	stringKind := kind.String()
-	innerLen, innerMapping := bindUnnestedTypeGo(stringKind)
-	return arrayBindingGo(wrapArray(stringKind, innerLen, innerMapping))
+// bindStructTypeGo converts a Solidity tuple to a Go struct, returning the name
+// of the struct. Tuples with the same field names and types share a struct.
+func bindStructTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	var (
+		fields []*tmplField
+		key    []string
+	)
+	for i, elem := range kind.TupleElems {
+		field := &tmplField{
+			Type:    bindTypeGo(*elem, structs),
+			Name:    abi.ToCamelCase(kind.TupleRawNames[i]),
+			SolKind: *elem,
+		}
+		fields = append(fields, field)
+		key = append(key, field.Name+" "+field.Type)
+	}
+	id := strings.Join(key, ";")
+	if s, exist := structs[id]; exist {
+		return s.Name
+	}
+	name := fmt.Sprintf("Struct%d", len(structs))
+	structs[id] = &tmplStruct{Name: name, Fields: fields}
+	return name
 }
 
 // The inner function of bindTypeGo, this finds the inner type of stringKind.
@@ -247,7 +362,7 @@ func arrayBindingJava(inner string, arraySizes []string) string {
 // bindTypeJava converts a Solidity type to a Java one. Since there is no clear mapping
 // from all Solidity types to Java ones (e.g. uint17), those that cannot be exactly
 // mapped will use an upscaled type (e.g. BigDecimal).
-func bindTypeJava(kind abi.Type) string {
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
 	stringKind := kind.String()
 	innerLen, innerMapping := bindUnnestedTypeJava(stringKind)
 	return arrayBindingJava(wrapArray(stringKind, innerLen, innerMapping))
@@ -308,18 +423,156 @@ func bindUnnestedTypeJava(stringKind string) (int, string) {
 	}
 }
 
+// bindTypeTypeScript converts a Solidity type to the TypeScript one returned by
+// ethers.js. Integers of up to 48 bits fit into a JavaScript number, larger ones
+// are mapped to BigNumber.
+func bindTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return bindStructTypeTypeScript(kind, structs)
+	case abi.ArrayTy, abi.SliceTy:
+		return bindTypeTypeScript(*kind.Elem, structs) + "[]"
+	case abi.IntTy, abi.UintTy:
+		if kind.Size <= 48 {
+			return "number"
+		}
+		return "ethers.utils.BigNumber"
+	case abi.BoolTy:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// bindStructTypeTypeScript converts a Solidity tuple to a TypeScript interface,
+// returning the name of the interface. The fields keep their Solidity names, as
+// ethers.js uses those to access the tuple values.
+func bindStructTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	var (
+		fields []*tmplField
+		key    []string
+	)
+	for i, elem := range kind.TupleElems {
+		field := &tmplField{
+			Type:    bindTypeTypeScript(*elem, structs),
+			Name:    kind.TupleRawNames[i],
+			SolKind: *elem,
+		}
+		fields = append(fields, field)
+		key = append(key, field.Name+" "+field.Type)
+	}
+	id := strings.Join(key, ";")
+	if s, exist := structs[id]; exist {
+		return s.Name
+	}
+	name := fmt.Sprintf("Struct%d", len(structs))
+	structs[id] = &tmplStruct{Name: name, Fields: fields}
+	return name
+}
+
+// bindInputTypeTypeScript converts a Solidity type to the TypeScript one accepted
+// by ethers.js as a method argument.
+func bindInputTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.ArrayTy, abi.SliceTy:
+		return bindInputTypeTypeScript(*kind.Elem, structs) + "[]"
+	case abi.IntTy, abi.UintTy:
+		return "ethers.utils.BigNumberish"
+	case abi.FixedBytesTy, abi.BytesTy:
+		return "ethers.utils.Arrayish"
+	default:
+		return bindTypeTypeScript(kind, structs)
+	}
+}
+
+// bindTypePython converts a Solidity type to a Python type hint matching the
+// values accepted and returned by web3.py.
+func bindTypePython(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return bindStructTypePython(kind, structs)
+	case abi.ArrayTy, abi.SliceTy:
+		return "List[" + bindTypePython(*kind.Elem, structs) + "]"
+	case abi.IntTy, abi.UintTy:
+		return "int"
+	case abi.BoolTy:
+		return "bool"
+	case abi.FixedBytesTy, abi.BytesTy:
+		return "bytes"
+	default:
+		return "str"
+	}
+}
+
+// bindStructTypePython converts a Solidity tuple to a Python NamedTuple, returning
+// the name of the class. Fields referencing other tuples are annotated with string
+// forward references, as the classes may be defined in any order.
+func bindStructTypePython(kind abi.Type, structs map[string]*tmplStruct) string {
+	var (
+		fields []*tmplField
+		key    []string
+	)
+	for i, elem := range kind.TupleElems {
+		field := &tmplField{
+			Type:    bindTypePython(*elem, structs),
+			Name:    identifierPython(kind.TupleRawNames[i]),
+			SolKind: *elem,
+		}
+		if hasTuple(*elem) {
+			field.Type = "'" + field.Type + "'"
+		}
+		fields = append(fields, field)
+		key = append(key, field.Name+" "+field.Type)
+	}
+	id := strings.Join(key, ";")
+	if s, exist := structs[id]; exist {
+		return s.Name
+	}
+	name := fmt.Sprintf("Struct%d", len(structs))
+	structs[id] = &tmplStruct{Name: name, Fields: fields}
+	return name
+}
+
+// convertValue is a set of functions that return an expression converting a value
+// decoded by the language's Ethereum library to its bound type. Languages without
+// an entry use the decoded values as is.
+var convertValue = map[Lang]func(kind abi.Type, expr string, structs map[string]*tmplStruct) string{
+	LangPython: convertPython,
+}
+
+// convertPython returns a Python expression converting the value of expr, as
+// decoded by web3.py, to its bound type. Only tuples need converting, web3.py
+// decodes them into plain tuples instead of the generated NamedTuples.
+func convertPython(kind abi.Type, expr string, structs map[string]*tmplStruct) string {
+	var convert func(kind abi.Type, expr string, depth int) string
+	convert = func(kind abi.Type, expr string, depth int) string {
+		switch {
+		case !hasTuple(kind):
+			return expr
+		case kind.T == abi.TupleTy:
+			return bindTypePython(kind, structs) + ".from_abi(" + expr + ")"
+		default:
+			elem := fmt.Sprintf("x%d", depth)
+			return "[" + convert(*kind.Elem, elem, depth+1) + " for " + elem + " in " + expr + "]"
+		}
+	}
+	return convert(kind, expr, 0)
+}
+
 // bindTopicType is a set of type binders that convert Solidity types to some
 // supported programming language topic types.
-var bindTopicType = map[Lang]func(kind abi.Type) string{
-	LangGo:   bindTopicTypeGo,
-	LangJava: bindTopicTypeJava,
+var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:         bindTopicTypeGo,
+	LangJava:       bindTopicTypeJava,
+	LangTypeScript: bindTopicTypeTypeScript,
+	LangPython:     bindTopicTypePython,
 }
 
 // bindTypeGo converts a Solidity topic type to a Go one. It is almost the same
 // funcionality as for simple types, but dynamic types get converted to hashes.
-func bindTopicTypeGo(kind abi.Type) string {
-	bound := bindTypeGo(kind)
-	if bound == "string" || bound == "[]byte" {
+func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	bound := bindTypeGo(kind, structs)
+	if bound == "string" || bound == "[]byte" || kind.T == abi.TupleTy {
 		bound = "common.Hash"
 	}
 	return bound
@@ -327,14 +580,43 @@ func bindTopicTypeGo(kind abi.Type) string {
 
 // bindTypeGo converts a Solidity topic type to a Java one. It is almost the same
 // funcionality as for simple types, but dynamic types get converted to hashes.
-func bindTopicTypeJava(kind abi.Type) string {
-	bound := bindTypeJava(kind)
+func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	bound := bindTypeJava(kind, structs)
 	if bound == "String" || bound == "Bytes" {
 		bound = "Hash"
 	}
 	return bound
 }
 
+// hashedTopic reports whether an indexed event field is stored as the hash of its
+// value instead of the value itself.
+func hashedTopic(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	default:
+		return false
+	}
+}
+
+// bindTopicTypeTypeScript converts a Solidity topic type to a TypeScript one,
+// with dynamic types converted to their hex encoded hashes.
+func bindTopicTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	if hashedTopic(kind) {
+		return "string"
+	}
+	return bindTypeTypeScript(kind, structs)
+}
+
+// bindTopicTypePython converts a Solidity topic type to a Python one, with
+// dynamic types converted to their hashes.
+func bindTopicTypePython(kind abi.Type, structs map[string]*tmplStruct) string {
+	if hashedTopic(kind) {
+		return "bytes"
+	}
+	return bindTypePython(kind, structs)
+}
+
 // namedType is a set of functions that transform language specific types to
 // named versions that my be used inside method names.
 var namedType = map[Lang]func(string, abi.Type) string{
@@ -381,8 +663,62 @@ func namedTypeJava(javaKind string, solKind abi.Type) string {
 // methodNormalizer is a name transformer that modifies Solidity method names to
 // conform to target language naming concentions.
 var methodNormalizer = map[Lang]func(string) string{
-	LangGo:   capitalise,
-	LangJava: decapitalise,
+	LangGo:         capitalise,
+	LangJava:       decapitalise,
+	LangTypeScript: decapitaliseTypeScript,
+	LangPython:     identifierPython,
+}
+
+// identifier is a set of name transformers that convert Solidity argument and
+// field names to valid identifiers of the target language. Languages without
+// an entry use the names as is.
+var identifier = map[Lang]func(string) string{
+	LangTypeScript: identifierTypeScript,
+	LangPython:     identifierPython,
+}
+
+// keywordsTypeScript is the set of reserved words which can't be used as
+// identifiers in TypeScript.
+var keywordsTypeScript = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "implements": true, "import": true, "in": true, "instanceof": true, "interface": true,
+	"let": true, "new": true, "null": true, "package": true, "private": true, "protected": true,
+	"public": true, "return": true, "static": true, "super": true, "switch": true, "this": true,
+	"throw": true, "true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "yield": true,
+}
+
+// keywordsPython is the set of reserved words which can't be used as identifiers
+// in Python.
+var keywordsPython = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true, "def": true,
+	"del": true, "elif": true, "else": true, "except": true, "finally": true, "for": true,
+	"from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// identifierTypeScript converts a name to a camel-case TypeScript identifier,
+// suffixing reserved words with an underscore.
+func identifierTypeScript(input string) string {
+	name := decapitaliseTypeScript(input)
+	if keywordsTypeScript[name] {
+		name += "_"
+	}
+	return name
+}
+
+// identifierPython converts a name to a snake-case Python identifier, suffixing
+// reserved words with an underscore.
+func identifierPython(input string) string {
+	name := toSnakeCase(strings.TrimLeft(input, "_"))
+	if keywordsPython[name] {
+		name += "_"
+	}
+	return name
 }
 
 // capitalise makes a camel-case string which starts with an upper case character.
@@ -407,6 +743,16 @@ func decapitalise(input string) string {
 	return toCamelCase(strings.ToLower(input[:1]) + input[1:])
 }
 
+// decapitaliseTypeScript makes a camel-case string which starts with a lower case
+// character, as used for TypeScript methods and variables.
+func decapitaliseTypeScript(input string) string {
+	name := capitalise(input)
+	if len(name) == 0 {
+		return ""
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
 // toCamelCase converts an under-score string to a camel-case string
 func toCamelCase(input string) string {
 	toupper := false
@@ -431,6 +777,23 @@ func toCamelCase(input string) string {
 	return result
 }
 
+// toSnakeCase converts a camel-case string to a lower case, under-score separated
+// one, keeping acronyms together (e.g. ownerOfNFT to owner_of_nft).
+func toSnakeCase(input string) string {
+	runes := []rune(input)
+
+	result := ""
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && runes[i-1] != '_' {
+			if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				result += "_"
+			}
+		}
+		result += string(unicode.ToLower(r))
+	}
+	return result
+}
+
 // structured checks whether a list of ABI data types has enough information to
 // operate through a proper Go struct or if flat returns are needed.
 func structured(args abi.Arguments) bool {
@@ -17,6 +17,8 @@
 package bind
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -703,7 +705,10 @@ var bindTests = []struct {
 		`6060604052341561000f57600080fd5b61042c8061001e6000396000f300606060405260043610610057576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff168063528300ff1461005c578063630c31e2146100fc578063c7d116dd14610156575b600080fd5b341561006757600080fd5b6100fa600480803590602001908201803590602001908080601f0160208091040260200160405190810160405280939291908181526020018383808284378201915050505050509190803590602001908201803590602001908080601f01602080910402602001604051908101604052809392919081815260200183838082843782019150505050505091905050610194565b005b341561010757600080fd5b610154600480803573ffffffffffffffffffffffffffffffffffffffff16906020019091908035600019169060200190919080351515906020019091908035906020019091905050610367565b005b341561016157600080fd5b610192600480803590602001909190803560010b90602001909190803563ffffffff169060200190919050506103c3565b005b806040518082805190602001908083835b6020831015156101ca57805182526020820191506020810190506020830392506101a5565b6001836020036101000a0380198251168184511680821785525050505050509050019150506040518091039020826040518082805190602001908083835b60208310151561022d5780518252602082019150602081019050602083039250610208565b6001836020036101000a03801982511681845116808217855250505050505090500191505060405180910390207f3281fd4f5e152dd3385df49104a3f633706e21c9e80672e88d3bcddf33101f008484604051808060200180602001838103835285818151815260200191508051906020019080838360005b838110156102c15780820151818401526020810190506102a6565b50505050905090810190601f1680156102ee5780820380516001836020036101000a031916815260200191505b50838103825284818151815260200191508051906020019080838360005b8381101561032757808201518184015260208101905061030c565b50505050905090810190601f1680156103545780820380516001836020036101000a031916815260200191505b5094505050505060405180910390a35050565b81151583600019168573ffffffffffffffffffffffffffffffffffffffff167f1f097de4289df643bd9c11011cc61367aa12983405c021056e706eb5ba1250c8846040518082815260200191505060405180910390a450505050565b8063ffffffff168260010b847f3ca7f3a77e5e6e15e781850bc82e32adfa378a2a609370db24b4d0fae10da2c960405160405180910390a45050505600a165627a7a72305820d1f8a8bbddbc5bb29f285891d6ae1eef8420c52afdc05e1573f6114d8e1714710029`,
 		`[{"constant":false,"inputs":[{"name":"str","type":"string"},{"name":"blob","type":"bytes"}],"name":"raiseDynamicEvent","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"},{"name":"id","type":"bytes32"},{"name":"flag","type":"bool"},{"name":"value","type":"uint256"}],"name":"raiseSimpleEvent","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},{"constant":false,"inputs":[{"name":"number","type":"uint256"},{"name":"short","type":"int16"},{"name":"long","type":"uint32"}],"name":"raiseNodataEvent","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"Addr","type":"address"},{"indexed":true,"name":"Id","type":"bytes32"},{"indexed":true,"name":"Flag","type":"bool"},{"indexed":false,"name":"Value","type":"uint256"}],"name":"SimpleEvent","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"name":"Number","type":"uint256"},{"indexed":true,"name":"Short","type":"int16"},{"indexed":true,"name":"Long","type":"uint32"}],"name":"NodataEvent","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"name":"IndexedString","type":"string"},{"indexed":true,"name":"IndexedBytes","type":"bytes"},{"indexed":false,"name":"NonIndexedString","type":"string"},{"indexed":false,"name":"NonIndexedBytes","type":"bytes"}],"name":"DynamicEvent","type":"event"}]`,
 		`
+			"io/ioutil"
 			"math/big"
+			"os"
+			"path/filepath"
 			"time"
 
 			"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -844,6 +849,69 @@ var bindTests = []struct {
 				t.Fatalf("unsubscribed simple event arrived: %v", event)
 			case <-time.After(250 * time.Millisecond):
 			}
+			// Test streaming past and live events with a persisted cursor
+			dir, err := ioutil.TempDir("", "")
+			if err != nil {
+				t.Fatalf("failed to create temporary directory: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			opts := &bind.StreamOpts{Cursor: bind.NewFileCursor(filepath.Join(dir, "cursor.json"))}
+			updates := make(chan *EventerSimpleEventUpdate, 16)
+			sub, err = eventer.StreamSimpleEvent(opts, updates, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to stream simple events: %v", err)
+			}
+			for _, want := range []uint64{11, 21, 22, 31, 32, 33, 255, 254} {
+				select {
+				case update := <-updates:
+					if update.Removed || update.Event.Value.Uint64() != want {
+						t.Errorf("streamed log content mismatch: have %v (removed %v), want %d", update.Event.Value, update.Removed, want)
+					}
+				case <-time.After(250 * time.Millisecond):
+					t.Fatalf("streamed simple event %d didn't arrive", want)
+				}
+			}
+			if _, err := eventer.RaiseSimpleEvent(auth, common.Address{253}, [32]byte{253}, true, big.NewInt(253)); err != nil {
+				t.Fatalf("failed to raise streamed simple event: %v", err)
+			}
+			sim.Commit()
+
+			select {
+			case update := <-updates:
+				if update.Event.Value.Uint64() != 253 {
+					t.Errorf("streamed log content mismatch: have %v, want 253", update.Event.Value)
+				}
+			case <-time.After(250 * time.Millisecond):
+				t.Fatalf("streamed simple event didn't arrive")
+			}
+			sub.Unsubscribe()
+
+			// Raise an event while disconnected and ensure the stream resumes with it
+			if _, err := eventer.RaiseSimpleEvent(auth, common.Address{252}, [32]byte{252}, true, big.NewInt(252)); err != nil {
+				t.Fatalf("failed to raise streamed simple event: %v", err)
+			}
+			sim.Commit()
+
+			sub, err = eventer.StreamSimpleEvent(opts, updates, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to resume simple event stream: %v", err)
+			}
+			defer sub.Unsubscribe()
+
+			select {
+			case update := <-updates:
+				if update.Event.Value.Uint64() != 252 {
+					t.Errorf("resumed log content mismatch: have %v, want 252", update.Event.Value)
+				}
+			case <-time.After(250 * time.Millisecond):
+				t.Fatalf("resumed simple event didn't arrive")
+			}
+			select {
+			case update := <-updates:
+				t.Fatalf("duplicate event streamed: %v", update.Event.Value)
+			case <-time.After(250 * time.Millisecond):
+			}
 		`,
 	},
 	{
@@ -921,6 +989,77 @@ var bindTests = []struct {
 			}
 		`,
 	},
+	// Tests that tuples are bound to structs and round trip through a contract. The
+	// contract is hand assembled to echo back its call data without the selector,
+	// which for equal input and output types is exactly the expected return data.
+	{
+		`Structer`,
+		`
+			pragma experimental ABIEncoderV2;
+
+			contract Structer {
+				struct Point { uint x; uint y; }
+				struct Order { address maker; uint[] amounts; string note; }
+
+				event OrderPlaced(uint indexed id, Order order);
+
+				function echoPoint(Point p) public pure returns (Point) {}
+				function echoPoints(Point[] ps) public pure returns (Point[]) {}
+				function echoOrder(Order o) public pure returns (Order) {}
+			}
+		`,
+		`600d80600b6000396000f3600436038060046000376000f3`,
+		`[{"constant":true,"inputs":[{"name":"p","type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}],"name":"echoPoint","outputs":[{"name":"p","type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}],"type":"function"},{"constant":true,"inputs":[{"name":"ps","type":"tuple[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}],"name":"echoPoints","outputs":[{"name":"ps","type":"tuple[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}],"type":"function"},{"constant":true,"inputs":[{"name":"o","type":"tuple","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[]"},{"name":"note","type":"string"}]}],"name":"echoOrder","outputs":[{"name":"o","type":"tuple","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[]"},{"name":"note","type":"string"}]}],"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"id","type":"uint256"},{"indexed":false,"name":"order","type":"tuple","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[]"},{"name":"note","type":"string"}]}],"name":"OrderPlaced","type":"event"}]`,
+		`
+			"math/big"
+			"reflect"
+
+			"github.com/ethereum/go-ethereum/accounts/abi/bind"
+			"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+			"github.com/ethereum/go-ethereum/common"
+			"github.com/ethereum/go-ethereum/core"
+			"github.com/ethereum/go-ethereum/crypto"
+		`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}}, 10000000)
+
+			// Deploy the echoing contract and round trip the structs through it
+			_, _, structer, err := DeployStructer(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy structer contract: %v", err)
+			}
+			sim.Commit()
+
+			point := Struct1{X: big.NewInt(1), Y: big.NewInt(2)}
+			if out, err := structer.EchoPoint(nil, point); err != nil {
+				t.Fatalf("Failed to echo point: %v", err)
+			} else if !reflect.DeepEqual(out, point) {
+				t.Fatalf("Point mismatch: have %v, want %v", out, point)
+			}
+			points := []Struct1{point, {X: big.NewInt(3), Y: big.NewInt(4)}}
+			if out, err := structer.EchoPoints(nil, points); err != nil {
+				t.Fatalf("Failed to echo points: %v", err)
+			} else if !reflect.DeepEqual(out, points) {
+				t.Fatalf("Points mismatch: have %v, want %v", out, points)
+			}
+			order := Struct0{Maker: auth.From, Amounts: []*big.Int{big.NewInt(5), big.NewInt(6)}, Note: "structs"}
+			if out, err := structer.EchoOrder(nil, order); err != nil {
+				t.Fatalf("Failed to echo order: %v", err)
+			} else if !reflect.DeepEqual(out, order) {
+				t.Fatalf("Order mismatch: have %v, want %v", out, order)
+			}
+			// Check that the events are bound with the struct fields too
+			if false { // Don't run, just compile and test types
+				var event StructerOrderPlaced
+				var _ *big.Int = event.Id
+				var _ Struct0 = event.Order
+				var _ common.Address = event.Order.Maker
+			}
+		`,
+	},
 }
 
 // Tests that packages generated by the binder can be successfully compiled and
@@ -976,3 +1115,74 @@ func TestBindings(t *testing.T) {
 		t.Fatalf("failed to run binding test: %v\n%s", err, out)
 	}
 }
+
+// updateGolden rewrites the golden files of the binding tests instead of comparing
+// against them.
+var updateGolden = flag.Bool("update", false, "update the golden binding files")
+
+var goldenTests = []struct {
+	name     string
+	abi      string
+	bytecode string
+	lang     Lang
+	golden   string
+}{
+	{`Token`, goldenTokenABI, goldenTokenBin, LangTypeScript, "token.ts.golden"},
+	{`Token`, goldenTokenABI, goldenTokenBin, LangPython, "token.py.golden"},
+	{`Orders`, goldenOrdersABI, "", LangTypeScript, "orders.ts.golden"},
+	{`Orders`, goldenOrdersABI, "", LangPython, "orders.py.golden"},
+}
+
+// goldenTokenABI is a token contract covering the constructor, calls with single,
+// named and anonymous multiple returns, transactions and indexed events.
+const goldenTokenABI = `
+[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"},{"name":"name","type":"string"}]},
+	{"type":"function","name":"balanceOf","constant":true,"inputs":[{"name":"_owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"decimals","constant":true,"inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"info","constant":true,"inputs":[],"outputs":[{"name":"owner","type":"address"},{"name":"supply","type":"uint256"}]},
+	{"type":"function","name":"lookupNFT","constant":true,"inputs":[{"name":"ids","type":"uint256[]"}],"outputs":[{"name":"","type":"bytes32[]"},{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","constant":false,"inputs":[{"name":"_to","type":"address"},{"name":"_value","type":"uint256"}],"outputs":[{"name":"success","type":"bool"}]},
+	{"type":"function","name":"transferFrom","constant":false,"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Memo","inputs":[{"name":"data","type":"bytes","indexed":false},{"name":"topic","type":"string","indexed":true}]}
+]
+`
+
+const goldenTokenBin = `0x6060604052600a8060106000396000f360606040526008565b00`
+
+// goldenOrdersABI is an order book contract covering tuple arguments, returns and
+// event fields, including arrays of tuples and nested tuples.
+const goldenOrdersABI = `
+[
+	{"type":"function","name":"place","constant":false,"inputs":[{"name":"order","type":"tuple","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[]"},{"name":"note","type":"string"}]}],"outputs":[{"name":"id","type":"uint256"}]},
+	{"type":"function","name":"getOrder","constant":true,"inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"order","type":"tuple","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[]"},{"name":"note","type":"string"}]}]},
+	{"type":"function","name":"shapes","constant":true,"inputs":[],"outputs":[{"name":"shapes","type":"tuple[]","components":[{"name":"points","type":"tuple[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]},{"name":"owner","type":"address"}]},{"name":"count","type":"uint256"}]},
+	{"type":"event","name":"OrderPlaced","inputs":[{"name":"id","type":"uint256","indexed":true},{"name":"order","type":"tuple","indexed":false,"components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[]"},{"name":"note","type":"string"}]}]}
+]
+`
+
+// Tests that the bindings generated for the languages which can't be compiled in
+// the test environment match their golden files.
+func TestGoldenBindings(t *testing.T) {
+	for i, tt := range goldenTests {
+		bind, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", tt.lang)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		path := filepath.Join("testdata", tt.golden)
+		if *updateGolden {
+			if err := ioutil.WriteFile(path, []byte(bind), 0644); err != nil {
+				t.Fatalf("test %d: failed to update golden file: %v", i, err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("test %d: failed to read golden file: %v", i, err)
+		}
+		if !bytes.Equal([]byte(bind), want) {
+			t.Errorf("test %d: binding mismatch for %s, run with -update to regenerate:\n%s", i, tt.golden, bind)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// streamDedupDepth is the number of blocks behind the highest seen one for which
+// a stream remembers the delivered logs. Duplicates and removals of logs older
+// than this are not detected.
+const streamDedupDepth = 256
+
+// StreamOpts is the collection of options to fine tune a reorg-aware event stream
+// within a bound contract.
+type StreamOpts struct {
+	Start  uint64      // Block to start backfilling from if no cursor was persisted yet
+	Cursor CursorStore // Persistence for the stream position (nil = always start from Start)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// LogCursor is the position of an event stream, identifying the last log that
+// was delivered. A zero block hash means that no log of the block was delivered.
+type LogCursor struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+	Index       uint        `json:"logIndex"`
+}
+
+// CursorStore is the persistence backend of an event stream position, allowing
+// a stream to resume where it left off after a disconnect or a restart.
+type CursorStore interface {
+	// Load retrieves the persisted cursor, or nil if none was stored yet.
+	Load() (*LogCursor, error)
+
+	// Store persists the current position of the stream.
+	Store(cursor *LogCursor) error
+}
+
+// fileCursor is a CursorStore persisting the stream position into a JSON file.
+type fileCursor struct {
+	path string
+}
+
+// NewFileCursor creates a cursor store persisting the stream position into the
+// given file.
+func NewFileCursor(path string) CursorStore {
+	return &fileCursor{path: path}
+}
+
+// Load implements CursorStore, retrieving the cursor from the file.
+func (c *fileCursor) Load() (*LogCursor, error) {
+	blob, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cursor := new(LogCursor)
+	if err := json.Unmarshal(blob, cursor); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Store implements CursorStore, writing the cursor into the file.
+func (c *fileCursor) Store(cursor *LogCursor) error {
+	blob, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, blob, 0600)
+}
+
+// logKey uniquely identifies a log within the chain.
+type logKey struct {
+	block common.Hash
+	index uint
+}
+
+// logStream tracks the logs delivered by an event stream, to filter out the
+// duplicates and only surface the removals of logs actually delivered.
+type logStream struct {
+	seen   map[logKey]uint64 // Logs delivered recently, mapped to their block number
+	head   uint64            // Highest block number delivered so far
+	cursor *LogCursor        // Position of the last delivered log
+	store  CursorStore       // Persistence for the cursor
+}
+
+// deliver forwards a log to the sink unless it's a duplicate or the removal of
+// a log which was never delivered. The method returns false if the stream was
+// torn down while waiting on the sink.
+func (s *logStream) deliver(log types.Log, sink chan<- types.Log, quit <-chan struct{}) (bool, error) {
+	key := logKey{block: log.BlockHash, index: log.Index}
+	_, delivered := s.seen[key]
+	if (delivered && !log.Removed) || (!delivered && log.Removed) {
+		return true, nil
+	}
+	select {
+	case sink <- log:
+	case <-quit:
+		return false, nil
+	}
+	// Log handed over to the user, update the stream position
+	if log.Removed {
+		delete(s.seen, key)
+		if s.cursor == nil || log.BlockNumber <= s.cursor.BlockNumber {
+			s.cursor = &LogCursor{BlockNumber: log.BlockNumber}
+		}
+	} else {
+		s.seen[key] = log.BlockNumber
+		s.cursor = &LogCursor{BlockNumber: log.BlockNumber, BlockHash: log.BlockHash, Index: log.Index}
+	}
+	if log.BlockNumber > s.head {
+		s.head = log.BlockNumber
+		for key, number := range s.seen {
+			if number+streamDedupDepth < s.head {
+				delete(s.seen, key)
+			}
+		}
+	}
+	if s.store != nil {
+		if err := s.store.Store(s.cursor); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// StreamLogs subscribes to contract logs in a reorg-aware way, returning an
+// unbuffered channel of logs and a subscription object that can be used to tear
+// down the stream.
+//
+// Past logs are backfilled starting from the persisted cursor (or opts.Start if
+// none is available), after which the stream switches over to the live logs.
+// Each log is delivered only once, logs reverted by a chain reorganisation are
+// delivered again with their Removed flag set. The cursor is updated after each
+// log was handed over to the channel reader.
+//
+// If the underlying subscription fails, the stream is torn down with the error.
+// Streaming with the same cursor store afterwards resumes where it left off;
+// logs removed while the stream was down are not reported though.
+func (c *BoundContract) StreamLogs(opts *StreamOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(StreamOpts)
+	}
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{c.abi.Events[name].Id()}}, query...)
+
+	topics, err := makeTopics(query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Resume from the persisted position, if available
+	stream := &logStream{
+		seen:  make(map[logKey]uint64),
+		store: opts.Cursor,
+	}
+	start := opts.Start
+	if opts.Cursor != nil {
+		if stream.cursor, err = opts.Cursor.Load(); err != nil {
+			return nil, nil, err
+		}
+		if stream.cursor != nil {
+			start = stream.cursor.BlockNumber
+		}
+	}
+	// Subscribe to the live logs first, so nothing is missed while backfilling
+	config := ethereum.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}
+	live := make(chan types.Log, 128)
+	sub, err := c.filterer.SubscribeFilterLogs(ensureContext(opts.Context), config, live)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.FromBlock = new(big.Int).SetUint64(start)
+	past, err := c.filterer.FilterLogs(ensureContext(opts.Context), config)
+	if err != nil {
+		sub.Unsubscribe()
+		return nil, nil, err
+	}
+	// Skip over the logs of the cursor block which were already delivered
+	if cursor := stream.cursor; cursor != nil && cursor.BlockHash != (common.Hash{}) {
+		for len(past) > 0 && past[0].BlockHash == cursor.BlockHash && past[0].Index <= cursor.Index {
+			past = past[1:]
+		}
+	}
+	logs := make(chan types.Log)
+	return logs, event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+
+		for _, log := range past {
+			if ok, err := stream.deliver(log, logs, quit); !ok {
+				return err
+			}
+		}
+		for {
+			select {
+			case log := <-live:
+				if ok, err := stream.deliver(log, logs, quit); !ok {
+					return err
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
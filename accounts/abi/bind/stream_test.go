@@ -0,0 +1,137 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// streamFilterer is a mock ContractFilterer returning a fixed set of past logs
+// and streaming the live logs fed into it.
+type streamFilterer struct {
+	past []types.Log
+	feed event.Feed
+	from uint64 // Start block of the last backfill
+}
+
+func (f *streamFilterer) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	f.from = query.FromBlock.Uint64()
+
+	var logs []types.Log
+	for _, log := range f.past {
+		if log.BlockNumber >= f.from {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (f *streamFilterer) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return f.feed.Subscribe(ch), nil
+}
+
+func streamLog(number uint64, fork byte, index uint) types.Log {
+	return types.Log{BlockNumber: number, BlockHash: common.Hash{byte(number), fork}, Index: index}
+}
+
+// expectLogs reads the given logs from the stream and checks that nothing else
+// is delivered.
+func expectLogs(t *testing.T, logs chan types.Log, want ...types.Log) {
+	t.Helper()
+
+	for i, w := range want {
+		select {
+		case have := <-logs:
+			if have.BlockHash != w.BlockHash || have.Index != w.Index || have.Removed != w.Removed {
+				t.Fatalf("log %d mismatch: have %x/%d (removed %v), want %x/%d (removed %v)", i, have.BlockHash, have.Index, have.Removed, w.BlockHash, w.Index, w.Removed)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("log %d not delivered", i)
+		}
+	}
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log delivered: %x/%d (removed %v)", log.BlockHash, log.Index, log.Removed)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// Tests that streams backfill, de-duplicate, surface removals and resume from
+// their persisted cursor.
+func TestStreamLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	parsed, _ := abi.JSON(strings.NewReader(`[{"type":"event","name":"Event","inputs":[]}]`))
+	filterer := &streamFilterer{
+		past: []types.Log{streamLog(1, 0, 0), streamLog(2, 0, 1), streamLog(2, 0, 2)},
+	}
+	contract := NewBoundContract(common.Address{}, parsed, nil, nil, filterer)
+	opts := &StreamOpts{Start: 1, Cursor: NewFileCursor(filepath.Join(dir, "cursor.json"))}
+
+	logs, sub, err := contract.StreamLogs(opts, "Event")
+	if err != nil {
+		t.Fatalf("failed to stream logs: %v", err)
+	}
+	expectLogs(t, logs, filterer.past...)
+
+	// Duplicate of a backfilled log and a new one
+	filterer.feed.Send(streamLog(2, 0, 2))
+	filterer.feed.Send(streamLog(3, 0, 3))
+	expectLogs(t, logs, streamLog(3, 0, 3))
+
+	// Reorg removing block 3 and a log which was never delivered
+	removed, unknown := streamLog(3, 0, 3), streamLog(3, 0, 4)
+	removed.Removed, unknown.Removed = true, true
+	filterer.feed.Send(unknown)
+	filterer.feed.Send(removed)
+	expectLogs(t, logs, removed)
+
+	// New canonical block 3
+	filterer.feed.Send(streamLog(3, 1, 3))
+	expectLogs(t, logs, streamLog(3, 1, 3))
+	sub.Unsubscribe()
+
+	// Resume the stream, the cursor block's delivered logs are skipped
+	filterer.past = append(filterer.past[:3], streamLog(3, 1, 3), streamLog(4, 0, 4))
+
+	logs, sub, err = contract.StreamLogs(opts, "Event")
+	if err != nil {
+		t.Fatalf("failed to resume stream: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	if filterer.from != 3 {
+		t.Errorf("backfill start mismatch: have %d, want %d", filterer.from, 3)
+	}
+	expectLogs(t, logs, streamLog(4, 0, 4))
+}
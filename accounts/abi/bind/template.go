@@ -22,6 +22,7 @@ import "github.com/ethereum/go-ethereum/accounts/abi"
 type tmplData struct {
 	Package   string                   // Name of the package to place the generated file in
 	Contracts map[string]*tmplContract // List of contracts to generate into this file
+	Structs   map[string]*tmplStruct   // Tuple types shared by the contracts, keyed by layout
 }
 
 // tmplContract contains the data needed to generate an individual contract binding.
@@ -49,11 +50,28 @@ type tmplEvent struct {
 	Normalized abi.Event // Normalized version of the parsed fields
 }
 
+// tmplField is a wrapper around a struct field with binding language
+// struct type definition and relative filed name.
+type tmplField struct {
+	Type    string   // Field type representation depends on target binding language
+	Name    string   // Field name converted from the raw user-defined field name
+	SolKind abi.Type // Raw abi type information
+}
+
+// tmplStruct is a wrapper around an abi.tuple contains an auto-generated
+// struct name.
+type tmplStruct struct {
+	Name   string       // Auto-generated struct name (we can't obtain the raw struct name through abi)
+	Fields []*tmplField // Struct fields definition depends on the binding language.
+}
+
 // tmplSource is language to template mapping containing all the supported
 // programming languages the package can generate to.
 var tmplSource = map[Lang]string{
-	LangGo:   tmplSourceGo,
-	LangJava: tmplSourceJava,
+	LangGo:         tmplSourceGo,
+	LangJava:       tmplSourceJava,
+	LangTypeScript: tmplSourceTypeScript,
+	LangPython:     tmplSourcePython,
 }
 
 // tmplSourceGo is the Go source template use to generate the contract binding
@@ -88,6 +106,14 @@ var (
 	_ = event.NewSubscription
 )
 
+{{range $structs := .Structs}}
+	// {{.Name}} is an auto generated low-level Go binding around an user-defined struct.
+	type {{.Name}} struct {
+	{{range $field := .Fields}}
+	{{$field.Name}} {{$field.Type}}{{end}}
+	}
+{{end}}
+
 {{range $contract := .Contracts}}
 	// {{.Type}}ABI is the input ABI used to generate the binding from.
 	const {{.Type}}ABI = "{{.InputABI}}"
@@ -439,6 +465,55 @@ var (
 				}
 			}), nil
 		}
+
+		// {{$contract.Type}}{{.Normalized.Name}}Update is delivered by Stream{{.Normalized.Name}}, either for a newly added {{.Normalized.Name}} event or for one reverted by a chain reorganisation.
+		type {{$contract.Type}}{{.Normalized.Name}}Update struct {
+			Event   *{{$contract.Type}}{{.Normalized.Name}} // Event containing the contract specifics and raw log
+			Removed bool // Whether the event was reverted by a chain reorganisation
+		}
+
+		// Stream{{.Normalized.Name}} is a reorg-aware log subscription operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		// Past events are backfilled from the persisted cursor or opts.Start, events reverted by reorgs are delivered again as removals.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Stream{{.Normalized.Name}}(opts *bind.StreamOpts, sink chan<- *{{$contract.Type}}{{.Normalized.Name}}Update{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type}}{{end}}{{end}}) (event.Subscription, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.StreamLogs(opts, "{{.Original.Name}}"{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return event.NewSubscription(func(quit <-chan struct{}) error {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case log := <-logs:
+						// New log arrived, parse the event and forward to the user
+						event := new({{$contract.Type}}{{.Normalized.Name}})
+						if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+							return err
+						}
+						event.Raw = log
+
+						select {
+						case sink <- &{{$contract.Type}}{{.Normalized.Name}}Update{Event: event, Removed: log.Removed}:
+						case err := <-sub.Err():
+							return err
+						case <-quit:
+							return nil
+						}
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			}), nil
+		}
  	{{end}}
 {{end}}
 `
@@ -544,3 +619,250 @@ import org.ethereum.geth.internal.*;
 	}
 {{end}}
 `
+
+// tmplSourceTypeScript is the TypeScript source template use to generate the
+// contract binding based on. The binding is built on top of ethers.js v4.
+const tmplSourceTypeScript = `// This file is an automatically generated TypeScript binding. Do not modify as
+// any change will likely be lost upon the next re-generation!
+
+import { ethers } from "ethers";
+
+// indexed unwraps the topic hash of an indexed dynamic event field.
+function indexed(value: any): any {
+  return value instanceof Object && "hash" in value ? value.hash : value;
+}
+{{range .Structs}}
+// {{.Name}} is an auto generated TypeScript binding around a user-defined struct.
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}: {{.Type}};
+{{- end}}
+}
+{{end}}
+{{- range $contract := .Contracts}}
+{{- range .Calls}}{{if .Structured}}
+// {{$contract.Type}}{{capitalise .Normalized.Name}}Result is the output of a call to {{.Normalized.Name}}.
+export interface {{$contract.Type}}{{capitalise .Normalized.Name}}Result {
+{{- range .Original.Outputs}}
+  {{.Name}}: {{bindtype .Type}};
+{{- end}}
+}
+{{end}}{{end}}
+{{- range .Events}}
+// {{$contract.Type}}{{capitalise .Normalized.Name}} represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+export interface {{$contract.Type}}{{capitalise .Normalized.Name}} {
+{{- range .Normalized.Inputs}}
+  {{identifier .Name}}: {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}};
+{{- end}}
+  raw: ethers.providers.Log; // Blockchain specific contextual infos
+}
+{{end}}
+// {{.Type}} is an auto generated wrapper around an Ethereum contract.
+export class {{.Type}} {
+  // ABI is the input ABI used to generate the binding from.
+  static readonly ABI = "{{.InputABI}}";
+{{- if .InputBin}}
+
+  // BYTECODE is the compiled bytecode used for deploying new contracts.
+  static readonly BYTECODE = "{{.InputBin}}";
+
+  // deploy deploys a new Ethereum contract, binding an instance of {{.Type}} to it.
+  static async deploy(signer: ethers.Signer{{range .Constructor.Inputs}}, {{identifier .Name}}: {{bindinputtype .Type}}{{end}}, overrides: ethers.providers.TransactionRequest = {}): Promise<{{.Type}}> {
+    const factory = new ethers.ContractFactory({{.Type}}.ABI, {{.Type}}.BYTECODE, signer);
+    const contract = await factory.deploy({{range .Constructor.Inputs}}{{identifier .Name}}, {{end}}overrides);
+    return new {{.Type}}(contract.address, signer, contract.deployTransaction);
+  }
+{{- end}}
+
+  // Contract instance bound to a blockchain address.
+  readonly contract: ethers.Contract;
+
+  // Creates a new instance of {{.Type}}, bound to a specific deployed contract.
+  constructor(readonly address: string, signerOrProvider: ethers.Signer | ethers.providers.Provider, readonly deployTransaction?: ethers.providers.TransactionResponse) {
+    this.contract = new ethers.Contract(address, {{.Type}}.ABI, signerOrProvider);
+  }
+{{range .Calls}}
+  // {{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
+  //
+  // Solidity: {{.Original.String}}
+  async {{.Normalized.Name}}({{range .Normalized.Inputs}}{{identifier .Name}}: {{bindinputtype .Type}}, {{end}}overrides: ethers.providers.TransactionRequest = {}): Promise<
+  {{- if .Structured}}{{$contract.Type}}{{capitalise .Normalized.Name}}Result
+  {{- else if eq (len .Normalized.Outputs) 0}}void
+  {{- else if eq (len .Normalized.Outputs) 1}}{{range .Normalized.Outputs}}{{bindtype .Type}}{{end}}
+  {{- else}}[{{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype .Type}}{{end}}]{{end}}> {
+    return this.contract.functions["{{.Original.Sig}}"]({{range .Normalized.Inputs}}{{identifier .Name}}, {{end}}overrides);
+  }
+{{end}}
+{{- range .Transacts}}
+  // {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+  //
+  // Solidity: {{.Original.String}}
+  async {{.Normalized.Name}}({{range .Normalized.Inputs}}{{identifier .Name}}: {{bindinputtype .Type}}, {{end}}overrides: ethers.providers.TransactionRequest = {}): Promise<ethers.providers.TransactionResponse> {
+    return this.contract.functions["{{.Original.Sig}}"]({{range .Normalized.Inputs}}{{identifier .Name}}, {{end}}overrides);
+  }
+{{end}}
+{{- range .Events}}
+  // filter{{capitalise .Normalized.Name}} retrieves the {{.Original.Name}} events raised by the contract between the given blocks.
+  //
+  // Solidity: {{.Original.String}}
+  async filter{{capitalise .Normalized.Name}}(fromBlock: ethers.providers.BlockTag = 0, toBlock: ethers.providers.BlockTag = "latest"{{range .Normalized.Inputs}}{{if .Indexed}}, {{identifier .Name}}: {{bindinputtype .Type}} | null = null{{end}}{{end}}): Promise<{{$contract.Type}}{{capitalise .Normalized.Name}}[]> {
+    const filter = this.contract.filters.{{.Original.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{if .Indexed}}{{identifier .Name}}{{else}}null{{end}}{{end}});
+    const logs = await this.contract.provider.getLogs({ ...filter, fromBlock, toBlock });
+    return logs.map((log) => this.parse{{capitalise .Normalized.Name}}(log));
+  }
+
+  // watch{{capitalise .Normalized.Name}} subscribes to the {{.Original.Name}} events raised by the contract, returning
+  // a function to unsubscribe with.
+  //
+  // Solidity: {{.Original.String}}
+  watch{{capitalise .Normalized.Name}}(callback: (event: {{$contract.Type}}{{capitalise .Normalized.Name}}) => void{{range .Normalized.Inputs}}{{if .Indexed}}, {{identifier .Name}}: {{bindinputtype .Type}} | null = null{{end}}{{end}}): () => void {
+    const filter = this.contract.filters.{{.Original.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{if .Indexed}}{{identifier .Name}}{{else}}null{{end}}{{end}});
+    const listener = (...args: any[]) => callback(this.parse{{capitalise .Normalized.Name}}(args[args.length - 1]));
+    this.contract.on(filter, listener);
+    return () => {
+      this.contract.removeListener(filter, listener);
+    };
+  }
+
+  // parse{{capitalise .Normalized.Name}} decodes a {{.Original.Name}} event from a raw log.
+  private parse{{capitalise .Normalized.Name}}(log: ethers.providers.Log): {{$contract.Type}}{{capitalise .Normalized.Name}} {
+    const values = this.contract.interface.parseLog(log).values;
+    return {
+{{- range $i, $_ := .Normalized.Inputs}}
+      {{identifier .Name}}: {{if .Indexed}}indexed(values[{{$i}}]){{else}}values[{{$i}}]{{end}},
+{{- end}}
+      raw: log,
+    };
+  }
+{{end -}}
+}
+{{end}}`
+
+// tmplSourcePython is the Python source template use to generate the contract
+// binding based on. The binding is built on top of web3.py v5.
+const tmplSourcePython = `# This file is an automatically generated Python binding. Do not modify as any
+# change will likely be lost upon the next re-generation!
+
+import json
+import time
+from typing import Any, Dict, Iterator, List, NamedTuple, Optional, Tuple
+
+from web3 import Web3
+{{range .Structs}}
+
+class {{.Name}}(NamedTuple):
+    """{{.Name}} is an auto generated Python binding around a user-defined struct."""
+{{- range .Fields}}
+    {{.Name}}: {{.Type}}
+{{- end}}
+
+    @classmethod
+    def from_abi(cls, value: Any) -> '{{.Name}}':
+        """Converts a tuple decoded by web3.py to a {{.Name}}."""
+        return cls({{range $i, $field := .Fields}}{{if $i}}, {{end}}{{convert $field.SolKind (printf "value[%d]" $i)}}{{end}})
+{{end}}
+{{- range $contract := .Contracts}}
+{{- range .Calls}}{{if .Structured}}
+
+class {{$contract.Type}}{{capitalise .Normalized.Name}}Result(NamedTuple):
+    """{{$contract.Type}}{{capitalise .Normalized.Name}}Result is the output of a call to {{.Normalized.Name}}."""
+{{- range .Normalized.Outputs}}
+    {{identifier .Name}}: {{bindtype .Type}}
+{{- end}}
+{{end}}{{end}}
+{{- range .Events}}
+
+class {{$contract.Type}}{{capitalise .Normalized.Name}}(NamedTuple):
+    """{{$contract.Type}}{{capitalise .Normalized.Name}} represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract."""
+{{- range .Normalized.Inputs}}
+    {{identifier .Name}}: {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}}
+{{- end}}
+    raw: Any  # Blockchain specific contextual infos
+{{end}}
+
+class {{.Type}}:
+    """{{.Type}} is an auto generated wrapper around an Ethereum contract."""
+
+    # ABI is the input ABI used to generate the binding from.
+    ABI = json.loads('{{.InputABI}}')
+{{- if .InputBin}}
+
+    # BYTECODE is the compiled bytecode used for deploying new contracts.
+    BYTECODE = '{{.InputBin}}'
+
+    @classmethod
+    def deploy(cls, w3: Web3{{range .Constructor.Inputs}}, {{identifier .Name}}: {{bindtype .Type}}{{end}}, tx: Optional[Dict[str, Any]] = None) -> '{{.Type}}':
+        """Deploys a new Ethereum contract, binding an instance of {{.Type}} to it."""
+        factory = w3.eth.contract(abi=cls.ABI, bytecode=cls.BYTECODE)
+        tx_hash = factory.constructor({{range $i, $_ := .Constructor.Inputs}}{{if $i}}, {{end}}{{identifier .Name}}{{end}}).transact(tx or {})
+        receipt = w3.eth.waitForTransactionReceipt(tx_hash)
+        return cls(w3, receipt.contractAddress)
+{{- end}}
+
+    def __init__(self, w3: Web3, address: str) -> None:
+        """Creates a new instance of {{.Type}}, bound to a specific deployed contract."""
+        self.w3 = w3
+        self.address = Web3.toChecksumAddress(address)
+        self.contract = w3.eth.contract(address=self.address, abi=self.ABI)
+{{range .Calls}}
+    def {{.Normalized.Name}}(self{{range .Normalized.Inputs}}, {{identifier .Name}}: {{bindtype .Type}}{{end}}, tx: Optional[Dict[str, Any]] = None, block_identifier: Any = 'latest') -> {{if .Structured}}{{$contract.Type}}{{capitalise .Normalized.Name}}Result
+    {{- else if eq (len .Normalized.Outputs) 0}}None
+    {{- else if eq (len .Normalized.Outputs) 1}}{{range .Normalized.Outputs}}{{bindtype .Type}}{{end}}
+    {{- else}}Tuple[{{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype .Type}}{{end}}]{{end}}:
+        """{{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
+
+        Solidity: {{.Original.String}}
+        """
+        {{if ne (len .Normalized.Outputs) 0}}result = {{end}}self.contract.get_function_by_signature('{{.Original.Sig}}')({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{identifier .Name}}{{end}}).call(tx or {}, block_identifier)
+        {{- if .Structured}}
+        return {{$contract.Type}}{{capitalise .Normalized.Name}}Result({{range $i, $output := .Normalized.Outputs}}{{if $i}}, {{end}}{{convert $output.Type (printf "result[%d]" $i)}}{{end}})
+        {{- else if gt (len .Normalized.Outputs) 1}}
+        return ({{range $i, $output := .Normalized.Outputs}}{{if $i}}, {{end}}{{convert $output.Type (printf "result[%d]" $i)}}{{end}})
+        {{- else if eq (len .Normalized.Outputs) 1}}
+        return {{range .Normalized.Outputs}}{{convert .Type "result"}}{{end}}
+        {{- end}}
+{{end}}
+{{- range .Transacts}}
+    def {{.Normalized.Name}}(self{{range .Normalized.Inputs}}, {{identifier .Name}}: {{bindtype .Type}}{{end}}, tx: Optional[Dict[str, Any]] = None) -> bytes:
+        """{{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+
+        Solidity: {{.Original.String}}
+        """
+        return self.contract.get_function_by_signature('{{.Original.Sig}}')({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{identifier .Name}}{{end}}).transact(tx or {})
+{{end}}
+{{- range $event := .Events}}
+    def filter_{{.Normalized.Name}}(self, from_block: Any = 0, to_block: Any = 'latest'{{range .Normalized.Inputs}}{{if .Indexed}}, {{identifier .Name}}: Optional[{{bindtype .Type}}] = None{{end}}{{end}}) -> List[{{$contract.Type}}{{capitalise .Normalized.Name}}]:
+        """filter_{{.Normalized.Name}} retrieves the {{.Original.Name}} events raised by the contract between the given blocks.
+
+        Solidity: {{.Original.String}}
+        """
+        filters: Dict[str, Any] = {}
+        {{- range $i, $_ := .Normalized.Inputs}}{{if .Indexed}}
+        if {{identifier .Name}} is not None:
+            filters['{{(index $event.Original.Inputs $i).Name}}'] = {{identifier .Name}}
+        {{- end}}{{end}}
+        logs = self.contract.events.{{.Original.Name}}.getLogs(argument_filters=filters, fromBlock=from_block, toBlock=to_block)
+        return [self._parse_{{.Normalized.Name}}(log) for log in logs]
+
+    def watch_{{.Normalized.Name}}(self{{range .Normalized.Inputs}}{{if .Indexed}}, {{identifier .Name}}: Optional[{{bindtype .Type}}] = None{{end}}{{end}}, poll_interval: float = 2.0) -> Iterator[{{$contract.Type}}{{capitalise .Normalized.Name}}]:
+        """watch_{{.Normalized.Name}} polls for new {{.Original.Name}} events raised by the contract, yielding them
+        as they arrive.
+
+        Solidity: {{.Original.String}}
+        """
+        filters: Dict[str, Any] = {}
+        {{- range $i, $_ := .Normalized.Inputs}}{{if .Indexed}}
+        if {{identifier .Name}} is not None:
+            filters['{{(index $event.Original.Inputs $i).Name}}'] = {{identifier .Name}}
+        {{- end}}{{end}}
+        event_filter = self.contract.events.{{.Original.Name}}.createFilter(fromBlock='latest', argument_filters=filters)
+        while True:
+            for log in event_filter.get_new_entries():
+                yield self._parse_{{.Normalized.Name}}(log)
+            time.sleep(poll_interval)
+
+    def _parse_{{.Normalized.Name}}(self, log: Any) -> {{$contract.Type}}{{capitalise .Normalized.Name}}:
+        """Decodes a {{.Original.Name}} event from a raw log."""
+        return {{$contract.Type}}{{capitalise .Normalized.Name}}({{range $i, $input := .Normalized.Inputs}}{{if $input.Indexed}}log.args['{{(index $event.Original.Inputs $i).Name}}']{{else}}{{convert $input.Type (printf "log.args['%s']" (index $event.Original.Inputs $i).Name)}}{{end}}, {{end}}log)
+{{end}}
+{{- end}}`
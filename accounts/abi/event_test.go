@@ -95,6 +95,16 @@ func TestEventId(t *testing.T) {
 				"check":   crypto.Keccak256Hash([]byte("check(address,uint256)")),
 			},
 		},
+		{
+			definition: `[
+			{ "type" : "event", "name" : "order", "inputs": [{ "name" : "o", "type": "tuple", "components": [{ "name": "maker", "type": "address" }, { "name": "amounts", "type": "uint256[2]" }] }] },
+			{ "type" : "event", "name" : "orders", "inputs": [{ "name" : "o", "type": "tuple[]", "components": [{ "name": "maker", "type": "address" }, { "name": "tags", "type": "string[]" }] }] }
+			]`,
+			expectations: map[string]common.Hash{
+				"order":  crypto.Keccak256Hash([]byte("order((address,uint256[2]))")),
+				"orders": crypto.Keccak256Hash([]byte("orders((address,string[])[])")),
+			},
+		},
 	}
 
 	for _, test := range table {
@@ -355,6 +365,33 @@ func (tc testCase) encoded(intType, arrayType Type) []byte {
 	return b.Bytes()
 }
 
+// TestEventTupleFieldUnpack verifies that static tuples are decoded inline and
+// counted towards the offsets of the following fields.
+func TestEventTupleFieldUnpack(t *testing.T) {
+	definition := `[{"name": "test", "type": "event", "inputs": [{"indexed": true, "name":"value1", "type":"uint8"},{"indexed": false, "name":"value2", "type":"tuple", "components": [{"name":"a", "type":"uint8"},{"name":"b", "type":"uint8[2]"}]},{"indexed": false, "name":"value3", "type":"uint8"}]}]`
+	type testTuple struct {
+		A uint8
+		B [2]uint8
+	}
+	type testStruct struct {
+		Value1 uint8
+		Value2 testTuple
+		Value3 uint8
+	}
+	abi, err := JSON(strings.NewReader(definition))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	var i uint8 = 1
+	for ; i <= 4; i++ {
+		b.Write(packNum(reflect.ValueOf(i)))
+	}
+	var rst testStruct
+	require.NoError(t, abi.Unpack(&rst, "test", b.Bytes()))
+	require.Equal(t, uint8(0), rst.Value1)
+	require.Equal(t, testTuple{1, [2]uint8{2, 3}}, rst.Value2)
+	require.Equal(t, uint8(4), rst.Value3)
+}
+
 // TestEventUnpackIndexed verifies that indexed field will be skipped by event decoder.
 func TestEventUnpackIndexed(t *testing.T) {
 	definition := `[{"name": "test", "type": "event", "inputs": [{"indexed": true, "name":"value1", "type":"uint8"},{"indexed": false, "name":"value2", "type":"uint8"}]}]`
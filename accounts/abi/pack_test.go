@@ -18,6 +18,7 @@ package abi
 
 import (
 	"bytes"
+	"encoding/json"
 	"math"
 	"math/big"
 	"reflect"
@@ -325,7 +326,7 @@ func TestPack(t *testing.T) {
 			common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000006666f6f6261720000000000000000000000000000000000000000000000000000"),
 		},
 	} {
-		typ, err := NewType(test.typ)
+		typ, err := NewType(test.typ, nil)
 		if err != nil {
 			t.Fatalf("%v failed. Unexpected parse error: %v", i, err)
 		}
@@ -441,3 +442,113 @@ func TestPackNumber(t *testing.T) {
 		}
 	}
 }
+
+type (
+	tuplePoint struct {
+		X *big.Int
+		Y *big.Int
+	}
+	tupleNamed struct {
+		X *big.Int
+		Y string
+	}
+	tupleNested struct {
+		A *big.Int
+		B tuplePoint
+	}
+	tuplePoints struct {
+		A      *big.Int
+		Points []tuplePoint
+	}
+)
+
+// TestPackUnpackTuple checks that tuples, nested into each other or into arrays,
+// round trip through their canonical encodings.
+func TestPackUnpackTuple(t *testing.T) {
+	for i, test := range []struct {
+		def   string      // argument definition
+		value interface{} // Go value to pack
+		enc   string      // expected encoding
+	}{
+		{
+			`{"type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}`,
+			tuplePoint{big.NewInt(1), big.NewInt(2)},
+			"00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+		},
+		{
+			`{"type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"string"}]}`,
+			tupleNamed{big.NewInt(1), "foo"},
+			"0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000003666f6f0000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			`{"type":"tuple","components":[{"name":"a","type":"uint256"},{"name":"b","type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}]}`,
+			tupleNested{big.NewInt(1), tuplePoint{big.NewInt(2), big.NewInt(3)}},
+			"000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000003",
+		},
+		{
+			`{"type":"tuple","components":[{"name":"a","type":"uint256"},{"name":"points","type":"tuple[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}]}`,
+			tuplePoints{big.NewInt(1), []tuplePoint{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(4)}}},
+			"00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000030000000000000000000000000000000000000000000000000000000000000004",
+		},
+		{
+			`{"type":"tuple[2]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"string"}]}`,
+			[2]tupleNamed{{big.NewInt(1), "foo"}, {big.NewInt(2), "bar"}},
+			"0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000c0000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000003666f6f00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000036261720000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			`{"type":"string[]"}`,
+			[]string{"one", "two", "three"},
+			"00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000003000000000000000000000000000000000000000000000000000000000000006000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000e000000000000000000000000000000000000000000000000000000000000000036f6e650000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000374776f000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000057468726565000000000000000000000000000000000000000000000000000000",
+		},
+	} {
+		var arg Argument
+		if err := json.Unmarshal([]byte(test.def), &arg); err != nil {
+			t.Fatalf("test %d: invalid definition: %v", i, err)
+		}
+		args := Arguments{arg}
+
+		packed, err := args.Pack(test.value)
+		if err != nil {
+			t.Fatalf("test %d: failed to pack: %v", i, err)
+		}
+		if want := common.Hex2Bytes(test.enc); !bytes.Equal(packed, want) {
+			t.Errorf("test %d: pack mismatch: have %x, want %x", i, packed, want)
+		}
+		out := reflect.New(reflect.TypeOf(test.value))
+		if err := args.Unpack(out.Interface(), packed); err != nil {
+			t.Fatalf("test %d: failed to unpack: %v", i, err)
+		}
+		if !reflect.DeepEqual(out.Elem().Interface(), test.value) {
+			t.Errorf("test %d: unpack mismatch: have %+v, want %+v", i, out.Elem().Interface(), test.value)
+		}
+	}
+}
+
+// TestPackUnpackTupleArguments checks that dynamic tuples mixed with other
+// arguments are referenced by offset.
+func TestPackUnpackTupleArguments(t *testing.T) {
+	const definition = `[{"name":"a","type":"uint256"},{"name":"t","type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"string"}]},{"name":"b","type":"uint256"}]`
+
+	var args Arguments
+	if err := json.Unmarshal([]byte(definition), &args); err != nil {
+		t.Fatalf("invalid definition: %v", err)
+	}
+	packed, err := args.Pack(big.NewInt(1), tupleNamed{big.NewInt(3), "foo"}, big.NewInt(2))
+	if err != nil {
+		t.Fatalf("failed to pack: %v", err)
+	}
+	if want := common.Hex2Bytes("000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000003666f6f0000000000000000000000000000000000000000000000000000000000"); !bytes.Equal(packed, want) {
+		t.Errorf("pack mismatch: have %x, want %x", packed, want)
+	}
+	var out struct {
+		A *big.Int
+		T tupleNamed
+		B *big.Int
+	}
+	if err := args.Unpack(&out, packed); err != nil {
+		t.Fatalf("failed to unpack: %v", err)
+	}
+	if out.A.Cmp(big.NewInt(1)) != 0 || out.B.Cmp(big.NewInt(2)) != 0 || out.T.X.Cmp(big.NewInt(3)) != 0 || out.T.Y != "foo" {
+		t.Errorf("unpack mismatch: have %+v", out)
+	}
+}
@@ -81,12 +81,65 @@ func set(dst, src reflect.Value, output Argument) error {
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
 		return set(dst.Elem(), src, output)
+	case srcType.Kind() == reflect.Slice && dstType.Kind() == reflect.Slice:
+		return setSlice(dst, src, output)
+	case srcType.Kind() == reflect.Array && dstType.Kind() == reflect.Array:
+		return setArray(dst, src, output)
+	case srcType.Kind() == reflect.Struct && dstType.Kind() == reflect.Struct:
+		return setStruct(dst, src, output)
 	default:
 		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
 	}
 	return nil
 }
 
+// setSlice attempts to assign src to dst when slices are not assignable by
+// default, e.g. when the elements are decoded tuples and dst holds user
+// defined structs.
+func setSlice(dst, src reflect.Value, output Argument) error {
+	slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		if err := set(slice.Index(i), src.Index(i), output); err != nil {
+			return err
+		}
+	}
+	dst.Set(slice)
+	return nil
+}
+
+// setArray attempts to assign src to dst when arrays are not assignable by
+// default, e.g. when the elements are decoded tuples and dst holds user
+// defined structs.
+func setArray(dst, src reflect.Value, output Argument) error {
+	if dst.Len() != src.Len() {
+		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
+	}
+	array := reflect.New(dst.Type()).Elem()
+	for i := 0; i < src.Len(); i++ {
+		if err := set(array.Index(i), src.Index(i), output); err != nil {
+			return err
+		}
+	}
+	dst.Set(array)
+	return nil
+}
+
+// setStruct assigns the fields of a decoded tuple to the equally named fields
+// of dst.
+func setStruct(dst, src reflect.Value, output Argument) error {
+	for i := 0; i < src.NumField(); i++ {
+		srcField := src.Field(i)
+		dstField := dst.FieldByName(src.Type().Field(i).Name)
+		if !dstField.IsValid() {
+			return fmt.Errorf("abi: field %s can't be found in the given value", src.Type().Field(i).Name)
+		}
+		if err := set(dstField, srcField, output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // requireAssignable assures that `dest` is a pointer and it's not an interface.
 func requireAssignable(dst, src reflect.Value) error {
 	if dst.Kind() != reflect.Ptr && dst.Kind() != reflect.Interface {
@@ -112,13 +165,13 @@ func requireUnpackKind(v reflect.Value, t reflect.Type, k reflect.Kind,
 	return nil
 }
 
-// mapAbiToStringField maps abi to struct fields.
+// mapArgNamesToStructFields maps a slice of argument names to struct fields.
 // first round: for each Exportable field that contains a `abi:""` tag
 //   and this field name exists in the arguments, pair them together.
 // second round: for each argument field that has not been already linked,
 //   find what variable is expected to be mapped into, if it exists and has not been
 //   used, pair them.
-func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]string, error) {
+func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[string]string, error) {
 
 	typ := value.Type()
 
@@ -148,14 +201,14 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 
 		// check which argument field matches with the abi tag.
 		found := false
-		for _, abiField := range args.NonIndexed() {
-			if abiField.Name == tagName {
-				if abi2struct[abiField.Name] != "" {
+		for _, arg := range argNames {
+			if arg == tagName {
+				if abi2struct[arg] != "" {
 					return nil, fmt.Errorf("struct: abi tag in '%s' already mapped", structFieldName)
 				}
 				// pair them
-				abi2struct[abiField.Name] = structFieldName
-				struct2abi[structFieldName] = abiField.Name
+				abi2struct[arg] = structFieldName
+				struct2abi[structFieldName] = arg
 				found = true
 			}
 		}
@@ -168,10 +221,10 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 	}
 
 	// second round ~~~
-	for _, arg := range args {
+	for _, argName := range argNames {
 
-		abiFieldName := arg.Name
-		structFieldName := capitalise(abiFieldName)
+		abiFieldName := argName
+		structFieldName := ToCamelCase(abiFieldName)
 
 		if structFieldName == "" {
 			return nil, fmt.Errorf("abi: purely underscored output cannot unpack to struct")
@@ -128,6 +128,17 @@ type Wallet interface {
 	SignTxWithPassphrase(account Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
 }
 
+// TextSigner is an optional interface implemented by wallets which cannot sign
+// arbitrary hashes, only text messages (e.g. external signers that compute the
+// hash themselves, so the user can review the message before approving).
+type TextSigner interface {
+	// SignText requests the wallet to sign the hash of the given text message:
+	// keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
+	//
+	// The V value of the returned signature is 0 or 1, same as for SignHash.
+	SignText(account Account, text []byte) ([]byte, error)
+}
+
 // Backend is a "wallet provider" that may contain a batch of accounts they can
 // sign transactions with and upon request, do so.
 type Backend interface {
@@ -0,0 +1,298 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend proxying the signing requests
+// to an external signer (e.g. clef) over its account_* RPC API.
+package external
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ExternalBackendType is the reflect type of an external signer backend.
+var ExternalBackendType = reflect.TypeOf(&ExternalBackend{})
+
+// ExternalScheme is the protocol scheme prefixing external signer URLs.
+const ExternalScheme = "extapi"
+
+// listThrottling is the minimum time between two account listings requested
+// from the external signer, as each of them may need to be approved by the user.
+const listThrottling = time.Second
+
+// ExternalBackend is an account backend with a single wallet, proxying all the
+// requests to an external signer.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend creates an account backend connected to the external signer
+// listening on the given IPC path or HTTP endpoint.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer never arrives or
+// departs, so no events are ever sent.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is a wallet whose accounts are held and whose requests are
+// signed by an external signer. The signer authenticates the requests itself,
+// usually by asking its user for approval, so no passphrases are ever needed.
+type ExternalSigner struct {
+	client *rpc.Client
+	url    accounts.URL
+
+	accounts []accounts.Account // Accounts listed by the signer, nil if never listed
+	listTime time.Time          // Time of the last account listing
+	listErr  error              // Failure of the last account listing
+	lock     sync.Mutex
+}
+
+// NewExternalSigner creates a wallet connected to the external signer listening
+// on the given IPC path or HTTP endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return newExternalSigner(client, endpoint), nil
+}
+
+// newExternalSigner creates a wallet proxying the requests over the given client.
+func newExternalSigner(client *rpc.Client, endpoint string) *ExternalSigner {
+	return &ExternalSigner{
+		client: client,
+		url:    accounts.URL{Scheme: ExternalScheme, Path: endpoint},
+	}
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the external signer.
+func (api *ExternalSigner) URL() accounts.URL {
+	return api.url
+}
+
+// Status implements accounts.Wallet, returning the failure of the last request
+// to list the accounts of the signer, if any.
+func (api *ExternalSigner) Status() (string, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if api.listErr != nil {
+		return "Failed", api.listErr
+	}
+	return "Ok", nil
+}
+
+// Open implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return nil
+}
+
+// Close implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) Close() error {
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the accounts of the external
+// signer. The accounts are listed once and cached afterwards, as listing them
+// may need the approval of the signer's user.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if api.accounts == nil {
+		api.refresh()
+	}
+	cpy := make([]accounts.Account, len(api.accounts))
+	copy(cpy, api.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whether the external signer
+// holds the given account. Unknown accounts trigger a (throttled) listing of the
+// accounts, to pick up ones added to the signer meanwhile.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if api.contains(account) {
+		return true
+	}
+	if time.Since(api.listTime) < listThrottling {
+		return false
+	}
+	api.refresh()
+	return api.contains(account)
+}
+
+// contains checks whether the account is among the cached ones.
+//
+// Note, contains assumes the signer's lock is held!
+func (api *ExternalSigner) contains(account accounts.Account) bool {
+	for _, acc := range api.accounts {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == api.url) {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh retrieves the list of accounts from the external signer.
+//
+// Note, refresh assumes the signer's lock is held!
+func (api *ExternalSigner) refresh() {
+	api.listTime = time.Now()
+
+	var addresses []common.Address
+	if api.listErr = api.client.Call(&addresses, "account_list"); api.listErr != nil {
+		log.Warn("Failed to list external signer accounts", "url", api.url, "err", api.listErr)
+		return
+	}
+	accs := make([]accounts.Account, 0, len(addresses))
+	for _, address := range addresses {
+		accs = append(accs, accounts.Account{Address: address, URL: api.url})
+	}
+	api.accounts = accs
+}
+
+// Derive implements accounts.Wallet, but is not supported by external signers.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {
+}
+
+// SignHash implements accounts.Wallet, but is not supported by external signers,
+// as they refuse to sign hashes their user cannot review.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignText implements accounts.TextSigner, requesting the external signer to
+// sign the given text message.
+func (api *ExternalSigner) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := api.client.Call(&signature, "account_sign", account.Address, hexutil.Bytes(text)); err != nil {
+		return nil, err
+	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+	// The signer returns the V value as 27/28, convert back to 0/1
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	return signature, nil
+}
+
+// sendTxArgs is the transaction format accepted by the external signer.
+type sendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+// signTransactionResult is the response of the external signer to a transaction
+// signing request.
+type signTransactionResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// SignTx implements accounts.Wallet, requesting the external signer to sign the
+// given transaction. The signer signs with its own configured chain ID, which is
+// verified against the requested one.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := &sendTxArgs{
+		From:     account.Address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+	}
+	var res signTransactionResult
+	if err := api.client.Call(&res, "account_signTransaction", args, nil); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(res.Raw, signed); err != nil {
+		return nil, err
+	}
+	// Make sure the signer signed for the right chain and account
+	var signer types.Signer = types.HomesteadSigner{}
+	if signed.Protected() {
+		if chainID != nil && signed.ChainId().Cmp(chainID) != 0 {
+			return nil, fmt.Errorf("chain ID mismatch: have %v, want %v", signed.ChainId(), chainID)
+		}
+		signer = types.NewEIP155Signer(signed.ChainId())
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+	if from != account.Address {
+		return nil, fmt.Errorf("signer mismatch: have %x, want %x", from, account.Address)
+	}
+	return signed, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, but is not supported by
+// external signers.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, requesting the external signer
+// to sign the given transaction. The passphrase is ignored, the signer handles
+// the authentication of the request itself.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return api.SignTx(account, tx, chainID)
+}
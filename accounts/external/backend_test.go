@@ -0,0 +1,127 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// StubSignerAPI is a minimal account namespace mimicking an external signer which
+// approves all requests.
+type StubSignerAPI struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+	lists   int // Number of account listings requested
+}
+
+func (api *StubSignerAPI) List() []common.Address {
+	api.lists++
+	return []common.Address{crypto.PubkeyToAddress(api.key.PublicKey)}
+}
+
+// StubTxArgs is the exported counterpart of sendTxArgs, usable by the RPC server.
+type StubTxArgs sendTxArgs
+
+func (api *StubSignerAPI) SignTransaction(args StubTxArgs, methodSelector *string) (map[string]interface{}, error) {
+	if args.From != crypto.PubkeyToAddress(api.key.PublicKey) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	tx := types.NewTransaction(uint64(args.Nonce), *args.To, args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), args.Data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(api.chainID), api.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := rlp.EncodeToBytes(signed)
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+}
+
+func (api *StubSignerAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), []byte(data))
+	signature, err := crypto.Sign(crypto.Keccak256([]byte(msg)), api.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+// Tests that the external signer lists its accounts and signs transactions and
+// text messages through the remote API.
+func TestExternalSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	stub := &StubSignerAPI{key: key, chainID: big.NewInt(1337)}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", stub); err != nil {
+		t.Fatalf("failed to register stub API: %v", err)
+	}
+	signer := newExternalSigner(rpc.DialInProc(server), "stub")
+
+	// Ensure the accounts are listed once and unknown ones are refreshed throttled
+	if accs := signer.Accounts(); len(accs) != 1 || accs[0].Address != addr {
+		t.Fatalf("account list mismatch: have %v, want %x", accs, addr)
+	}
+	signer.Accounts()
+	if !signer.Contains(accounts.Account{Address: addr}) {
+		t.Fatalf("listed account not contained")
+	}
+	if signer.Contains(accounts.Account{Address: common.Address{1}}) {
+		t.Fatalf("unknown account contained")
+	}
+	if stub.lists != 1 {
+		t.Fatalf("account listings mismatch: have %d, want 1", stub.lists)
+	}
+	// Sign a transaction and check the sender and chain
+	to := common.Address{0xff}
+	tx := types.NewTransaction(3, to, big.NewInt(1), 21000, big.NewInt(1), []byte{0x01})
+
+	signed, err := signer.SignTxWithPassphrase(accounts.Account{Address: addr}, "", tx, big.NewInt(1337))
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if signed.Nonce() != 3 || *signed.To() != to {
+		t.Errorf("signed transaction mismatch: nonce %d, to %x", signed.Nonce(), signed.To())
+	}
+	if _, err := signer.SignTx(accounts.Account{Address: addr}, tx, big.NewInt(1)); err == nil {
+		t.Errorf("transaction signed for wrong chain accepted")
+	}
+	// Sign a text message and check the recovered signer
+	if _, err := signer.SignHash(accounts.Account{Address: addr}, make([]byte, 32)); err != accounts.ErrNotSupported {
+		t.Errorf("hash signing error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	text := []byte("hello")
+	sig, err := signer.SignText(accounts.Account{Address: addr}, text)
+	if err != nil {
+		t.Fatalf("failed to sign text: %v", err)
+	}
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(text), text)))
+	if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Errorf("text signature recovery mismatch: have %v (%v), want %x", pub, err, addr)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// errInvalidChildKey is returned if a BIP-32 derivation step results in an
+// invalid key. The probability of this is lower than 1 in 2^127.
+var errInvalidChildKey = errors.New("invalid derived key, use the next index")
+
+// deriveHDKey derives the private key at the given BIP-32 derivation path from
+// the root seed of a hierarchical deterministic wallet.
+func deriveHDKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chain := sum[:32], sum[32:]
+	if _, err := crypto.ToECDSA(key); err != nil {
+		return nil, errInvalidChildKey
+	}
+	n := crypto.S256().Params().N
+	for _, index := range path {
+		// Hardened children are derived from the private key, others from the public one
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0x00}, key...)
+		} else {
+			parent, err := crypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = append(data, byte(index>>24), byte(index>>16), byte(index>>8), byte(index))
+
+		mac := hmac.New(sha512.New, chain)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		// The child key is the parent key tweaked by the left half of the hash
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, errInvalidChildKey
+		}
+		child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if child.Sign() == 0 {
+			return nil, errInvalidChildKey
+		}
+		key, chain = math.PaddedBigBytes(child, 32), sum[32:]
+	}
+	return crypto.ToECDSA(key)
+}
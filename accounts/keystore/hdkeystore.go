@@ -0,0 +1,262 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pborman/uuid"
+)
+
+// HDKeyStoreType is the reflect type of a hierarchical deterministic keystore
+// backend.
+var HDKeyStoreType = reflect.TypeOf(&HDKeyStore{})
+
+// HDKeyStoreScheme is the protocol scheme prefixing HD wallet and account URLs.
+const HDKeyStoreScheme = "hdkeystore"
+
+// hdWalletVersion is the version of the HD wallet file format.
+const hdWalletVersion = 1
+
+// hdWalletJSON is the on-disk format of an HD wallet, consisting of the encrypted
+// mnemonic and the accounts pinned by the user.
+type hdWalletJSON struct {
+	Id       string          `json:"id"`
+	Version  int             `json:"version"`
+	Crypto   CryptoJSON      `json:"crypto"`
+	Accounts []hdAccountJSON `json:"accounts"`
+}
+
+// hdAccountJSON is a pinned account of an HD wallet.
+type hdAccountJSON struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
+}
+
+// HDKeyStore manages a directory of hierarchical deterministic software wallets.
+// Each wallet stores a BIP-39 mnemonic encrypted with the Web3 Secret Storage
+// scheme, and derives its accounts on demand according to BIP-32.
+//
+// Note, the optional BIP-39 passphrase is not supported, the seeds are always
+// derived with an empty one.
+type HDKeyStore struct {
+	keydir  string // Directory containing the wallet files
+	scryptN int    // Scrypt N parameter for encrypting new wallets
+	scryptP int    // Scrypt P parameter for encrypting new wallets
+
+	wallets   []*hdWallet // Wallets stored in the directory, sorted by URL
+	refreshed time.Time   // Time of the last directory scan
+
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+
+	mu sync.RWMutex
+}
+
+// NewHDKeyStore creates an HD wallet keystore for the given directory.
+func NewHDKeyStore(keydir string, scryptN, scryptP int) *HDKeyStore {
+	keydir, _ = filepath.Abs(keydir)
+	return &HDKeyStore{
+		keydir:  keydir,
+		scryptN: scryptN,
+		scryptP: scryptP,
+	}
+}
+
+// Wallets implements accounts.Backend, returning all HD wallets from the keystore
+// directory.
+func (ks *HDKeyStore) Wallets() []accounts.Wallet {
+	// Make sure the list of wallets is in sync with the directory
+	ks.refreshWallets()
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(ks.wallets))
+	for i, wallet := range ks.wallets {
+		cpy[i] = wallet
+	}
+	return cpy
+}
+
+// refreshWallets scans the keystore directory for wallet files added or removed
+// since the last scan and fires the corresponding wallet events. Scans are
+// throttled to avoid hitting the filesystem on every wallet listing.
+func (ks *HDKeyStore) refreshWallets() {
+	ks.mu.Lock()
+	if time.Since(ks.refreshed) < walletRefreshCycle {
+		ks.mu.Unlock()
+		return
+	}
+	ks.refreshed = time.Now()
+
+	files, err := ioutil.ReadDir(ks.keydir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to scan HD keystore", "dir", ks.keydir, "err", err)
+	}
+	known := make(map[string]*hdWallet)
+	for _, wallet := range ks.wallets {
+		known[wallet.url.Path] = wallet
+	}
+	var (
+		wallets []*hdWallet
+		events  []accounts.WalletEvent
+	)
+	for _, fi := range files {
+		if nonKeyFile(fi) {
+			continue
+		}
+		path := filepath.Join(ks.keydir, fi.Name())
+		if wallet, ok := known[path]; ok {
+			wallets = append(wallets, wallet)
+			delete(known, path)
+			continue
+		}
+		wallet, err := ks.loadWallet(path)
+		if err != nil {
+			log.Debug("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		wallets = append(wallets, wallet)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	for _, wallet := range known {
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	sort.Slice(wallets, func(i, j int) bool { return wallets[i].url.Cmp(wallets[j].url) < 0 })
+	ks.wallets = wallets
+	ks.mu.Unlock()
+
+	for _, event := range events {
+		ks.updateFeed.Send(event)
+	}
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of HD wallets.
+func (ks *HDKeyStore) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return ks.updateScope.Track(ks.updateFeed.Subscribe(sink))
+}
+
+// NewWallet generates a new random mnemonic and stores it as an HD wallet
+// encrypted with the passphrase. The mnemonic is returned to allow the user to
+// back it up, it cannot be retrieved afterwards.
+func (ks *HDKeyStore) NewWallet(passphrase string) (accounts.Wallet, string, error) {
+	mnemonic, err := newMnemonic(mnemonicEntropyBits)
+	if err != nil {
+		return nil, "", err
+	}
+	wallet, err := ks.ImportMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return wallet, mnemonic, nil
+}
+
+// ImportMnemonic stores the given BIP-39 mnemonic as an HD wallet encrypted with
+// the passphrase. The first account of the default derivation path is pinned.
+func (ks *HDKeyStore) ImportMnemonic(mnemonic, passphrase string) (accounts.Wallet, error) {
+	mnemonic = normalizeMnemonic(mnemonic)
+	seed, err := mnemonicToSeed(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveHDKey(seed, accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		return nil, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	cryptoStruct, err := EncryptDataV3([]byte(mnemonic), []byte(passphrase), ks.scryptN, ks.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.NewRandom()
+	file := &hdWalletJSON{
+		Id:       id.String(),
+		Version:  hdWalletVersion,
+		Crypto:   cryptoStruct,
+		Accounts: []hdAccountJSON{{Address: address, Path: accounts.DefaultBaseDerivationPath.String()}},
+	}
+	path := filepath.Join(ks.keydir, fmt.Sprintf("UTC--%s--hd-%s", toISO8601(time.Now().UTC()), id))
+	wallet, err := newHDWallet(ks, path, file)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.storeWallet(wallet); err != nil {
+		return nil, err
+	}
+	ks.mu.Lock()
+	ks.wallets = append(ks.wallets, wallet)
+	sort.Slice(ks.wallets, func(i, j int) bool { return ks.wallets[i].url.Cmp(ks.wallets[j].url) < 0 })
+	ks.mu.Unlock()
+
+	ks.updateFeed.Send(accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	return wallet, nil
+}
+
+// loadWallet reads an HD wallet file from disk, without decrypting it.
+func (ks *HDKeyStore) loadWallet(path string) (*hdWallet, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := new(hdWalletJSON)
+	if err := json.Unmarshal(blob, file); err != nil {
+		return nil, err
+	}
+	if file.Version != hdWalletVersion {
+		return nil, fmt.Errorf("unsupported HD wallet version %d", file.Version)
+	}
+	return newHDWallet(ks, path, file)
+}
+
+// storeWallet writes the encrypted mnemonic and the pinned accounts of an HD
+// wallet to disk.
+//
+// Note, storeWallet assumes the wallet's lock is held!
+func (ks *HDKeyStore) storeWallet(w *hdWallet) error {
+	file := &hdWalletJSON{
+		Id:      w.id,
+		Version: hdWalletVersion,
+		Crypto:  w.crypto,
+	}
+	for _, account := range w.accounts {
+		if w.pinned[account.Address] {
+			file.Accounts = append(file.Accounts, hdAccountJSON{Address: account.Address, Path: w.paths[account.Address].String()})
+		}
+	}
+	blob, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(w.url.Path, blob)
+}
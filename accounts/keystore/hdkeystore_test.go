@@ -0,0 +1,212 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testMnemonic = strings.Repeat("abandon ", 11) + "about"
+	testAddress0 = common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	testAddress1 = common.HexToAddress("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0")
+)
+
+// Tests the mnemonic generation and seed derivation against the BIP-39 vectors.
+func TestMnemonicVectors(t *testing.T) {
+	tests := []struct {
+		entropy  []byte
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  make([]byte, 16),
+			mnemonic: testMnemonic,
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  bytes.Repeat([]byte{0x7f}, 16),
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  make([]byte, 32),
+			mnemonic: strings.Repeat("abandon ", 23) + "art",
+		},
+	}
+	for i, tt := range tests {
+		mnemonic, err := entropyToMnemonic(tt.entropy)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode entropy: %v", i, err)
+		}
+		if mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, tt.mnemonic)
+		}
+		if tt.seed == "" {
+			continue
+		}
+		seed, err := mnemonicToSeed(mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("test %d: failed to derive seed: %v", i, err)
+		}
+		if have := common.Bytes2Hex(seed); have != tt.seed {
+			t.Errorf("test %d: seed mismatch: have %s, want %s", i, have, tt.seed)
+		}
+	}
+	// Ensure invalid mnemonics are rejected
+	if err := validateMnemonic(strings.TrimSpace(strings.Repeat("abandon ", 12))); err != errMnemonicChecksum {
+		t.Errorf("bad checksum error mismatch: have %v, want %v", err, errMnemonicChecksum)
+	}
+	if err := validateMnemonic("abandon about"); err != errMnemonicLength {
+		t.Errorf("bad length error mismatch: have %v, want %v", err, errMnemonicLength)
+	}
+}
+
+// Tests that accounts are derived from the seed according to BIP-32 and BIP-44.
+func TestHDKeyDerivation(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		path     string
+		address  common.Address
+	}{
+		{testMnemonic, "m/44'/60'/0'/0/0", testAddress0},
+		{testMnemonic, "m/44'/60'/0'/0/1", testAddress1},
+		{strings.Repeat("test ", 11) + "junk", "m/44'/60'/0'/0/0", common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")},
+		{strings.Repeat("test ", 11) + "junk", "m/44'/60'/0'/0/1", common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")},
+	}
+	for i, tt := range tests {
+		seed, err := mnemonicToSeed(tt.mnemonic, "")
+		if err != nil {
+			t.Fatalf("test %d: failed to derive seed: %v", i, err)
+		}
+		path, _ := accounts.ParseDerivationPath(tt.path)
+		key, err := deriveHDKey(seed, path)
+		if err != nil {
+			t.Fatalf("test %d: failed to derive key: %v", i, err)
+		}
+		if have := crypto.PubkeyToAddress(key.PublicKey); have != tt.address {
+			t.Errorf("test %d: address mismatch: have %x, want %x", i, have, tt.address)
+		}
+	}
+}
+
+// Tests importing, opening, deriving and signing with HD wallets, and that the
+// pinned accounts survive reloading the keystore.
+func TestHDKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-hdkeystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := NewHDKeyStore(dir, veryLightScryptN, veryLightScryptP)
+	if _, err := ks.ImportMnemonic("abandon about", "foo"); err != errMnemonicLength {
+		t.Fatalf("invalid mnemonic error mismatch: have %v, want %v", err, errMnemonicLength)
+	}
+	wallet, err := ks.ImportMnemonic("  "+strings.ToUpper(testMnemonic)+"\n", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if accs := wallet.Accounts(); len(accs) != 1 || accs[0].Address != testAddress0 {
+		t.Fatalf("imported accounts mismatch: have %v, want %x", accs, testAddress0)
+	}
+	// Deriving and signing needs the wallet opened
+	path, _ := accounts.ParseDerivationPath("m/44'/60'/0'/0/1")
+	if _, err := wallet.Derive(path, true); err != accounts.ErrWalletClosed {
+		t.Fatalf("closed derivation error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignHash(accounts.Account{Address: testAddress0}, make([]byte, 32)); err != ErrLocked {
+		t.Fatalf("closed signing error mismatch: have %v, want %v", err, ErrLocked)
+	}
+	if err := wallet.Open(""); err == nil {
+		t.Fatalf("opened password protected wallet without passphrase")
+	} else if _, ok := err.(*accounts.AuthNeededError); !ok {
+		t.Fatalf("missing passphrase error mismatch: have %v, want %T", err, &accounts.AuthNeededError{})
+	}
+	if err := wallet.Open("bar"); err != ErrDecrypt {
+		t.Fatalf("bad passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if account.Address != testAddress1 {
+		t.Fatalf("derived account mismatch: have %x, want %x", account.Address, testAddress1)
+	}
+	hash := crypto.Keccak256([]byte("hello"))
+	sig, err := wallet.SignHash(account, hash)
+	if err != nil {
+		t.Fatalf("failed to sign hash: %v", err)
+	}
+	if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != testAddress1 {
+		t.Fatalf("signature recovery mismatch: have %v (%v), want %x", pub, err, testAddress1)
+	}
+	wallet.Close()
+
+	// Reload the keystore and check the pinned accounts and passphrase signing
+	ks = NewHDKeyStore(dir, veryLightScryptN, veryLightScryptP)
+	wallets := ks.Wallets()
+	if len(wallets) != 1 || wallets[0].URL() != wallet.URL() {
+		t.Fatalf("reloaded wallets mismatch: have %v, want %v", wallets, wallet.URL())
+	}
+	if accs := wallets[0].Accounts(); len(accs) != 2 || accs[0].Address != testAddress0 || accs[1].Address != testAddress1 {
+		t.Fatalf("reloaded accounts mismatch: have %v", accs)
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := wallets[0].SignTxWithPassphrase(account, "foo", tx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), signed); err != nil || from != testAddress1 {
+		t.Fatalf("transaction sender mismatch: have %x (%v), want %x", from, err, testAddress1)
+	}
+}
+
+// Tests that a wallet protected by an empty password can be opened with an empty
+// passphrase, as done automatically on startup.
+func TestHDKeyStoreEmptyPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-hdkeystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := NewHDKeyStore(dir, veryLightScryptN, veryLightScryptP)
+	wallet, err := ks.ImportMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if err := wallet.Open(""); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	if status, _ := wallet.Status(); status != "Unlocked" {
+		t.Fatalf("wallet status mismatch: have %q, want %q", status, "Unlocked")
+	}
+}
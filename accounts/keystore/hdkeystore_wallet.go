@@ -0,0 +1,402 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// selfDeriveThrottling is the minimum time between two account discoveries of
+// an HD wallet, to avoid hammering the chain on every account listing.
+const selfDeriveThrottling = time.Second
+
+// hdWallet implements the accounts.Wallet interface for a hierarchical
+// deterministic wallet stored in an HDKeyStore.
+type hdWallet struct {
+	url    accounts.URL // Location of the wallet file
+	store  *HDKeyStore  // Keystore where the wallet originates from
+	id     string       // Unique identifier of the wallet file
+	crypto CryptoJSON   // Encrypted mnemonic of the wallet
+
+	accounts []accounts.Account                         // Pinned and self-derived accounts
+	paths    map[common.Address]accounts.DerivationPath // Derivation paths of the known accounts
+	pinned   map[common.Address]bool                    // Accounts persisted in the wallet file
+
+	seed []byte // Decrypted BIP-39 seed while the wallet is open
+
+	deriveNextPath accounts.DerivationPath   // Next derivation path for account auto-discovery
+	deriveChain    ethereum.ChainStateReader // Blockchain state reader to discover used account with
+	deriveTime     time.Time                 // Time of the last account auto-discovery
+
+	lock sync.RWMutex
+}
+
+// newHDWallet creates a closed wallet from its on-disk representation.
+func newHDWallet(store *HDKeyStore, path string, file *hdWalletJSON) (*hdWallet, error) {
+	w := &hdWallet{
+		url:    accounts.URL{Scheme: HDKeyStoreScheme, Path: path},
+		store:  store,
+		id:     file.Id,
+		crypto: file.Crypto,
+		paths:  make(map[common.Address]accounts.DerivationPath),
+		pinned: make(map[common.Address]bool),
+	}
+	for _, account := range file.Accounts {
+		path, err := accounts.ParseDerivationPath(account.Path)
+		if err != nil {
+			return nil, err
+		}
+		w.track(account.Address, path)
+		w.pinned[account.Address] = true
+	}
+	return w, nil
+}
+
+// track adds an account to the list of known ones if not yet present.
+//
+// Note, track assumes the wallet's lock is held!
+func (w *hdWallet) track(address common.Address, path accounts.DerivationPath) accounts.Account {
+	account := accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+	}
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = path
+	}
+	return account
+}
+
+// URL implements accounts.Wallet, returning the URL of the wallet file.
+func (w *hdWallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the seed of the wallet
+// is decrypted or not.
+func (w *hdWallet) Status() (string, error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if w.seed != nil {
+		return "Unlocked", nil
+	}
+	return "Locked", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet to allow
+// deriving accounts and signing with them without further authentication.
+func (w *hdWallet) Open(passphrase string) error {
+	seed, err := w.decryptSeed(passphrase)
+	if err != nil {
+		// Wallets are opened automatically with an empty passphrase on startup,
+		// ask for the real one if the wallet is protected by a password
+		if passphrase == "" && err == ErrDecrypt {
+			return accounts.NewAuthNeededError("password")
+		}
+		return err
+	}
+	w.lock.Lock()
+	if w.seed != nil {
+		w.lock.Unlock()
+		return accounts.ErrWalletAlreadyOpen
+	}
+	w.seed = seed
+	w.lock.Unlock()
+
+	// Notify anyone listening for wallet events that the accounts are accessible
+	go w.store.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// decryptSeed decrypts the mnemonic of the wallet and converts it into the root
+// seed of the account derivations.
+func (w *hdWallet) decryptSeed(passphrase string) ([]byte, error) {
+	mnemonic, err := DecryptDataV3(w.crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(mnemonic)
+	return mnemonicToSeed(string(mnemonic), "")
+}
+
+// Close implements accounts.Wallet, wiping the decrypted seed from memory and
+// forgetting the self-derived accounts.
+func (w *hdWallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	zeroBytes(w.seed)
+	w.seed = nil
+
+	accs := w.accounts[:0]
+	for _, account := range w.accounts {
+		if w.pinned[account.Address] {
+			accs = append(accs, account)
+		} else {
+			delete(w.paths, account.Address)
+		}
+	}
+	w.accounts = accs
+	w.deriveChain = nil
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts pinned to
+// the wallet. If self-derivation was enabled, the account list is periodically
+// expanded based on current chain state.
+func (w *hdWallet) Accounts() []accounts.Account {
+	w.selfDerive()
+
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// selfDerive attempts to discover new non-zero accounts, starting from the next
+// derivation path and stopping at the first empty one.
+func (w *hdWallet) selfDerive() {
+	// Derivation needs an open wallet and a chain, skip if either unavailable
+	w.lock.Lock()
+	if w.seed == nil || w.deriveChain == nil || time.Since(w.deriveTime) < selfDeriveThrottling {
+		w.lock.Unlock()
+		return
+	}
+	w.deriveTime = time.Now()
+
+	seed := common.CopyBytes(w.seed)
+	chain, nextPath := w.deriveChain, append(accounts.DerivationPath{}, w.deriveNextPath...)
+	w.lock.Unlock()
+
+	defer zeroBytes(seed)
+
+	// Derive and check accounts until an empty one is found, outside of the lock
+	var (
+		addrs []common.Address
+		paths []accounts.DerivationPath
+		ctx   = context.Background()
+	)
+	for empty := false; !empty; {
+		key, err := deriveHDKey(seed, nextPath)
+		if err != nil {
+			log.Warn("HD wallet account derivation failed", "path", nextPath, "err", err)
+			break
+		}
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		zeroKey(key)
+
+		balance, err := chain.BalanceAt(ctx, address, nil)
+		if err != nil {
+			log.Warn("HD wallet balance retrieval failed", "err", err)
+			break
+		}
+		nonce, err := chain.NonceAt(ctx, address, nil)
+		if err != nil {
+			log.Warn("HD wallet nonce retrieval failed", "err", err)
+			break
+		}
+		// If the next account is empty, stop self-derivation, but add it nonetheless
+		if balance.Sign() == 0 && nonce == 0 {
+			empty = true
+		}
+		addrs = append(addrs, address)
+		paths = append(paths, append(accounts.DerivationPath{}, nextPath...))
+
+		if !empty {
+			nextPath[len(nextPath)-1]++
+		}
+	}
+	// Insert any accounts successfully derived and shift the derivation forward
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.deriveChain != chain {
+		return // Wallet closed or derivation reset meanwhile
+	}
+	for i, address := range addrs {
+		if _, known := w.paths[address]; !known {
+			log.Info("HD wallet discovered new account", "address", address, "path", paths[i])
+		}
+		w.track(address, paths[i])
+	}
+	w.deriveNextPath = nextPath
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not known to this wallet instance.
+func (w *hdWallet) Contains(account accounts.Account) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return false
+	}
+	return account.URL == (accounts.URL{}) || account.URL.Path == fmt.Sprintf("%s/%s", w.url.Path, path)
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and persisted into the wallet file.
+func (w *hdWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.seed == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	key, err := deriveHDKey(w.seed, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	if !pin {
+		return accounts.Account{
+			Address: address,
+			URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+		}, nil
+	}
+	account := w.track(address, append(accounts.DerivationPath{}, path...))
+	if !w.pinned[address] {
+		w.pinned[address] = true
+		if err := w.store.storeWallet(w); err != nil {
+			delete(w.pinned, address)
+			return accounts.Account{}, err
+		}
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, trying to discover accounts that the
+// user used previously (based on the chain state), but ones that he/she did not
+// explicitly pin to the wallet manually. To avoid chain head monitoring, self
+// derivation only runs during account listing (and even then throttled).
+func (w *hdWallet) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.deriveNextPath = append(accounts.DerivationPath{}, base...)
+	w.deriveChain = chain
+	w.deriveTime = time.Time{}
+}
+
+// key derives the private key of an account known to the wallet. If no seed is
+// given, the one of the open wallet is used.
+func (w *hdWallet) key(account accounts.Account, seed []byte) (*ecdsa.PrivateKey, error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if seed == nil {
+		if seed = w.seed; seed == nil {
+			return nil, ErrLocked
+		}
+	}
+	return deriveHDKey(seed, path)
+}
+
+// SignHash implements accounts.Wallet, signing the given hash with an account
+// of the open wallet.
+func (w *hdWallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	key, err := w.key(account, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with an
+// account of the open wallet.
+func (w *hdWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.key(account, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return signTxWithKey(tx, chainID, key)
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, decrypting the seed of the
+// wallet with the passphrase to sign the given hash.
+func (w *hdWallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	seed, err := w.decryptSeed(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	key, err := w.key(account, seed)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, decrypting the seed of the
+// wallet with the passphrase to sign the given transaction.
+func (w *hdWallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	seed, err := w.decryptSeed(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	key, err := w.key(account, seed)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return signTxWithKey(tx, chainID, key)
+}
+
+// signTxWithKey signs a transaction with EIP155 or homestead rules, depending
+// on the presence of the chain ID.
+func signTxWithKey(tx *types.Transaction, chainID *big.Int, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	if chainID != nil {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	}
+	return types.SignTx(tx, types.HomesteadSigner{}, key)
+}
+
+// zeroBytes overwrites a byte slice with zeroes.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
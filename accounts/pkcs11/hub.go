@@ -0,0 +1,79 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Scheme is the protocol scheme prefixing account and wallet URLs.
+const Scheme = "pkcs11"
+
+// HubType is the reflect type of a PKCS#11 token hub.
+var HubType = reflect.TypeOf(&Hub{})
+
+// Hub is an accounts.Backend exposing the tokens of a PKCS#11 module as wallets.
+// The tokens are enumerated once when the module is loaded, tokens inserted or
+// removed afterwards are not detected.
+type Hub struct {
+	wallets     []accounts.Wallet       // Wallets of the tokens present in the module
+	updateFeed  event.Feed              // Event feed to notify wallet openings
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+}
+
+// NewHub loads the PKCS#11 module from the given path and creates a wallet for
+// each of its tokens.
+func NewHub(module string) (*Hub, error) {
+	tokens, err := openTokens(module)
+	if err != nil {
+		return nil, err
+	}
+	return newHub(tokens)
+}
+
+// newHub creates a hub tracking the given tokens.
+func newHub(tokens []token) (*Hub, error) {
+	hub := new(Hub)
+	for _, token := range tokens {
+		wallet, err := newWallet(hub, token)
+		if err != nil {
+			return nil, err
+		}
+		hub.wallets = append(hub.wallets, wallet)
+	}
+	sort.Slice(hub.wallets, func(i, j int) bool {
+		return hub.wallets[i].URL().Cmp(hub.wallets[j].URL()) < 0
+	})
+	return hub, nil
+}
+
+// Wallets implements accounts.Backend, returning the wallets of the tokens.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	cpy := make([]accounts.Wallet, len(hub.wallets))
+	copy(cpy, hub.wallets)
+	return cpy
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the opening of the wallets.
+func (hub *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return hub.updateScope.Track(hub.updateFeed.Subscribe(sink))
+}
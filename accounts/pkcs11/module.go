@@ -0,0 +1,181 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/miekg/pkcs11"
+)
+
+// moduleToken is a token accessed through a session of a PKCS#11 module.
+type moduleToken struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	serial  string
+}
+
+// openTokens loads the PKCS#11 module from the given path and opens a session
+// with each token present in its slots.
+func openTokens(path string) (tokens []token, err error) {
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", path)
+	}
+	// Only finalize the module on failure if it was initialized here, another
+	// user of the module in this process might still depend on it
+	initialized := true
+	if err := ctx.Initialize(); err != nil {
+		if err != pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+			ctx.Destroy()
+			return nil, err
+		}
+		initialized = false
+	}
+	defer func() {
+		if err != nil {
+			if initialized {
+				ctx.Finalize()
+			}
+			ctx.Destroy()
+		}
+	}()
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []pkcs11.SessionHandle
+	defer func() {
+		if err != nil {
+			for _, session := range sessions {
+				ctx.CloseSession(session)
+			}
+		}
+	}()
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return nil, err
+		}
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+		tokens = append(tokens, &moduleToken{
+			ctx:     ctx,
+			session: session,
+			serial:  strings.TrimSpace(info.SerialNumber),
+		})
+	}
+	return tokens, nil
+}
+
+// Serial implements token, returning the serial number of the token.
+func (t *moduleToken) Serial() string {
+	return t.serial
+}
+
+// findObjects returns the handles of all objects matching the template.
+func (t *moduleToken) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return nil, err
+	}
+	defer t.ctx.FindObjectsFinal(t.session)
+
+	var handles []pkcs11.ObjectHandle
+	for {
+		batch, _, err := t.ctx.FindObjects(t.session, 16)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return handles, nil
+		}
+		handles = append(handles, batch...)
+	}
+}
+
+// Keys implements token, enumerating the public secp256k1 keys of the token.
+func (t *moduleToken) Keys() ([]tokenKey, error) {
+	handles, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var keys []tokenKey
+	for _, handle := range handles {
+		attrs, err := t.ctx.GetAttributeValue(t.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		pub, err := parseECPoint(attrs[1].Value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tokenKey{id: attrs[0].Value, pub: pub})
+	}
+	return keys, nil
+}
+
+// Login implements token, logging the user in with the PIN.
+func (t *moduleToken) Login(pin string) error {
+	switch err := t.ctx.Login(t.session, pkcs11.CKU_USER, pin); err {
+	case nil, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN):
+		return nil
+	case pkcs11.Error(pkcs11.CKR_PIN_INCORRECT):
+		return accounts.ErrInvalidPassphrase
+	default:
+		return err
+	}
+}
+
+// Logout implements token, logging the user out of the token.
+func (t *moduleToken) Logout() error {
+	if err := t.ctx.Logout(t.session); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_NOT_LOGGED_IN) {
+		return err
+	}
+	return nil
+}
+
+// Sign implements token, signing the hash with the private key of the given
+// identifier using the raw ECDSA mechanism.
+func (t *moduleToken) Sign(id []byte, hash []byte) ([]byte, error) {
+	handles, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(handles) != 1 {
+		return nil, fmt.Errorf("found %d private keys with id %x", len(handles), id)
+	}
+	if err := t.ctx.SignInit(t.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, handles[0]); err != nil {
+		return nil, err
+	}
+	return t.ctx.Sign(t.session, hash)
+}
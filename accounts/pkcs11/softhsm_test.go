@@ -0,0 +1,67 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests signing with a real PKCS#11 module, such as SoftHSM. The test is skipped
+// unless the module is configured in the environment, e.g.:
+//
+//   softhsm2-util --init-token --free --label geth --pin 1234 --so-pin 1234
+//   pkcs11-tool --module $PKCS11_MODULE --login --pin 1234 --keypairgen \
+//     --key-type EC:secp256k1 --id 01
+//   PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so PKCS11_PIN=1234 go test
+func TestModuleSigning(t *testing.T) {
+	module, pin := os.Getenv("PKCS11_MODULE"), os.Getenv("PKCS11_PIN")
+	if module == "" {
+		t.Skip("PKCS11_MODULE not set")
+	}
+	hub, err := NewHub(module)
+	if err != nil {
+		t.Fatalf("failed to load module: %v", err)
+	}
+	var signed bool
+	for _, wallet := range hub.Wallets() {
+		accs := wallet.Accounts()
+		if len(accs) == 0 {
+			continue
+		}
+		if err := wallet.Open(pin); err != nil {
+			t.Fatalf("failed to open %s: %v", wallet.URL(), err)
+		}
+		hash := crypto.Keccak256([]byte("hello"))
+		for _, account := range accs {
+			sig, err := wallet.SignHash(account, hash)
+			if err != nil {
+				t.Fatalf("failed to sign with %s: %v", account.URL, err)
+			}
+			if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != account.Address {
+				t.Errorf("recovery mismatch for %s: have %v (%v), want %x", account.URL, pub, err, account.Address)
+			}
+			signed = true
+		}
+		wallet.Close()
+	}
+	if !signed {
+		t.Fatalf("no secp256k1 keys found on the tokens")
+	}
+}
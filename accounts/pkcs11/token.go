@@ -0,0 +1,78 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pkcs11 implements support for secp256k1 keys held in PKCS#11 tokens,
+// such as hardware security modules.
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// secp256k1OID is the DER encoded object identifier of the secp256k1 curve, as
+// stored in the CKA_EC_PARAMS attribute of the keys.
+var secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+// errInvalidECPoint is returned if the public point of a key cannot be decoded.
+var errInvalidECPoint = errors.New("invalid EC point")
+
+// token defines the functionality of a PKCS#11 token that the wallets need,
+// abstracting away the underlying cryptoki module.
+type token interface {
+	// Serial retrieves the serial number of the token.
+	Serial() string
+
+	// Keys enumerates the secp256k1 public keys stored on the token.
+	Keys() ([]tokenKey, error)
+
+	// Login authenticates the user to the token with the PIN, returning the error
+	// accounts.ErrInvalidPassphrase if the PIN is incorrect.
+	Login(pin string) error
+
+	// Logout ends the authenticated session with the token.
+	Logout() error
+
+	// Sign creates a raw (r || s) ECDSA signature of the hash with the private key
+	// having the given identifier. The user must be logged in.
+	Sign(id []byte, hash []byte) ([]byte, error)
+}
+
+// tokenKey is a secp256k1 key stored on a token, identified by its CKA_ID.
+type tokenKey struct {
+	id  []byte
+	pub *ecdsa.PublicKey
+}
+
+// parseECPoint decodes the CKA_EC_POINT attribute of a key, which is the DER
+// encoded octet string of an uncompressed point. Some tokens omit the encoding.
+func parseECPoint(point []byte) (*ecdsa.PublicKey, error) {
+	if len(point) != 65 || point[0] != 0x04 {
+		var raw []byte
+		if rest, err := asn1.Unmarshal(point, &raw); err != nil || len(rest) > 0 {
+			return nil, errInvalidECPoint
+		}
+		point = raw
+	}
+	pub, err := crypto.UnmarshalPubkey(point)
+	if err != nil {
+		return nil, errInvalidECPoint
+	}
+	return pub, nil
+}
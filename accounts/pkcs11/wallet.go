@@ -0,0 +1,276 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// errUnrecoverable is returned if a signature created by the token does not
+// recover to the public key of the signing account.
+var errUnrecoverable = errors.New("token signature not recoverable")
+
+// wallet is an accounts.Wallet backed by the secp256k1 keys of a PKCS#11 token.
+// Opening the wallet logs the user into the token with the PIN.
+type wallet struct {
+	hub   *Hub         // Hub the wallet originates from
+	url   accounts.URL // Location of the token
+	token token        // Token holding the keys
+
+	accounts []accounts.Account          // Accounts of the keys on the token
+	keys     map[common.Address]tokenKey // Keys of the token by account address
+	open     bool                        // Whether the user is logged into the token
+
+	lock sync.Mutex // Protects the wallet fields and serialises the token access
+}
+
+// newWallet creates a wallet for the given token, enumerating its keys.
+func newWallet(hub *Hub, token token) (*wallet, error) {
+	keys, err := token.Keys()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i].id, keys[j].id) < 0 })
+
+	w := &wallet{
+		hub:   hub,
+		url:   accounts.URL{Scheme: Scheme, Path: token.Serial()},
+		token: token,
+		keys:  make(map[common.Address]tokenKey),
+	}
+	for _, key := range keys {
+		address := crypto.PubkeyToAddress(*key.pub)
+		if _, ok := w.keys[address]; ok {
+			continue
+		}
+		w.keys[address] = key
+		w.accounts = append(w.accounts, accounts.Account{
+			Address: address,
+			URL:     accounts.URL{Scheme: Scheme, Path: fmt.Sprintf("%s/%x", w.url.Path, key.id)},
+		})
+	}
+	return w, nil
+}
+
+// URL implements accounts.Wallet, returning the URL of the token.
+func (w *wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the user is logged into
+// the token.
+func (w *wallet) Status() (string, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.open {
+		return "Logged in", nil
+	}
+	return "Locked", nil
+}
+
+// Open implements accounts.Wallet, logging the user into the token with the PIN
+// given as the passphrase.
+func (w *wallet) Open(passphrase string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.open {
+		return accounts.ErrWalletAlreadyOpen
+	}
+	if passphrase == "" {
+		return accounts.NewAuthNeededError("PIN")
+	}
+	if err := w.token.Login(passphrase); err != nil {
+		return err
+	}
+	w.open = true
+
+	// Notify anyone listening for wallet events that the keys are accessible
+	go w.hub.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// Close implements accounts.Wallet, logging the user out of the token.
+func (w *wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		return nil
+	}
+	w.open = false
+	return w.token.Logout()
+}
+
+// Accounts implements accounts.Wallet, returning the accounts of the keys on the
+// token.
+func (w *wallet) Accounts() []accounts.Account {
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not held by the token.
+func (w *wallet) Contains(account accounts.Account) bool {
+	for _, acc := range w.accounts {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == acc.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is not supported by PKCS#11 tokens.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for PKCS#11 tokens.
+func (w *wallet) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {
+}
+
+// signHash signs the hash with the key of the account on the token, converting
+// the signature into Ethereum's recoverable form.
+//
+// Note, signHash assumes the wallet's lock is held and the user logged in!
+func (w *wallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	key, ok := w.keys[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	sig, err := w.token.Sign(key.id, hash)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeSignature(hash, sig, key.pub)
+}
+
+// SignHash implements accounts.Wallet, signing the given hash with a key of the
+// token. The wallet needs to be opened first.
+func (w *wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		return nil, accounts.NewAuthNeededError("PIN")
+	}
+	return w.signHash(account, hash)
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with a key of
+// the token. The wallet needs to be opened first.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		return nil, accounts.NewAuthNeededError("PIN")
+	}
+	return w.signTx(account, tx, chainID)
+}
+
+// signTx signs the transaction with EIP155 or homestead rules, depending on the
+// presence of the chain ID.
+//
+// Note, signTx assumes the wallet's lock is held and the user logged in!
+func (w *wallet) signTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	sig, err := w.signHash(account, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, signing the given hash with
+// a key of the token, logging in with the PIN given as passphrase if needed.
+func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		if err := w.token.Login(passphrase); err != nil {
+			return nil, err
+		}
+		defer w.token.Logout()
+	}
+	return w.signHash(account, hash)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, signing the given transaction
+// with a key of the token, logging in with the PIN given as passphrase if needed.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		if err := w.token.Login(passphrase); err != nil {
+			return nil, err
+		}
+		defer w.token.Logout()
+	}
+	return w.signTx(account, tx, chainID)
+}
+
+// normalizeSignature converts a raw (r || s) ECDSA signature created by a token
+// into Ethereum's recoverable (r || s || v) form. High s values are flipped into
+// the lower half of the curve order, and the recovery id is found by trying to
+// recover the public key of the signer.
+func normalizeSignature(hash []byte, sig []byte, pub *ecdsa.PublicKey) ([]byte, error) {
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("invalid token signature length: %d", len(sig))
+	}
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(secp256k1N, s)
+	}
+	recoverable := make([]byte, 65)
+	copy(recoverable, sig[:32])
+	copy(recoverable[32:], math.PaddedBigBytes(s, 32))
+
+	want := crypto.FromECDSAPub(pub)
+	for v := byte(0); v < 2; v++ {
+		recoverable[64] = v
+		if have, err := crypto.Ecrecover(hash, recoverable); err == nil && bytes.Equal(have, want) {
+			return recoverable, nil
+		}
+	}
+	return nil, errUnrecoverable
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testToken is an in-memory token signing with high s values half the time, as
+// tokens are not aware of Ethereum's malleability rules.
+type testToken struct {
+	keys     map[string]*ecdsa.PrivateKey
+	pin      string
+	loggedIn bool
+	signs    int
+}
+
+func newTestToken(pin string, n int) *testToken {
+	t := &testToken{keys: make(map[string]*ecdsa.PrivateKey), pin: pin}
+	for i := 0; i < n; i++ {
+		t.keys[string([]byte{byte(i)})], _ = crypto.GenerateKey()
+	}
+	return t
+}
+
+func (t *testToken) Serial() string { return "0123456789abcdef" }
+
+func (t *testToken) Keys() ([]tokenKey, error) {
+	var keys []tokenKey
+	for id, key := range t.keys {
+		keys = append(keys, tokenKey{id: []byte(id), pub: &key.PublicKey})
+	}
+	return keys, nil
+}
+
+func (t *testToken) Login(pin string) error {
+	if pin != t.pin {
+		return accounts.ErrInvalidPassphrase
+	}
+	t.loggedIn = true
+	return nil
+}
+
+func (t *testToken) Logout() error {
+	t.loggedIn = false
+	return nil
+}
+
+func (t *testToken) Sign(id []byte, hash []byte) ([]byte, error) {
+	if !t.loggedIn {
+		return nil, errors.New("user not logged in")
+	}
+	sig, err := crypto.Sign(hash, t.keys[string(id)])
+	if err != nil {
+		return nil, err
+	}
+	if t.signs++; t.signs%2 == 0 {
+		s := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64]))
+		copy(sig[32:64], math.PaddedBigBytes(s, 32))
+	}
+	return sig[:64], nil
+}
+
+// Tests that the keys of a token can be used to sign after logging in, and that
+// the signatures are converted into Ethereum's recoverable form.
+func TestWalletSigning(t *testing.T) {
+	tok := newTestToken("1234", 2)
+	hub, err := newHub([]token{tok})
+	if err != nil {
+		t.Fatalf("failed to create hub: %v", err)
+	}
+	wallets := hub.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if accs := wallet.Accounts(); len(accs) != 2 {
+		t.Fatalf("account count mismatch: have %d, want 2", len(accs))
+	}
+	account := wallet.Accounts()[0]
+
+	// Signing needs the PIN, either by opening the wallet or per request
+	hash := crypto.Keccak256([]byte("hello"))
+	if _, err := wallet.SignHash(account, hash); err == nil {
+		t.Fatalf("signed without logging in")
+	}
+	if _, err := wallet.SignHashWithPassphrase(account, "4321", hash); err != accounts.ErrInvalidPassphrase {
+		t.Fatalf("bad PIN error mismatch: have %v, want %v", err, accounts.ErrInvalidPassphrase)
+	}
+	if _, err := wallet.SignHashWithPassphrase(account, "1234", hash); err != nil {
+		t.Fatalf("failed to sign with PIN: %v", err)
+	}
+	if tok.loggedIn {
+		t.Fatalf("token left logged in after signing with PIN")
+	}
+	if err := wallet.Open(""); err == nil {
+		t.Fatalf("opened without PIN")
+	}
+	if err := wallet.Open("1234"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	// Sign a few times to hit both low and high s values
+	for i := 0; i < 4; i++ {
+		sig, err := wallet.SignHash(account, hash)
+		if err != nil {
+			t.Fatalf("sign %d: failed to sign hash: %v", i, err)
+		}
+		if new(big.Int).SetBytes(sig[32:64]).Cmp(secp256k1HalfN) > 0 {
+			t.Errorf("sign %d: high s value not normalised", i)
+		}
+		if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != account.Address {
+			t.Errorf("sign %d: recovery mismatch: have %v (%v), want %x", i, pub, err, account.Address)
+		}
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	for i := 0; i < 2; i++ {
+		signed, err := wallet.SignTx(account, tx, big.NewInt(1))
+		if err != nil {
+			t.Fatalf("sign %d: failed to sign transaction: %v", i, err)
+		}
+		if from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), signed); err != nil || from != account.Address {
+			t.Errorf("sign %d: sender mismatch: have %x (%v), want %x", i, from, err, account.Address)
+		}
+	}
+	if _, err := wallet.SignHash(accounts.Account{Address: common.Address{1}}, hash); err != accounts.ErrUnknownAccount {
+		t.Errorf("unknown account error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+}
+
+// Tests that the EC points of the keys are decoded with or without their DER
+// octet string wrapping.
+func TestParseECPoint(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	point := crypto.FromECDSAPub(&key.PublicKey)
+
+	for i, blob := range [][]byte{point, append([]byte{0x04, 0x41}, point...)} {
+		pub, err := parseECPoint(blob)
+		if err != nil {
+			t.Fatalf("test %d: failed to parse point: %v", i, err)
+		}
+		if crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("test %d: public key mismatch", i)
+		}
+	}
+	if _, err := parseECPoint(point[:64]); err != errInvalidECPoint {
+		t.Errorf("truncated point error mismatch: have %v, want %v", err, errInvalidECPoint)
+	}
+}
@@ -39,7 +39,7 @@ var (
 
 	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
 	outFlag  = flag.String("out", "", "Output file for the generated binding (default = stdout)")
-	langFlag = flag.String("lang", "go", "Destination language for the bindings (go, java, objc)")
+	langFlag = flag.String("lang", "go", "Destination language for the bindings (go, java, objc, ts, py)")
 )
 
 func main() {
@@ -65,6 +65,10 @@ func main() {
 		lang = bind.LangJava
 	case "objc":
 		lang = bind.LangObjC
+	case "ts":
+		lang = bind.LangTypeScript
+	case "py":
+		lang = bind.LangPython
 	default:
 		fmt.Printf("Unsupported destination language \"%s\" (--lang)\n", *langFlag)
 		os.Exit(-1)
@@ -0,0 +1,255 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle/contract"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var commandStatus = cli.Command{
+	Name:  "status",
+	Usage: "Fetches the admins and the latest checkpoint of the oracle",
+	Flags: []cli.Flag{
+		oracleFlag,
+		nodeURLFlag,
+	},
+	Action: utils.MigrateFlags(status),
+}
+
+var commandDeploy = cli.Command{
+	Name:  "deploy",
+	Usage: "Deploy a new checkpoint oracle contract",
+	Flags: []cli.Flag{
+		nodeURLFlag,
+		keyFileFlag,
+		passphraseFlag,
+		signersFlag,
+		thresholdFlag,
+	},
+	Action: utils.MigrateFlags(deploy),
+}
+
+var commandSign = cli.Command{
+	Name:  "sign",
+	Usage: "Sign the checkpoint with the specified key",
+	Flags: []cli.Flag{
+		nodeURLFlag,
+		oracleFlag,
+		indexFlag,
+		keyFileFlag,
+		passphraseFlag,
+	},
+	Action: utils.MigrateFlags(sign),
+}
+
+var commandPublish = cli.Command{
+	Name:  "publish",
+	Usage: "Publish a checkpoint into the oracle",
+	Flags: []cli.Flag{
+		nodeURLFlag,
+		oracleFlag,
+		indexFlag,
+		signatureFlag,
+		keyFileFlag,
+		passphraseFlag,
+	},
+	Action: utils.MigrateFlags(publish),
+}
+
+// status fetches the admin list and the latest checkpoint of the oracle.
+func status(ctx *cli.Context) error {
+	client := newRPCClient(ctx)
+
+	addr, oracle := newContract(ctx, client)
+	fmt.Printf("Oracle => %s\n", addr.Hex())
+	fmt.Println()
+
+	admins, err := oracle.Contract().GetAllAdmin(nil)
+	if err != nil {
+		return err
+	}
+	for i, admin := range admins {
+		fmt.Printf("Admin %d => %s\n", i+1, admin.Hex())
+	}
+	fmt.Println()
+
+	index, hash, height, err := oracle.Contract().GetLatestCheckpoint(nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Checkpoint (published at #%d) %d => %s\n", height, index, common.Hash(hash).Hex())
+	return nil
+}
+
+// deploy deploys a checkpoint oracle contract with the specified admins and
+// signature threshold.
+func deploy(ctx *cli.Context) error {
+	var (
+		signers   []common.Address
+		threshold = uint64(ctx.Int64(thresholdFlag.Name))
+	)
+	for _, account := range strings.Split(ctx.String(signersFlag.Name), ",") {
+		if account = strings.TrimSpace(account); !common.IsHexAddress(account) {
+			utils.Fatalf("Invalid account in --signers: '%s'", account)
+		}
+		signers = append(signers, common.HexToAddress(account))
+	}
+	if threshold == 0 || threshold > uint64(len(signers)) {
+		utils.Fatalf("Invalid signature threshold %d", threshold)
+	}
+	fmt.Printf("Deploying new checkpoint oracle:\n\n")
+	for i, addr := range signers {
+		fmt.Printf("Admin %d => %s\n", i+1, addr.Hex())
+	}
+	fmt.Printf("\nSignatures needed to publish: %d\n", threshold)
+
+	key := getKey(ctx)
+	client := ethclient.NewClient(newRPCClient(ctx))
+
+	addr, tx, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(key.PrivateKey), client, signers,
+		big.NewInt(params.CHTFrequencyClient), big.NewInt(params.HelperTrieProcessConfirmations), new(big.Int).SetUint64(threshold))
+	if err != nil {
+		utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+	}
+	log.Info("Deployed checkpoint oracle", "address", addr, "tx", tx.Hash().Hex())
+
+	ctx2, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if _, err := bind.WaitDeployed(ctx2, client, tx); err != nil {
+		utils.Fatalf("Failed to wait for the deployment: %v", err)
+	}
+	return nil
+}
+
+// sign signs the specified checkpoint with the given key, producing a vote for
+// it that can be published to the oracle.
+func sign(ctx *cli.Context) error {
+	client := newRPCClient(ctx)
+
+	addr := getOracleAddr(ctx, client)
+	checkpoint := getCheckpoint(ctx, client)
+	hash := checkpoint.Hash()
+
+	fmt.Printf("Oracle     => %s\n", addr.Hex())
+	fmt.Printf("Index      => %d\n", checkpoint.SectionIndex)
+	fmt.Printf("Checkpoint => %s\n", hash.Hex())
+	fmt.Println()
+
+	key := getKey(ctx)
+	sig, err := crypto.Sign(checkpointoracle.SignatureHash(addr, checkpoint.SectionIndex, hash).Bytes(), key.PrivateKey)
+	if err != nil {
+		utils.Fatalf("Failed to sign checkpoint: %v", err)
+	}
+	fmt.Printf("Signer     => %s\n", key.Address.Hex())
+	fmt.Printf("Signature  => %s\n", hexutil.Encode(sig))
+	return nil
+}
+
+// publish submits the specified checkpoint along with the signatures collected
+// from the admins to the oracle.
+func publish(ctx *cli.Context) error {
+	client := newRPCClient(ctx)
+
+	addr, oracle := newContract(ctx, client)
+	checkpoint := getCheckpoint(ctx, client)
+	hash := checkpoint.Hash()
+
+	fmt.Printf("Publishing %d => %s:\n\n", checkpoint.SectionIndex, hash.Hex())
+
+	// Recover the signers of the votes, which must be submitted in ascending
+	// order of the signer addresses
+	type vote struct {
+		signer common.Address
+		sig    []byte
+	}
+	var (
+		votes   []vote
+		sighash = checkpointoracle.SignatureHash(addr, checkpoint.SectionIndex, hash)
+	)
+	for _, hexsig := range strings.Split(ctx.String(signatureFlag.Name), ",") {
+		sig, err := hexutil.Decode(strings.TrimSpace(hexsig))
+		if err != nil {
+			utils.Fatalf("Invalid signature '%s': %v", hexsig, err)
+		}
+		pubkey, err := crypto.SigToPub(sighash.Bytes(), sig)
+		if err != nil {
+			utils.Fatalf("Failed to recover signer of '%s': %v", hexsig, err)
+		}
+		votes = append(votes, vote{crypto.PubkeyToAddress(*pubkey), sig})
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		return bytes.Compare(votes[i].signer.Bytes(), votes[j].signer.Bytes()) < 0
+	})
+	var sigs [][]byte
+	for i, v := range votes {
+		fmt.Printf("Signer %d => %s\n", i+1, v.signer.Hex())
+		sigs = append(sigs, v.sig)
+	}
+	fmt.Println()
+
+	// Reference the latest block to prevent replaying the transaction elsewhere
+	ec := ethclient.NewClient(client)
+	head, err := ec.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve latest header: %v", err)
+	}
+	key := getKey(ctx)
+	tx, err := oracle.RegisterCheckpoint(bind.NewKeyedTransactor(key.PrivateKey), checkpoint.SectionIndex, hash, head.Number, head.Hash(), sigs)
+	if err != nil {
+		utils.Fatalf("Failed to publish checkpoint: %v", err)
+	}
+	log.Info("Sent checkpoint publication", "tx", tx.Hash().Hex())
+
+	ctx2, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx2, ec, tx)
+	if err != nil {
+		utils.Fatalf("Failed to wait for the publication: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		utils.Fatalf("Checkpoint publication failed")
+	}
+	// The oracle silently ignores checkpoints that are not yet final or stale
+	index, latest, _, err := oracle.Contract().GetLatestCheckpoint(nil)
+	if err != nil {
+		return err
+	}
+	if index != checkpoint.SectionIndex || common.Hash(latest) != hash {
+		utils.Fatalf("Checkpoint not accepted by the oracle, latest is %d => %s", index, common.Hash(latest).Hex())
+	}
+	log.Info("Published checkpoint", "index", index, "hash", hash.Hex())
+	return nil
+}
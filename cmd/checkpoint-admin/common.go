@@ -0,0 +1,124 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/console"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// newRPCClient creates an rpc client connected to the node specified by the
+// --rpc flag.
+func newRPCClient(ctx *cli.Context) *rpc.Client {
+	client, err := rpc.Dial(ctx.GlobalString(nodeURLFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to connect to Ethereum node: %v", err)
+	}
+	return client
+}
+
+// getContractAddr retrieves the address of the checkpoint oracle configured in
+// the connected node.
+func getContractAddr(client *rpc.Client) common.Address {
+	var addr common.Address
+	if err := client.Call(&addr, "les_getCheckpointContractAddress"); err != nil {
+		utils.Fatalf("Failed to fetch checkpoint oracle address: %v", err)
+	}
+	return addr
+}
+
+// getCheckpoint retrieves the checkpoint specified by the --index flag or the
+// latest one generated by the connected node.
+func getCheckpoint(ctx *cli.Context, client *rpc.Client) *params.TrustedCheckpoint {
+	var checkpoint *params.TrustedCheckpoint
+	if ctx.IsSet(indexFlag.Name) {
+		if err := client.Call(&checkpoint, "les_getCheckpoint", ctx.Int64(indexFlag.Name)); err != nil {
+			utils.Fatalf("Failed to get local checkpoint: %v", err)
+		}
+	} else {
+		if err := client.Call(&checkpoint, "les_latestCheckpoint"); err != nil {
+			utils.Fatalf("Failed to get local checkpoint: %v", err)
+		}
+	}
+	return checkpoint
+}
+
+// getOracleAddr returns the checkpoint oracle address specified by the --oracle
+// flag, or the one configured in the connected node.
+func getOracleAddr(ctx *cli.Context, client *rpc.Client) common.Address {
+	if ctx.GlobalIsSet(oracleFlag.Name) {
+		return common.HexToAddress(ctx.GlobalString(oracleFlag.Name))
+	}
+	return getContractAddr(client)
+}
+
+// newContract binds the checkpoint oracle at the address specified by the
+// --oracle flag, or the one configured in the connected node.
+func newContract(ctx *cli.Context, client *rpc.Client) (common.Address, *checkpointoracle.CheckpointOracle) {
+	addr := getOracleAddr(ctx, client)
+	contract, err := checkpointoracle.NewCheckpointOracle(addr, ethclient.NewClient(client))
+	if err != nil {
+		utils.Fatalf("Failed to bind checkpoint oracle %s: %v", addr, err)
+	}
+	return addr, contract
+}
+
+// getKey loads and decrypts the key specified by the --keyfile flag.
+func getKey(ctx *cli.Context) *keystore.Key {
+	// Read key from file.
+	keyFile := ctx.String(keyFileFlag.Name)
+	keyjson, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		utils.Fatalf("Failed to read the keyfile at '%s': %v", keyFile, err)
+	}
+	// Decrypt key with passphrase.
+	passphrase := getPassphrase(ctx)
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		utils.Fatalf("Failed to decrypt user key '%s': %v", keyFile, err)
+	}
+	return key
+}
+
+// getPassphrase obtains a passphrase given by the user. It first checks the
+// --passwordfile command line flag and ultimately prompts the user for a
+// passphrase.
+func getPassphrase(ctx *cli.Context) string {
+	passphraseFile := ctx.String(passphraseFlag.Name)
+	if passphraseFile != "" {
+		content, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			utils.Fatalf("Failed to read passphrase file '%s': %v", passphraseFile, err)
+		}
+		return strings.TrimRight(string(content), "\r\n")
+	}
+	passphrase, err := console.Stdin.PromptPassword("Passphrase: ")
+	if err != nil {
+		utils.Fatalf("Failed to read passphrase: %v", err)
+	}
+	return passphrase
+}
@@ -50,10 +50,10 @@ import (
 )
 
 // ExternalAPIVersion -- see extapi_changelog.md
-const ExternalAPIVersion = "4.0.0"
+const ExternalAPIVersion = "4.1.0"
 
 // InternalAPIVersion -- see intapi_changelog.md
-const InternalAPIVersion = "3.0.0"
+const InternalAPIVersion = "3.2.0"
 
 const legalWarning = `
 WARNING! 
@@ -376,11 +376,13 @@ func signer(c *cli.Context) error {
 		pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 		jskey := crypto.Keccak256([]byte("jsstorage"), stretchedKey)
 		confkey := crypto.Keccak256([]byte("config"), stretchedKey)
+		ledgerkey := crypto.Keccak256([]byte("ledger"), stretchedKey)
 
 		// Initialize the encrypted storages
 		pwStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
+		ledgerStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "ledger.json"), ledgerkey)
 
 		//Do we have a rule-file?
 		ruleJS, err := ioutil.ReadFile(c.GlobalString(ruleFlag.Name))
@@ -395,7 +397,7 @@ func signer(c *cli.Context) error {
 				log.Info("Could not validate ruleset hash, rules not enabled", "got", hex.EncodeToString(shasum), "expected", storedShasum)
 			} else {
 				// Initialize rules
-				ruleEngine, err := rules.NewRuleEvaluator(ui, jsStorage, pwStorage)
+				ruleEngine, err := rules.NewRuleEvaluator(ui, jsStorage, pwStorage, ledgerStorage)
 				if err != nil {
 					utils.Fatalf(err.Error())
 				}
@@ -0,0 +1,254 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dnsCommand = cli.Command{
+		Name:  "dns",
+		Usage: "DNS discovery commands",
+		Subcommands: []cli.Command{
+			dnsSyncCommand,
+			dnsSignCommand,
+			dnsTXTCommand,
+		},
+	}
+	dnsSyncCommand = cli.Command{
+		Name:      "sync",
+		Usage:     "Download a DNS discovery tree",
+		ArgsUsage: "<url> [ <tree-directory> ]",
+		Action:    dnsSync,
+	}
+	dnsSignCommand = cli.Command{
+		Name:      "sign",
+		Usage:     "Sign a DNS discovery tree",
+		ArgsUsage: "<tree-directory> <key-file>",
+		Description: `
+Sign the tree stored in the given directory with the hex encoded private key of
+the key file. The directory must contain the node records of the tree in
+nodes.json and may contain enrtree-info.json with the links of the tree. The
+signature, sequence number and URL of the tree are written to enrtree-info.json.
+`,
+		Action: dnsSign,
+		Flags: []cli.Flag{
+			dnsDomainFlag,
+			dnsSeqFlag,
+		},
+	}
+	dnsTXTCommand = cli.Command{
+		Name:      "to-txt",
+		Usage:     "Create the DNS TXT records of a signed tree",
+		ArgsUsage: "<tree-directory> [ <output-file> ]",
+		Action:    dnsToTXT,
+	}
+)
+
+var (
+	dnsDomainFlag = cli.StringFlag{
+		Name:  "domain",
+		Usage: "Domain name of the tree (defaults to the domain it was signed for before)",
+	}
+	dnsSeqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "New sequence number of the tree (defaults to the previous one plus one)",
+	}
+)
+
+const (
+	treeNodesFile = "nodes.json"
+	treeInfoFile  = "enrtree-info.json"
+)
+
+// treeInfo is the metadata of a tree, stored next to its node records.
+type treeInfo struct {
+	URL       string   `json:"url,omitempty"`
+	Seq       uint     `json:"seq"`
+	Signature string   `json:"signature,omitempty"`
+	Links     []string `json:"links,omitempty"`
+}
+
+// dnsSync downloads a tree and writes it to a tree directory if one is given.
+func dnsSync(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("need tree URL as argument")
+	}
+	url, dir := ctx.Args().Get(0), ctx.Args().Get(1)
+
+	client := dnsdisc.NewClient(dnsdisc.Config{})
+	t, err := client.SyncTree(url)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Tree %s: seq %d, %d nodes, %d links\n", url, t.Seq(), len(t.Nodes()), len(t.Links()))
+	if dir == "" {
+		return nil
+	}
+	info := &treeInfo{URL: url, Seq: t.Seq(), Signature: t.Signature(), Links: t.Links()}
+	return writeTreeDir(dir, info, t.Nodes())
+}
+
+// dnsSign signs the tree in a tree directory, updating its metadata.
+func dnsSign(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return fmt.Errorf("need tree directory and key file as arguments")
+	}
+	dir, keyfile := ctx.Args().Get(0), ctx.Args().Get(1)
+
+	info, nodes, err := loadTreeDir(dir)
+	if err != nil {
+		return err
+	}
+	domain := ctx.String(dnsDomainFlag.Name)
+	if domain == "" && info.URL != "" {
+		if domain, _, err = dnsdisc.ParseURL(info.URL); err != nil {
+			return fmt.Errorf("invalid tree URL in %s: %v", treeInfoFile, err)
+		}
+	}
+	if domain == "" {
+		return fmt.Errorf("missing domain name, use --%s", dnsDomainFlag.Name)
+	}
+	seq := info.Seq + 1
+	if ctx.IsSet(dnsSeqFlag.Name) {
+		seq = ctx.Uint(dnsSeqFlag.Name)
+	}
+	key, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		return fmt.Errorf("can't load key: %v", err)
+	}
+	t, err := dnsdisc.MakeTree(seq, nodes, info.Links)
+	if err != nil {
+		return err
+	}
+	url, err := t.Sign(key, domain)
+	if err != nil {
+		return fmt.Errorf("can't sign tree: %v", err)
+	}
+	info.URL, info.Seq, info.Signature = url, t.Seq(), t.Signature()
+	if err := writeJSON(filepath.Join(dir, treeInfoFile), info); err != nil {
+		return err
+	}
+	fmt.Println(url)
+	return nil
+}
+
+// dnsToTXT writes the TXT records of the signed tree in a tree directory.
+func dnsToTXT(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("need tree directory as argument")
+	}
+	dir, output := ctx.Args().Get(0), ctx.Args().Get(1)
+
+	info, nodes, err := loadTreeDir(dir)
+	if err != nil {
+		return err
+	}
+	if info.URL == "" || info.Signature == "" {
+		return fmt.Errorf("tree in %s is not signed", dir)
+	}
+	domain, pubkey, err := dnsdisc.ParseURL(info.URL)
+	if err != nil {
+		return fmt.Errorf("invalid tree URL in %s: %v", treeInfoFile, err)
+	}
+	t, err := dnsdisc.MakeTree(info.Seq, nodes, info.Links)
+	if err != nil {
+		return err
+	}
+	if err := t.SetSignature(pubkey, info.Signature); err != nil {
+		return fmt.Errorf("tree in %s changed since signing: %v", dir, err)
+	}
+	records := t.ToTXT(domain)
+	if output == "" {
+		enc, _ := json.MarshalIndent(records, "", "  ")
+		fmt.Println(string(enc))
+		return nil
+	}
+	return writeJSON(output, records)
+}
+
+// loadTreeDir reads the metadata and node records of a tree directory. A missing
+// metadata file yields empty metadata.
+func loadTreeDir(dir string) (*treeInfo, []*enode.Node, error) {
+	info := new(treeInfo)
+	if err := readJSON(filepath.Join(dir, treeInfoFile), info); err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	var records []string
+	if err := readJSON(filepath.Join(dir, treeNodesFile), &records); err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]*enode.Node, len(records))
+	for i, record := range records {
+		n, err := enode.Parse(enode.ValidSchemes, record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid node %d in %s: %v", i, treeNodesFile, err)
+		}
+		nodes[i] = n
+	}
+	return info, nodes, nil
+}
+
+// writeTreeDir writes the metadata and node records of a tree to a directory.
+func writeTreeDir(dir string, info *treeInfo, nodes []*enode.Node) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	records := make([]string, len(nodes))
+	for i, n := range nodes {
+		enc, err := rlp.EncodeToBytes(n.Record())
+		if err != nil {
+			return err
+		}
+		records[i] = "enr:" + base64.RawURLEncoding.EncodeToString(enc)
+	}
+	if err := writeJSON(filepath.Join(dir, treeNodesFile), records); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, treeInfoFile), info)
+}
+
+func readJSON(file string, v interface{}) error {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(blob, v); err != nil {
+		return fmt.Errorf("invalid JSON in %s: %v", file, err)
+	}
+	return nil
+}
+
+func writeJSON(file string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(blob, '\n'), 0644)
+}
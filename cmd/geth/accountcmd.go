@@ -22,10 +22,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts/pkcs11"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/console"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -188,6 +191,80 @@ this import mechanism is not needed when you transfer an account between
 nodes.
 `,
 			},
+			{
+				Name:     "hd",
+				Usage:    "Manage hierarchical deterministic wallets",
+				Category: "ACCOUNT COMMANDS",
+				Description: `
+
+Manage hierarchical deterministic (HD) wallets, create a new wallet from a random
+mnemonic, import an existing mnemonic or derive accounts from a wallet.
+
+The mnemonic of a wallet is saved in encrypted format under <DATADIR>/keystore/hd,
+the accounts are derived from it on demand according to BIP-32 and BIP-44.`,
+				Subcommands: []cli.Command{
+					{
+						Name:   "new",
+						Usage:  "Create a new HD wallet from a random mnemonic",
+						Action: utils.MigrateFlags(hdWalletCreate),
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+							utils.LightKDFFlag,
+						},
+						Description: `
+    geth account hd new
+
+Creates a new HD wallet and prints its mnemonic and the address of its first
+account (m/44'/60'/0'/0/0).
+
+The mnemonic is the only way to recover the wallet, write it down and keep it
+safe. It is saved in encrypted format, you are prompted for a passphrase.
+`,
+					},
+					{
+						Name:      "import",
+						Usage:     "Import a mnemonic into a new HD wallet",
+						Action:    utils.MigrateFlags(hdWalletImport),
+						ArgsUsage: "<mnemonicFile>",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+							utils.LightKDFFlag,
+						},
+						Description: `
+    geth account hd import <mnemonicfile>
+
+Imports the BIP-39 mnemonic from <mnemonicfile> into a new HD wallet and prints
+the address of its first account (m/44'/60'/0'/0/0).
+
+The mnemonic is saved in encrypted format, you are prompted for a passphrase.
+`,
+					},
+					{
+						Name:      "derive",
+						Usage:     "Derive and pin an account of an HD wallet",
+						Action:    utils.MigrateFlags(hdWalletDerive),
+						ArgsUsage: "<walletURL> <path>",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+						},
+						Description: `
+    geth account hd derive <walletURL> <path>
+
+Derives the account at the given derivation path from the HD wallet and pins it,
+listing it among the accounts from then on. Absolute paths start with m/, others
+are relative to the default root path m/44'/60'/0'/0.
+
+You are prompted for the passphrase of the wallet.
+`,
+					},
+				},
+			},
 		},
 	}
 )
@@ -233,6 +310,37 @@ func unlockAccount(ctx *cli.Context, ks *keystore.KeyStore, address string, i in
 	return accounts.Account{}, ""
 }
 
+// unlockTokenAccount tries unlocking an account held in a PKCS#11 token a few
+// times, by logging into the token with the PIN. It returns false if the account
+// is not held in any token.
+func unlockTokenAccount(am *accounts.Manager, address string, i int, passwords []string) bool {
+	if !common.IsHexAddress(address) {
+		return false
+	}
+	account := accounts.Account{Address: common.HexToAddress(address)}
+	wallet, err := am.Find(account)
+	if err != nil || wallet.URL().Scheme != pkcs11.Scheme {
+		return false
+	}
+	for trials := 0; trials < 3; trials++ {
+		prompt := fmt.Sprintf("Unlocking account %s | Attempt %d/%d", address, trials+1, 3)
+		pin := getPassPhrase(prompt, false, i, passwords)
+		err = wallet.Open(pin)
+		if err == nil || err == accounts.ErrWalletAlreadyOpen {
+			log.Info("Unlocked account", "address", account.Address.Hex(), "token", wallet.URL())
+			return true
+		}
+		if err != accounts.ErrInvalidPassphrase {
+			// No need to prompt again if the error is not PIN-related.
+			break
+		}
+	}
+	// All trials expended to unlock account, bail out
+	utils.Fatalf("Failed to unlock account %s (%v)", address, err)
+
+	return true
+}
+
 // getPassPhrase retrieves the password associated with an account, either fetched
 // from a list of preloaded passphrases, or requested interactively from the user.
 func getPassPhrase(prompt string, confirmation bool, i int, passwords []string) string {
@@ -377,3 +485,74 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// fetchHDKeyStore retrieves the HD wallet keystore from the node's account manager.
+func fetchHDKeyStore(stack *node.Node) *keystore.HDKeyStore {
+	return stack.AccountManager().Backends(keystore.HDKeyStoreType)[0].(*keystore.HDKeyStore)
+}
+
+// hdWalletCreate creates a new HD wallet from a random mnemonic.
+func hdWalletCreate(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	passphrase := getPassPhrase("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	wallet, mnemonic, err := fetchHDKeyStore(stack).NewWallet(passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the wallet: %v", err)
+	}
+	fmt.Printf("Wallet: %s\n", wallet.URL())
+	fmt.Printf("Mnemonic: %s\n", mnemonic)
+	fmt.Printf("Address: {%x}\n", wallet.Accounts()[0].Address)
+	fmt.Println("\nWrite down the mnemonic and keep it safe, it is the only way to recover the wallet!")
+	return nil
+}
+
+// hdWalletImport imports a mnemonic from a file into a new HD wallet.
+func hdWalletImport(ctx *cli.Context) error {
+	mnemonicfile := ctx.Args().First()
+	if len(mnemonicfile) == 0 {
+		utils.Fatalf("mnemonic file must be given as argument")
+	}
+	mnemonic, err := ioutil.ReadFile(mnemonicfile)
+	if err != nil {
+		utils.Fatalf("Could not read mnemonic file: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	passphrase := getPassPhrase("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	wallet, err := fetchHDKeyStore(stack).ImportMnemonic(string(mnemonic), passphrase)
+	if err != nil {
+		utils.Fatalf("Could not import the mnemonic: %v", err)
+	}
+	fmt.Printf("Wallet: %s\n", wallet.URL())
+	fmt.Printf("Address: {%x}\n", wallet.Accounts()[0].Address)
+	return nil
+}
+
+// hdWalletDerive derives an account from an HD wallet and pins it.
+func hdWalletDerive(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("Wallet URL and derivation path must be given as arguments")
+	}
+	path, err := accounts.ParseDerivationPath(ctx.Args().Get(1))
+	if err != nil {
+		utils.Fatalf("Invalid derivation path: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	wallet, err := stack.AccountManager().Wallet(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Could not find the wallet: %v", err)
+	}
+	passphrase := getPassPhrase("Unlocking wallet "+wallet.URL().String(), false, 0, utils.MakePasswordList(ctx))
+	if err := wallet.Open(passphrase); err != nil {
+		utils.Fatalf("Could not open the wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		utils.Fatalf("Could not derive the account: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", account.Address)
+	return nil
+}
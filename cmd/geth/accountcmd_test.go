@@ -18,6 +18,7 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -131,6 +132,47 @@ Fatal: could not decrypt key with given passphrase
 `)
 }
 
+func TestAccountHDImportDerive(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+
+	mnemonic := filepath.Join(datadir, "mnemonic.txt")
+	if err := ioutil.WriteFile(mnemonic, []byte(strings.Repeat("abandon ", 11)+"about\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "account", "hd", "import", "--datadir", datadir, "--lightkdf", mnemonic)
+	geth.Expect(`
+Your new wallet is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+`)
+	geth.ExpectRegexp(`Wallet: hdkeystore://.+\nAddress: \{9858effd232b4033e47d90003d41ec34ecaeda94\}\n`)
+	geth.ExpectExit()
+
+	files, err := ioutil.ReadDir(filepath.Join(datadir, "keystore", "hd"))
+	if len(files) != 1 {
+		t.Fatalf("expected one wallet file in HD keystore directory, found %d files (error: %v)", len(files), err)
+	}
+	url := "hdkeystore://" + filepath.Join(datadir, "keystore", "hd", files[0].Name())
+
+	geth = runGeth(t, "account", "hd", "derive", "--datadir", datadir, url, "1")
+	geth.Expect(`
+Unlocking wallet ` + url + `
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Address: {6fac4d18c912343bf86fa7049364dd4e424ab9c0}
+`)
+	geth.ExpectExit()
+
+	geth = runGeth(t, "account", "list", "--datadir", datadir)
+	geth.Expect(`
+Account #0: {9858effd232b4033e47d90003d41ec34ecaeda94} ` + url + `/m/44'/60'/0'/0/0
+Account #1: {6fac4d18c912343bf86fa7049364dd4e424ab9c0} ` + url + `/m/44'/60'/0'/0/1
+`)
+	geth.ExpectExit()
+}
+
 func TestUnlockFlag(t *testing.T) {
 	datadir := tmpDatadirWithKeystore(t)
 	geth := runGeth(t,
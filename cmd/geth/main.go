@@ -61,6 +61,8 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
+		utils.PKCS11ModuleFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
@@ -84,6 +86,8 @@ var (
 		utils.TxPoolLifetimeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.AddrIndexFlag,
+		utils.TxLookupLimitFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
@@ -113,6 +117,12 @@ var (
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
 		utils.NetrestrictFlag,
+		utils.DNSDiscoveryFlag,
+		utils.MaxUploadFlag,
+		utils.MaxDownloadFlag,
+		utils.MaxPeerUploadFlag,
+		utils.MaxPeerDownloadFlag,
+		utils.BandwidthSharesFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DeveloperFlag,
@@ -283,7 +293,9 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	unlocks := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
 	for i, account := range unlocks {
 		if trimmed := strings.TrimSpace(account); trimmed != "" {
-			unlockAccount(ctx, ks, trimmed, i, passwords)
+			if !unlockTokenAccount(stack.AccountManager(), trimmed, i, passwords) {
+				unlockAccount(ctx, ks, trimmed, i, passwords)
+			}
 		}
 	}
 	// Register wallet event handlers to open and auto-derive wallets
@@ -327,6 +339,15 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 			}
 		}
 	}()
+	// Give the les server (if any) access to the checkpoint oracle through the node
+	var ethereum *eth.Ethereum
+	if err := stack.Service(&ethereum); err == nil {
+		rpcClient, err := stack.Attach()
+		if err != nil {
+			utils.Fatalf("Failed to attach to self: %v", err)
+		}
+		ethereum.SetContractBackend(ethclient.NewClient(rpcClient))
+	}
 	// Start auxiliary services if enabled
 	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
 		// Mining only makes sense if a full Ethereum node is running
@@ -71,11 +71,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.ExternalSignerFlag,
+			utils.PKCS11ModuleFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.RinkebyFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.AddrIndexFlag,
+			utils.TxLookupLimitFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -177,6 +181,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
 			utils.NetrestrictFlag,
+			utils.DNSDiscoveryFlag,
+			utils.MaxUploadFlag,
+			utils.MaxDownloadFlag,
+			utils.MaxPeerUploadFlag,
+			utils.MaxPeerDownloadFlag,
+			utils.BandwidthSharesFlag,
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
 		},
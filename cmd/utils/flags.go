@@ -52,6 +52,7 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
@@ -127,6 +128,14 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer (IPC path or HTTP endpoint) to manage accounts with",
+	}
+	PKCS11ModuleFlag = cli.StringFlag{
+		Name:  "pkcs11",
+		Usage: "PKCS#11 module (shared library path) to use the HSM keys of",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -168,6 +177,15 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	AddrIndexFlag = cli.BoolFlag{
+		Name:  "addrindex",
+		Usage: "Maintain an address to transactions index (enables eth_getTransactionsByAddress)",
+	}
+	TxLookupLimitFlag = cli.Uint64Flag{
+		Name:  "txlookuplimit",
+		Usage: "Number of recent blocks to maintain transactions index for (default = all blocks)",
+		Value: 0,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -519,7 +537,7 @@ var (
 	}
 	NATFlag = cli.StringFlag{
 		Name:  "nat",
-		Usage: "NAT port mapping mechanism (any|none|upnp|pmp|extip:<IP>)",
+		Usage: "NAT port mapping mechanism (any|none|upnp|pmp|pcp|extip:<IP>)",
 		Value: "any",
 	}
 	NoDiscoverFlag = cli.BoolFlag{
@@ -534,6 +552,30 @@ var (
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "discovery.dns",
+		Usage: "Comma separated enrtree:// URLs of DNS discovery trees to find peers in",
+	}
+	MaxUploadFlag = cli.IntFlag{
+		Name:  "p2p.maxupload",
+		Usage: "Maximum upload bandwidth of all peers together in kB/s (unlimited if set to 0)",
+	}
+	MaxDownloadFlag = cli.IntFlag{
+		Name:  "p2p.maxdownload",
+		Usage: "Maximum download bandwidth of all peers together in kB/s (unlimited if set to 0)",
+	}
+	MaxPeerUploadFlag = cli.IntFlag{
+		Name:  "p2p.maxpeerupload",
+		Usage: "Maximum upload bandwidth of each peer in kB/s (unlimited if set to 0)",
+	}
+	MaxPeerDownloadFlag = cli.IntFlag{
+		Name:  "p2p.maxpeerdownload",
+		Usage: "Maximum download bandwidth of each peer in kB/s (unlimited if set to 0)",
+	}
+	BandwidthSharesFlag = cli.StringFlag{
+		Name:  "p2p.bandwidthshares",
+		Usage: "Comma separated protocol shares of the bandwidth limits in percent (e.g. eth=70,les=20,bzz=10)",
+	}
 
 	// ATM the url is left to the user and deployment to
 	JSpathFlag = cli.StringFlag{
@@ -955,6 +997,8 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NetRestrict = list
 	}
+	setDNSDiscovery(ctx, cfg)
+	setBandwidthLimits(ctx, cfg)
 
 	if ctx.GlobalBool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
@@ -965,6 +1009,51 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setBandwidthLimits sets the bandwidth limits of the p2p server from the
+// command line flags. Flag values are in kB/s, the config uses bytes per second.
+func setBandwidthLimits(ctx *cli.Context, cfg *p2p.Config) {
+	if ctx.GlobalIsSet(MaxUploadFlag.Name) {
+		cfg.Bandwidth.MaxUpload = ctx.GlobalInt(MaxUploadFlag.Name) * 1024
+	}
+	if ctx.GlobalIsSet(MaxDownloadFlag.Name) {
+		cfg.Bandwidth.MaxDownload = ctx.GlobalInt(MaxDownloadFlag.Name) * 1024
+	}
+	if ctx.GlobalIsSet(MaxPeerUploadFlag.Name) {
+		cfg.Bandwidth.MaxPeerUpload = ctx.GlobalInt(MaxPeerUploadFlag.Name) * 1024
+	}
+	if ctx.GlobalIsSet(MaxPeerDownloadFlag.Name) {
+		cfg.Bandwidth.MaxPeerDownload = ctx.GlobalInt(MaxPeerDownloadFlag.Name) * 1024
+	}
+	if shares := ctx.GlobalString(BandwidthSharesFlag.Name); shares != "" {
+		cfg.Bandwidth.Shares = make(map[string]int)
+		for _, entry := range strings.Split(shares, ",") {
+			kv := strings.SplitN(entry, "=", 2)
+			if len(kv) != 2 {
+				Fatalf("Option %q: invalid share %q, want <protocol>=<percent>", BandwidthSharesFlag.Name, entry)
+			}
+			share, err := strconv.Atoi(kv[1])
+			if err != nil {
+				Fatalf("Option %q: invalid share %q: %v", BandwidthSharesFlag.Name, entry, err)
+			}
+			cfg.Bandwidth.Shares[kv[0]] = share
+		}
+	}
+}
+
+// setDNSDiscovery sets the DNS discovery trees given on the command line.
+func setDNSDiscovery(ctx *cli.Context, cfg *p2p.Config) {
+	urls := ctx.GlobalString(DNSDiscoveryFlag.Name)
+	if urls == "" {
+		return
+	}
+	for _, url := range strings.Split(urls, ",") {
+		if _, _, err := dnsdisc.ParseURL(url); err != nil {
+			Fatalf("Option %q: invalid enrtree URL %q: %v", DNSDiscoveryFlag.Name, url, err)
+		}
+		cfg.DiscoveryDNS = append(cfg.DiscoveryDNS, url)
+	}
+}
+
 // SetNodeConfig applies node-related command line flags to the config.
 func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	SetP2PConfig(ctx, &cfg.P2P)
@@ -993,6 +1082,12 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
+	if ctx.GlobalIsSet(PKCS11ModuleFlag.Name) {
+		cfg.PKCS11Module = ctx.GlobalString(PKCS11ModuleFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -1157,6 +1252,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
 
+	if ctx.GlobalIsSet(AddrIndexFlag.Name) {
+		cfg.AddrIndex = ctx.GlobalBool(AddrIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
+		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
+	}
+
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
@@ -1396,6 +1498,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieNodeLimit: eth.DefaultConfig.TrieCache,
 		TrieTimeLimit: eth.DefaultConfig.TrieTimeout,
+		TxLookupLimit: ctx.GlobalUint64(TxLookupLimitFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -0,0 +1,129 @@
+pragma solidity ^0.5.10;
+
+/**
+ * CheckpointOracle is an on-chain registry of light client checkpoints. A new
+ * checkpoint is accepted once a threshold number of admins have signed it.
+ *
+ * Checkpoints are signed EIP-191 style (version 0x00, the intended validator
+ * being the oracle itself):
+ *
+ *   keccak256(0x19 || 0x00 || oracle || uint64(index) || hash)
+ *
+ * The signatures of a new checkpoint are submitted in a single transaction by
+ * an admin, ordered by the signer addresses so no admin is counted twice.
+ */
+contract CheckpointOracle {
+    // Latest registered checkpoint.
+    uint64 sectionIndex;
+    bytes32 hash;
+    uint height;
+
+    // Configuration.
+    uint sectionSize;
+    uint processConfirms;
+    uint threshold;
+
+    // Admins allowed to submit and sign checkpoints.
+    address[] adminList;
+    mapping(address => bool) admins;
+
+    // NewCheckpointVote is emitted for every valid signature of a registered
+    // checkpoint.
+    event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, uint8 v, bytes32 r, bytes32 s);
+
+    constructor(address[] memory _adminlist, uint _sectionSize, uint _processConfirms, uint _threshold) public {
+        for (uint i = 0; i < _adminlist.length; i++) {
+            // The zero address would accept invalid signatures.
+            require(_adminlist[i] != address(0));
+            admins[_adminlist[i]] = true;
+            adminList.push(_adminlist[i]);
+        }
+        sectionSize = _sectionSize;
+        processConfirms = _processConfirms;
+        threshold = _threshold;
+    }
+
+    /**
+     * GetLatestCheckpoint returns the index, hash and registration height of
+     * the latest checkpoint.
+     */
+    function GetLatestCheckpoint() public view returns (uint64, bytes32, uint) {
+        return (sectionIndex, hash, height);
+    }
+
+    /**
+     * GetAllAdmin returns the list of admins.
+     */
+    function GetAllAdmin() public view returns (address[] memory) {
+        return adminList;
+    }
+
+    /**
+     * SetCheckpoint registers a new checkpoint if it has enough valid
+     * signatures. It returns false if the checkpoint is not (yet) acceptable
+     * and reverts if the transaction or the signatures are invalid.
+     */
+    function SetCheckpoint(
+        uint _recentNumber,
+        bytes32 _recentHash,
+        bytes32 _hash,
+        uint64 _sectionIndex,
+        uint8[] memory v,
+        bytes32[] memory r,
+        bytes32[] memory s
+    )
+        public
+        returns (bool)
+    {
+        // Ensure the sender is an admin.
+        require(admins[msg.sender]);
+
+        // Ensure the transaction is not replayed on a different chain.
+        require(blockhash(_recentNumber) == _recentHash);
+
+        // The signature arrays must be of equal length.
+        require(v.length == r.length);
+        require(v.length == s.length);
+
+        // Filter out checkpoints of sections not yet processed.
+        if (block.number < (_sectionIndex + 1) * sectionSize + processConfirms) {
+            return false;
+        }
+        // Filter out checkpoints older than the latest one.
+        if (_sectionIndex < sectionIndex) {
+            return false;
+        }
+        // Filter out checkpoints already registered.
+        if (_sectionIndex == sectionIndex && (_sectionIndex != 0 || height != 0)) {
+            return false;
+        }
+        // Filter out empty checkpoints.
+        if (_hash == "") {
+            return false;
+        }
+
+        bytes32 signedHash = keccak256(abi.encodePacked(byte(0x19), byte(0), this, _sectionIndex, _hash));
+
+        // Verify the signatures one by one until the threshold is reached. The
+        // signer must be an admin, and larger than the previous one.
+        address lastSigner = address(0);
+        for (uint idx = 0; idx < v.length; idx++) {
+            address signer = ecrecover(signedHash, v[idx], r[idx], s[idx]);
+            require(admins[signer]);
+            require(uint256(signer) > uint256(lastSigner));
+            lastSigner = signer;
+
+            emit NewCheckpointVote(_sectionIndex, _hash, v[idx], r[idx], s[idx]);
+
+            // Register the checkpoint if enough signatures were verified.
+            if (idx + 1 >= threshold) {
+                hash = _hash;
+                height = block.number;
+                sectionIndex = _sectionIndex;
+                return true;
+            }
+        }
+        // Not enough valid signatures.
+        revert();
+    }
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package checkpointoracle is an on-chain light client checkpoint oracle.
+package checkpointoracle
+
+//go:generate abigen --sol contract/oracle.sol --pkg contract --out contract/oracle.go
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle/contract"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errInvalidSignature = errors.New("invalid checkpoint signature")
+
+// CheckpointOracle is a Go wrapper around an on-chain checkpoint oracle contract.
+type CheckpointOracle struct {
+	address  common.Address
+	contract *contract.CheckpointOracle
+}
+
+// NewCheckpointOracle binds checkpoint contract and returns a registrar instance.
+func NewCheckpointOracle(contractAddr common.Address, backend bind.ContractBackend) (*CheckpointOracle, error) {
+	c, err := contract.NewCheckpointOracle(contractAddr, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{address: contractAddr, contract: c}, nil
+}
+
+// ContractAddr returns the address of contract.
+func (oracle *CheckpointOracle) ContractAddr() common.Address {
+	return oracle.address
+}
+
+// Contract returns the underlying contract instance.
+func (oracle *CheckpointOracle) Contract() *contract.CheckpointOracle {
+	return oracle.contract
+}
+
+// SignatureHash returns the hash the admins of the given oracle sign to vote for
+// a checkpoint. It follows EIP-191 with version 0x00, the intended validator being
+// the oracle itself: keccak256(0x19 || 0x00 || oracle || index || hash).
+func SignatureHash(oracle common.Address, index uint64, hash common.Hash) common.Hash {
+	buf := make([]byte, 2+common.AddressLength+8+common.HashLength)
+	buf[0] = 0x19
+	copy(buf[2:], oracle.Bytes())
+	binary.BigEndian.PutUint64(buf[2+common.AddressLength:], index)
+	copy(buf[2+common.AddressLength+8:], hash.Bytes())
+	return crypto.Keccak256Hash(buf)
+}
+
+// LookupCheckpointVotes returns the signatures of the votes the checkpoint with
+// the given index and hash was registered with in the given block. The returned
+// signatures are in the [R || S || V] format, with V being 0 or 1.
+func (oracle *CheckpointOracle) LookupCheckpointVotes(ctx context.Context, index uint64, hash common.Hash, number uint64) ([][]byte, error) {
+	it, err := oracle.contract.FilterNewCheckpointVote(&bind.FilterOpts{Start: number, End: &number, Context: ctx}, []uint64{index})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var sigs [][]byte
+	for it.Next() {
+		if it.Event.Index != index || it.Event.CheckpointHash != hash {
+			continue
+		}
+		sig := make([]byte, 65)
+		copy(sig, it.Event.R[:])
+		copy(sig[32:], it.Event.S[:])
+		sig[64] = it.Event.V - 27
+		sigs = append(sigs, sig)
+	}
+	return sigs, it.Error()
+}
+
+// RegisterCheckpoint registers the checkpoint with the given index and hash in
+// the oracle. The signatures need to be in the [R || S || V] format and ordered
+// by the addresses of their signers. The recent block number and hash protect the
+// transaction from being replayed on a different chain.
+func (oracle *CheckpointOracle) RegisterCheckpoint(opts *bind.TransactOpts, index uint64, hash common.Hash, rnum *big.Int, rhash common.Hash, sigs [][]byte) (*types.Transaction, error) {
+	var (
+		v []uint8
+		r [][32]byte
+		s [][32]byte
+	)
+	for _, sig := range sigs {
+		if len(sig) != 65 {
+			return nil, errInvalidSignature
+		}
+		var rv, sv [32]byte
+		copy(rv[:], sig[:32])
+		copy(sv[:], sig[32:64])
+
+		v = append(v, sig[64]%27+27)
+		r = append(r, rv)
+		s = append(s, sv)
+	}
+	return oracle.contract.SetCheckpoint(opts, rnum, rhash, hash, index, v, r, s)
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle/contract"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	sectionSize = 16
+	confirms    = 4
+	threshold   = 2
+)
+
+// Account is a helper for sorting the test admins by address.
+type Account struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+type Accounts []Account
+
+func (a Accounts) Len() int           { return len(a) }
+func (a Accounts) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a Accounts) Less(i, j int) bool { return bytes.Compare(a[i].addr.Bytes(), a[j].addr.Bytes()) < 0 }
+
+// signCheckpoint signs the given checkpoint with each of the given accounts.
+func signCheckpoint(oracle common.Address, index uint64, hash common.Hash, accounts Accounts) [][]byte {
+	var sigs [][]byte
+	for _, account := range accounts {
+		sig, _ := crypto.Sign(SignatureHash(oracle, index, hash).Bytes(), account.key)
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
+
+func TestCheckpointRegister(t *testing.T) {
+	var accounts Accounts
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		accounts = append(accounts, Account{key: key, addr: crypto.PubkeyToAddress(key.PublicKey)})
+	}
+	sort.Sort(accounts)
+
+	alloc := core.GenesisAlloc{}
+	for _, account := range accounts {
+		alloc[account.addr] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	genesis := (&core.Genesis{Config: params.AllEthashProtocolChanges, GasLimit: 10000000, Alloc: alloc}).ToBlock(nil)
+	backend := backends.NewSimulatedBackend(alloc, 10000000)
+
+	// Deploy the oracle with all but the last account being admins
+	admins := []common.Address{accounts[1].addr, accounts[0].addr}
+	transactOpts := bind.NewKeyedTransactor(accounts[0].key)
+
+	addr, _, _, err := contract.DeployCheckpointOracle(transactOpts, backend, admins, big.NewInt(sectionSize), big.NewInt(confirms), big.NewInt(threshold))
+	if err != nil {
+		t.Fatalf("Failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(addr, backend)
+	if err != nil {
+		t.Fatalf("Failed to bind oracle: %v", err)
+	}
+	list, err := oracle.Contract().GetAllAdmin(nil)
+	if err != nil {
+		t.Fatalf("Failed to retrieve admins: %v", err)
+	}
+	if !reflect.DeepEqual(list, admins) {
+		t.Fatalf("Admin list mismatch: have %v, want %v", list, admins)
+	}
+	// checkLatest verifies the latest registered checkpoint of the oracle
+	checkLatest := func(index uint64, hash common.Hash, height uint64) {
+		t.Helper()
+
+		cindex, chash, cheight, err := oracle.Contract().GetLatestCheckpoint(nil)
+		if err != nil {
+			t.Fatalf("Failed to retrieve latest checkpoint: %v", err)
+		}
+		if cindex != index || chash != hash || cheight.Uint64() != height {
+			t.Fatalf("Latest checkpoint mismatch: have %d/%x/%d, want %d/%x/%d", cindex, chash, cheight, index, hash, height)
+		}
+	}
+	// register submits a checkpoint in a new block and returns whether the
+	// transaction went through
+	number := uint64(1)
+	register := func(index uint64, hash common.Hash, rhash common.Hash, sigs [][]byte) bool {
+		_, err := oracle.RegisterCheckpoint(transactOpts, index, hash, big.NewInt(0), rhash, sigs)
+		backend.Commit()
+		number++
+		return err == nil
+	}
+	var (
+		hash  = common.HexToHash("deadbeef")
+		admin = Accounts{accounts[0], accounts[1]}
+		sigs  = signCheckpoint(addr, 0, hash, admin)
+	)
+	// Checkpoints of sections not yet processed are ignored
+	if !register(0, hash, genesis.Hash(), sigs) {
+		t.Fatalf("Failed to submit future checkpoint")
+	}
+	checkLatest(0, common.Hash{}, 0)
+
+	for ; number < sectionSize+confirms; number++ {
+		backend.Commit()
+	}
+	// Invalid submissions are rejected
+	if register(0, hash, common.HexToHash("cafebabe"), sigs) {
+		t.Fatalf("Checkpoint with invalid recent hash accepted")
+	}
+	if register(0, hash, genesis.Hash(), sigs[:1]) {
+		t.Fatalf("Checkpoint with too few signatures accepted")
+	}
+	if register(0, hash, genesis.Hash(), [][]byte{sigs[1], sigs[0]}) {
+		t.Fatalf("Checkpoint with unordered signatures accepted")
+	}
+	if register(0, hash, genesis.Hash(), [][]byte{sigs[0], sigs[0]}) {
+		t.Fatalf("Checkpoint with duplicate signatures accepted")
+	}
+	if register(0, hash, genesis.Hash(), signCheckpoint(addr, 0, hash, Accounts{accounts[0], accounts[2]})) {
+		t.Fatalf("Checkpoint signed by non-admin accepted")
+	}
+	if register(0, hash, genesis.Hash(), signCheckpoint(addr, 1, hash, admin)) {
+		t.Fatalf("Checkpoint with signatures for different index accepted")
+	}
+	checkLatest(0, common.Hash{}, 0)
+
+	// Valid checkpoints are registered, along with their votes
+	if !register(0, hash, genesis.Hash(), sigs) {
+		t.Fatalf("Failed to register checkpoint")
+	}
+	height := number
+	checkLatest(0, hash, height)
+
+	votes, err := oracle.LookupCheckpointVotes(context.Background(), 0, hash, height)
+	if err != nil {
+		t.Fatalf("Failed to look up votes: %v", err)
+	}
+	if !reflect.DeepEqual(votes, sigs) {
+		t.Fatalf("Vote mismatch: have %x, want %x", votes, sigs)
+	}
+	// Registered checkpoints can't be overridden
+	if !register(0, common.HexToHash("cafebabe"), genesis.Hash(), signCheckpoint(addr, 0, common.HexToHash("cafebabe"), admin)) {
+		t.Fatalf("Failed to submit stale checkpoint")
+	}
+	checkLatest(0, hash, height)
+}
@@ -71,6 +71,7 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	TxLookupLimit uint64        // Number of recent blocks to maintain transaction lookup entries for (0 = all)
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -198,6 +199,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	// Take ownership of this particular state
 	go bc.update()
+
+	bc.wg.Add(1)
+	go bc.maintainTxIndex()
+
 	return bc, nil
 }
 
@@ -362,6 +367,15 @@ func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.processor = processor
 }
 
+// SetStateDatabase sets the state database used to open and process the state
+// of the chain. It must be backed by the chain database and should be set before
+// any blocks are imported.
+func (bc *BlockChain) SetStateDatabase(db state.Database) {
+	bc.procmu.Lock()
+	defer bc.procmu.Unlock()
+	bc.stateCache = db
+}
+
 // SetValidator sets the validator which is used to validate incoming blocks.
 func (bc *BlockChain) SetValidator(validator Validator) {
 	bc.procmu.Lock()
@@ -810,6 +824,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		start = time.Now()
 		bytes = 0
 		batch = bc.db.NewBatch()
+		tail  = rawdb.ReadTxIndexTail(bc.db)
 	)
 	for i, block := range blockChain {
 		receipts := receiptChain[i]
@@ -833,7 +848,9 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		// Write all the data out into the database
 		rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
 		rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
-		rawdb.WriteTxLookupEntries(batch, block)
+		if tail == nil || block.NumberU64() >= *tail {
+			rawdb.WriteTxLookupEntries(batch, block)
+		}
 
 		stats.processed++
 
@@ -1431,6 +1448,166 @@ func (bc *BlockChain) update() {
 	}
 }
 
+// maintainTxIndex is responsible for keeping the transaction lookup entries in
+// line with the configured limit: whenever a new head arrives, the entries of
+// blocks falling out of the limit are deleted, and if the limit was raised, the
+// entries of previously unindexed blocks are regenerated.
+func (bc *BlockChain) maintainTxIndex() {
+	defer bc.wg.Done()
+
+	// If the index was never limited and isn't limited now, there's nothing to do
+	if bc.cacheConfig.TxLookupLimit == 0 && rawdb.ReadTxIndexTail(bc.db) == nil {
+		return
+	}
+	// Listen to chain events and process the index in the background
+	headCh := make(chan ChainHeadEvent, 1)
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	var (
+		done    chan struct{} // Non-nil if background indexing is running
+		head    uint64        // Latest chain head number announced
+		indexed uint64        // Chain head number the last indexing was run for
+	)
+	index := func() {
+		done, indexed = make(chan struct{}), head
+		go bc.indexTransactions(head, done)
+	}
+	if block := bc.CurrentBlock(); block != nil {
+		head = block.NumberU64()
+	}
+	index()
+
+	for {
+		select {
+		case ev := <-headCh:
+			head = ev.Block.NumberU64()
+			if done == nil {
+				index()
+			}
+		case <-done:
+			done = nil
+			if head != indexed {
+				index()
+			}
+		case <-bc.quit:
+			if done != nil {
+				<-done
+			}
+			return
+		}
+	}
+}
+
+// indexTransactions moves the transaction index tail to the position required
+// by the configured limit given the current head, closing done when finished.
+func (bc *BlockChain) indexTransactions(head uint64, done chan struct{}) {
+	defer close(done)
+
+	var (
+		limit = bc.cacheConfig.TxLookupLimit
+		tail  = rawdb.ReadTxIndexTail(bc.db)
+		want  uint64
+	)
+	if limit != 0 && head+1 > limit {
+		want = head + 1 - limit
+	}
+	if tail == nil {
+		// First run with an index limit, all transactions are indexed yet
+		rawdb.WriteTxIndexTail(bc.db, 0)
+		tail = new(uint64)
+	}
+	switch {
+	case want > *tail:
+		bc.unindexTransactions(*tail, want)
+	case want < *tail:
+		bc.reindexTransactions(want, *tail)
+	}
+}
+
+// unindexTransactions removes the transaction lookup entries of the canonical
+// blocks in the range [from, to), progressively moving the index tail forward.
+func (bc *BlockChain) unindexTransactions(from, to uint64) {
+	var (
+		start = time.Now()
+		batch = bc.db.NewBatch()
+		txs   int
+	)
+	for number := from; number < to; number++ {
+		select {
+		case <-bc.quit:
+			to = number
+		default:
+		}
+		if number == to {
+			break
+		}
+		if body := rawdb.ReadBody(bc.db, rawdb.ReadCanonicalHash(bc.db, number), number); body != nil {
+			for _, tx := range body.Transactions {
+				rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+			}
+			txs += len(body.Transactions)
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			rawdb.WriteTxIndexTail(batch, number+1)
+			if err := batch.Write(); err != nil {
+				log.Error("Failed to unindex transactions", "err", err)
+				return
+			}
+			batch.Reset()
+		}
+	}
+	rawdb.WriteTxIndexTail(batch, to)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to unindex transactions", "err", err)
+		return
+	}
+	context := []interface{}{"blocks", to - from, "txs", txs, "tail", to, "elapsed", common.PrettyDuration(time.Since(start))}
+	if to-from > 1 {
+		log.Info("Unindexed old transactions", context...)
+	} else {
+		log.Debug("Unindexed old transactions", context...)
+	}
+}
+
+// reindexTransactions regenerates the transaction lookup entries of the canonical
+// blocks in the range [from, to), progressively moving the index tail backward.
+func (bc *BlockChain) reindexTransactions(from, to uint64) {
+	var (
+		start = time.Now()
+		batch = bc.db.NewBatch()
+		txs   int
+	)
+	for number := to; number > from; number-- {
+		select {
+		case <-bc.quit:
+			from = number
+		default:
+		}
+		if number == from {
+			break
+		}
+		if block := bc.GetBlockByNumber(number - 1); block != nil {
+			rawdb.WriteTxLookupEntries(batch, block)
+			txs += len(block.Transactions())
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			rawdb.WriteTxIndexTail(batch, number-1)
+			if err := batch.Write(); err != nil {
+				log.Error("Failed to reindex transactions", "err", err)
+				return
+			}
+			batch.Reset()
+		}
+	}
+	rawdb.WriteTxIndexTail(batch, from)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to reindex transactions", "err", err)
+		return
+	}
+	log.Info("Reindexed old transactions", "blocks", to-from, "txs", txs, "tail", from, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
 // BadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
 func (bc *BlockChain) BadBlocks() []*types.Block {
 	blocks := make([]*types.Block, 0, bc.badBlocks.Len())
@@ -1373,6 +1373,71 @@ func TestLargeReorgTrieGC(t *testing.T) {
 	}
 }
 
+// Tests that transaction lookup entries are only maintained for the configured
+// number of recent blocks, and that they are regenerated if the limit is raised.
+func TestTxLookupLimit(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.HomesteadSigner{}
+		engine  = ethash.NewFaker()
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 32, func(i int, block *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, key)
+		block.AddTx(tx)
+	})
+	// checkIndex waits for the index tail to reach the expected position and
+	// checks that exactly the transactions past it can be looked up
+	checkIndex := func(tail uint64) {
+		for i := 0; i < 500; i++ {
+			if have := rawdb.ReadTxIndexTail(db); have != nil && *have == tail {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if have := rawdb.ReadTxIndexTail(db); have == nil || *have != tail {
+			t.Fatalf("index tail mismatch: have %v, want %d", have, tail)
+		}
+		for _, block := range blocks {
+			for _, tx := range block.Transactions() {
+				indexed := block.NumberU64() >= tail
+				if txn, _, _, _ := rawdb.ReadTransaction(db, tx.Hash()); (txn != nil) != indexed {
+					t.Fatalf("block #%d: transaction index mismatch: have %v, want %v", block.NumberU64(), txn != nil, indexed)
+				}
+			}
+		}
+	}
+	// Import the chain with a limited index and ensure old entries are dropped
+	chain, err := NewBlockChain(db, &CacheConfig{TrieNodeLimit: 256, TrieTimeLimit: 5 * time.Minute, TxLookupLimit: 10}, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	checkIndex(23)
+	chain.Stop()
+
+	// Raise the limit and ensure the entries are regenerated
+	chain, err = NewBlockChain(db, &CacheConfig{TrieNodeLimit: 256, TrieTimeLimit: 5 * time.Minute, TxLookupLimit: 20}, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	checkIndex(13)
+	chain.Stop()
+
+	// Remove the limit and ensure all entries are regenerated
+	chain, err = NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	checkIndex(0)
+	chain.Stop()
+}
+
 // Benchmarks large blocks with value transfers to non-existing accounts
 func benchmarkLargeNumberOfValueToNonexisting(b *testing.B, numTxs, numBlocks int, recipientFn func(uint64) common.Address, dataFn func(uint64) []byte) {
 	var (
@@ -17,6 +17,8 @@
 package rawdb
 
 import (
+	"encoding/binary"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -62,6 +64,26 @@ func DeleteTxLookupEntry(db DatabaseDeleter, hash common.Hash) {
 	db.Delete(txLookupKey(hash))
 }
 
+// ReadTxIndexTail retrieves the number of the oldest block whose transaction
+// lookup entries are still maintained. A nil result means the index was never
+// limited, so all transactions are indexed.
+func ReadTxIndexTail(db DatabaseReader) *uint64 {
+	data, _ := db.Get(txIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteTxIndexTail stores the number of the oldest block whose transaction
+// lookup entries are still maintained.
+func WriteTxIndexTail(db DatabaseWriter, number uint64) {
+	if err := db.Put(txIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store transaction index tail", "err", err)
+	}
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db DatabaseReader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -105,3 +127,30 @@ func WriteBloomBits(db DatabaseWriter, bit uint, section uint64, head common.Has
 		log.Crit("Failed to store bloom bits", "err", err)
 	}
 }
+
+// ReadAddrTxEntries retrieves the positional metadata of all the transactions
+// touching the given address within a single address index section.
+func ReadAddrTxEntries(db DatabaseReader, addr common.Address, section uint64, head common.Hash) []TxLookupEntry {
+	data, _ := db.Get(addrTxKey(addr, section, head))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []TxLookupEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid address transaction lookup RLP", "address", addr, "section", section, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteAddrTxEntries stores the positional metadata of all the transactions
+// touching the given address within a single address index section.
+func WriteAddrTxEntries(db DatabaseWriter, addr common.Address, section uint64, head common.Hash, entries []TxLookupEntry) {
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Crit("Failed to encode address transaction lookups", "err", err)
+	}
+	if err := db.Put(addrTxKey(addr, section, head), data); err != nil {
+		log.Crit("Failed to store address transaction lookups", "err", err)
+	}
+}
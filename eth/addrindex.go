@@ -0,0 +1,131 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// addrIndexThrottling is the time to wait between processing two consecutive
+	// address index sections. It's useful during chain upgrades to prevent disk
+	// overload.
+	addrIndexThrottling = 100 * time.Millisecond
+)
+
+// AddrIndexer implements a core.ChainIndexer, building up an index from sender
+// and recipient addresses to the positional metadata of the transactions they
+// participated in.
+//
+// The index data of each section is keyed by the section head hash, so sections
+// rolled back by a reorg are simply regenerated under the new canonical head and
+// the stale data is never looked at again.
+type AddrIndexer struct {
+	size    uint64                                   // section size to generate the address index for
+	db      ethdb.Database                           // database instance to write index data and metadata into
+	config  *params.ChainConfig                      // chain configuration to derive transaction signers
+	entries map[common.Address][]rawdb.TxLookupEntry // address lookups accumulated in the current section
+	section uint64                                   // Section is the section number being processed currently
+	head    common.Hash                              // Head is the hash of the last header processed
+}
+
+// NewAddrIndexer returns a chain indexer that generates the address transaction
+// index for the canonical chain.
+func NewAddrIndexer(db ethdb.Database, config *params.ChainConfig, size, confirms uint64) *core.ChainIndexer {
+	backend := &AddrIndexer{
+		db:     db,
+		config: config,
+		size:   size,
+	}
+	table := ethdb.NewTable(db, string(rawdb.AddrTxIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, addrIndexThrottling, "addrindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new address index
+// section.
+func (b *AddrIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.entries, b.section, b.head = make(map[common.Address][]rawdb.TxLookupEntry), section, common.Hash{}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the participants of all
+// the transactions of a new block into the index.
+func (b *AddrIndexer) Process(ctx context.Context, header *types.Header) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+
+	body := rawdb.ReadBody(b.db, hash, number)
+	if body == nil {
+		return fmt.Errorf("block #%d [%x…] body not found", number, hash[:4])
+	}
+	signer := types.MakeSigner(b.config, header.Number)
+	for i, tx := range body.Transactions {
+		addrs, err := txParticipants(signer, tx)
+		if err != nil {
+			return err
+		}
+		entry := rawdb.TxLookupEntry{BlockHash: hash, BlockIndex: number, Index: uint64(i)}
+		for _, addr := range addrs {
+			b.entries[addr] = append(b.entries[addr], entry)
+		}
+	}
+	b.head = hash
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the address index
+// section and writing it out into the database.
+func (b *AddrIndexer) Commit() error {
+	batch := b.db.NewBatch()
+	for addr, entries := range b.entries {
+		rawdb.WriteAddrTxEntries(batch, addr, b.section, b.head, entries)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
+
+// txParticipants returns the deduplicated set of addresses a transaction touches
+// directly: the sender, and either the recipient or the created contract.
+func txParticipants(signer types.Signer, tx *types.Transaction) ([]common.Address, error) {
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction %x: %v", tx.Hash(), err)
+	}
+	to := crypto.CreateAddress(from, tx.Nonce())
+	if tx.To() != nil {
+		to = *tx.To()
+	}
+	if from == to {
+		return []common.Address{from}, nil
+	}
+	return []common.Address{from, to}, nil
+}
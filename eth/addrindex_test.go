@@ -0,0 +1,161 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// waitAddrIndexer blocks until the address indexer caught up with the canonical
+// chain up to the given number of sections.
+func waitAddrIndexer(t *testing.T, db ethdb.Database, indexer *core.ChainIndexer, size, sections uint64) {
+	for i := 0; i < 500; i++ {
+		stored, last, head := indexer.Sections()
+		if stored == sections && head == rawdb.ReadCanonicalHash(db, last) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("address indexer didn't reach %d sections", sections)
+}
+
+// collectAddrTxs retrieves all the transactions touching an address, following
+// the pagination cursors until the end.
+func collectAddrTxs(t *testing.T, api *PublicAddrIndexAPI, addr common.Address) []common.Hash {
+	var (
+		hashes []common.Hash
+		cursor *AddrTxCursor
+	)
+	for {
+		page, err := api.GetTransactionsByAddress(context.Background(), addr, rpc.EarliestBlockNumber, rpc.LatestBlockNumber, cursor)
+		if err != nil {
+			t.Fatalf("failed to retrieve address transactions: %v", err)
+		}
+		for _, tx := range page.Transactions {
+			hashes = append(hashes, tx.Hash)
+		}
+		if page.Next == nil {
+			return hashes
+		}
+		cursor = page.Next
+	}
+}
+
+// Tests that the address index tracks both senders and recipients across the
+// indexed sections and the unindexed chain tail, paginating and following reorgs.
+func TestAddrIndex(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		even    = common.Address{0x01}
+		odd     = common.Address{0x02}
+		signer  = types.HomesteadSigner{}
+		db      = ethdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	// Generate a chain with a number of transactions in each block, and a fork of
+	// it replacing everything past block 10 with transactions to a single account
+	generate := func(recipients func(int) common.Address) func(int, *core.BlockGen) {
+		return func(i int, block *core.BlockGen) {
+			for j := 0; j < 5; j++ {
+				tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(sender), recipients(i*5+j), big.NewInt(1), params.TxGas, nil, nil), signer, key)
+				block.AddTx(tx)
+			}
+		}
+	}
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 30, generate(func(n int) common.Address {
+		if n%2 == 0 {
+			return even
+		}
+		return odd
+	}))
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	indexer := NewAddrIndexer(db, gspec.Config, 4, 0)
+	defer indexer.Close()
+	indexer.Start(chain)
+
+	waitAddrIndexer(t, db, indexer, 4, 7)
+
+	api := &PublicAddrIndexAPI{e: &Ethereum{chainDb: db, chainConfig: gspec.Config, blockchain: chain, addrIndexer: indexer}, size: 4}
+
+	// Ensure the sender (spanning multiple pages) and recipients are all found
+	var all, evens, odds []common.Hash
+	for i, block := range blocks {
+		for j, tx := range block.Transactions() {
+			all = append(all, tx.Hash())
+			if (i*5+j)%2 == 0 {
+				evens = append(evens, tx.Hash())
+			} else {
+				odds = append(odds, tx.Hash())
+			}
+		}
+	}
+	checkAddrTxs(t, "sender", collectAddrTxs(t, api, sender), all)
+	checkAddrTxs(t, "even", collectAddrTxs(t, api, even), evens)
+	checkAddrTxs(t, "odd", collectAddrTxs(t, api, odd), odds)
+
+	// Reorg the chain and ensure the index follows the new canonical chain
+	fork, _ := core.GenerateChain(gspec.Config, blocks[9], ethash.NewFaker(), db, 25, generate(func(int) common.Address { return odd }))
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	waitAddrIndexer(t, db, indexer, 4, 8)
+
+	evens, odds = nil, nil
+	for i, block := range append(blocks[:10], fork...) {
+		for j, tx := range block.Transactions() {
+			if i < 10 && (i*5+j)%2 == 0 {
+				evens = append(evens, tx.Hash())
+			} else {
+				odds = append(odds, tx.Hash())
+			}
+		}
+	}
+	checkAddrTxs(t, "even after reorg", collectAddrTxs(t, api, even), evens)
+	checkAddrTxs(t, "odd after reorg", collectAddrTxs(t, api, odd), odds)
+}
+
+func checkAddrTxs(t *testing.T, name string, have, want []common.Hash) {
+	if len(have) != len(want) {
+		t.Fatalf("%s: transaction count mismatch: have %d, want %d", name, len(have), len(want))
+	}
+	for i := range have {
+		if have[i] != want[i] {
+			t.Fatalf("%s: transaction %d mismatch: have %x, want %x", name, i, have[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// addrTxPageSize is the maximum number of transactions returned in a single
+	// page of an address transaction query.
+	addrTxPageSize = 100
+)
+
+// errAddrIndexSyncing is returned if an address query would need to scan more
+// than a couple of unindexed sections because the address index hasn't caught
+// up with the chain yet.
+var errAddrIndexSyncing = errors.New("address index is still being generated")
+
+// AddrTxCursor is the position of the next transaction to return from an
+// address transaction query, used to paginate over long results.
+type AddrTxCursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Index       hexutil.Uint64 `json:"transactionIndex"`
+}
+
+// AddrTxPage is a single page of transactions touching an address. If Next is
+// set, further results are available by repeating the query with it.
+type AddrTxPage struct {
+	Transactions []*ethapi.RPCTransaction `json:"transactions"`
+	Next         *AddrTxCursor            `json:"next"`
+}
+
+// PublicAddrIndexAPI provides access to the address transaction index of a full
+// node, if it was enabled.
+type PublicAddrIndexAPI struct {
+	e    *Ethereum
+	size uint64 // Section size of the address indexer
+}
+
+// NewPublicAddrIndexAPI creates a new API to query the address transaction index.
+func NewPublicAddrIndexAPI(e *Ethereum) *PublicAddrIndexAPI {
+	return &PublicAddrIndexAPI{e, params.BloomBitsBlocks}
+}
+
+// addrTxQuery is the state of a single paginated address transaction query.
+type addrTxQuery struct {
+	from, to uint64
+	cursor   *AddrTxCursor
+	page     *AddrTxPage
+}
+
+// add appends a transaction to the result page if it's within the queried range
+// and past the cursor. It returns false once the page is full, having set the
+// cursor for the next one.
+func (q *addrTxQuery) add(tx *types.Transaction, blockHash common.Hash, number, index uint64) bool {
+	if number < q.from || number > q.to {
+		return true
+	}
+	if q.cursor != nil {
+		if number < uint64(q.cursor.BlockNumber) || (number == uint64(q.cursor.BlockNumber) && index < uint64(q.cursor.Index)) {
+			return true
+		}
+	}
+	if len(q.page.Transactions) == addrTxPageSize {
+		q.page.Next = &AddrTxCursor{BlockNumber: hexutil.Uint64(number), Index: hexutil.Uint64(index)}
+		return false
+	}
+	q.page.Transactions = append(q.page.Transactions, ethapi.RPCMarshalTransaction(tx, blockHash, number, index))
+	return true
+}
+
+// GetTransactionsByAddress returns the transactions sent from or to the given
+// address (including contract creations) within the given block range, in
+// chain order. At most addrTxPageSize transactions are returned at once, the
+// returned cursor can be passed to subsequent calls to retrieve the rest.
+func (api *PublicAddrIndexAPI) GetTransactionsByAddress(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, cursor *AddrTxCursor) (*AddrTxPage, error) {
+	head := api.e.blockchain.CurrentBlock().NumberU64()
+
+	q := &addrTxQuery{
+		from:   resolveBlockNumber(fromBlock, head),
+		to:     resolveBlockNumber(toBlock, head),
+		cursor: cursor,
+		page:   &AddrTxPage{Transactions: []*ethapi.RPCTransaction{}},
+	}
+	if q.to > head {
+		q.to = head
+	}
+	if q.from > q.to {
+		return q.page, nil
+	}
+	// Gather all the matches from the already indexed sections
+	db, size := api.e.chainDb, api.size
+
+	sections, _, _ := api.e.addrIndexer.Sections()
+	for section := q.from / size; section < sections && section*size <= q.to; section++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		shead := rawdb.ReadCanonicalHash(db, (section+1)*size-1)
+		for _, entry := range rawdb.ReadAddrTxEntries(db, addr, section, shead) {
+			body := rawdb.ReadBody(db, entry.BlockHash, entry.BlockIndex)
+			if body == nil || uint64(len(body.Transactions)) <= entry.Index {
+				return nil, fmt.Errorf("indexed transaction #%d of block #%d missing", entry.Index, entry.BlockIndex)
+			}
+			if !q.add(body.Transactions[entry.Index], entry.BlockHash, entry.BlockIndex, entry.Index) {
+				return q.page, nil
+			}
+		}
+	}
+	// Scan through the blocks not yet covered by the index
+	start := sections * size
+	if start < q.from {
+		start = q.from
+	}
+	if start <= q.to && q.to-start >= 2*size+params.BloomConfirms {
+		return nil, errAddrIndexSyncing
+	}
+	for number := start; number <= q.to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.e.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		signer := types.MakeSigner(api.e.chainConfig, block.Number())
+		for i, tx := range block.Transactions() {
+			addrs, err := txParticipants(signer, tx)
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range addrs {
+				if a != addr {
+					continue
+				}
+				if !q.add(tx, block.Hash(), number, uint64(i)) {
+					return q.page, nil
+				}
+				break
+			}
+		}
+	}
+	return q.page, nil
+}
+
+// resolveBlockNumber converts an RPC block number into an absolute one, mapping
+// the special latest and pending tags to the current head.
+func resolveBlockNumber(number rpc.BlockNumber, head uint64) uint64 {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return head
+	}
+	return uint64(number.Int64())
+}
@@ -86,10 +86,20 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Indexing options
+	AddrIndex     bool   `toml:",omitempty"` // Whether to maintain an address to transactions index
+	TxLookupLimit uint64 `toml:",omitempty"` // Number of recent blocks to maintain transaction lookup entries for (0 = all)
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// Ultra-light client options
+	ULC *ULCConfig `toml:",omitempty"`
+
+	// Checkpoint oracle options
+	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
@@ -131,3 +141,11 @@ type Config struct {
 type configMarshaling struct {
 	MinerExtraData hexutil.Bytes
 }
+
+// ULCConfig contains the configuration of the ultra-light client mode, in which
+// new chain heads are accepted without downloading and verifying the header chain
+// if enough trusted servers announce them with a valid signature.
+type ULCConfig struct {
+	TrustedServers     []string `toml:",omitempty"` // Enode URLs of the trusted LES servers
+	MinTrustedFraction int      `toml:",omitempty"` // Minimum percentage of trusted servers to agree on a new head
+}
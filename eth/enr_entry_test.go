@@ -0,0 +1,54 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+// Tests that the dial filter rejects nodes announcing an incompatible fork ID
+// and accepts nodes which don't announce one.
+func TestDialFilter(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	filter := newDialFilter(pm.forkFilter)
+	tests := []struct {
+		entry enr.Entry
+		want  bool
+	}{
+		{nil, true},
+		{&ethEntry{ForkID: forkid.NewID(pm.blockchain)}, true},
+		{&ethEntry{ForkID: forkid.ID{Hash: [4]byte{0x00, 0x01, 0x02, 0x03}}}, false},
+		{enr.WithEntry("eth", "invalid"), false},
+	}
+	for i, test := range tests {
+		var r enr.Record
+		if test.entry != nil {
+			r.Set(test.entry)
+		}
+		n := enode.SignNull(&r, enode.ID{byte(i)})
+		if have := filter(n); have != test.want {
+			t.Errorf("test %d: filter returned %t, want %t", i, have, test.want)
+		}
+	}
+}
@@ -40,4 +40,10 @@ var (
 	headerFilterOutMeter = metrics.NewRegisteredMeter("eth/fetcher/filter/headers/out", nil)
 	bodyFilterInMeter    = metrics.NewRegisteredMeter("eth/fetcher/filter/bodies/in", nil)
 	bodyFilterOutMeter   = metrics.NewRegisteredMeter("eth/fetcher/filter/bodies/out", nil)
+
+	txAnnounceInMeter   = metrics.NewRegisteredMeter("eth/fetcher/tx/announces/in", nil)
+	txAnnounceDOSMeter  = metrics.NewRegisteredMeter("eth/fetcher/tx/announces/dos", nil)
+	txDeliveryInMeter   = metrics.NewRegisteredMeter("eth/fetcher/tx/deliveries/in", nil)
+	txFetchMeter        = metrics.NewRegisteredMeter("eth/fetcher/tx/fetch", nil)
+	txFetchTimeoutMeter = metrics.NewRegisteredMeter("eth/fetcher/tx/fetch/timeout", nil)
 )
@@ -0,0 +1,310 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	maxTxAnnounces  = 4096            // Maximum number of unique transactions a peer may have announced
+	maxTxRetrievals = 256             // Maximum number of transactions to retrieve in a single request
+	txFetchTimeout  = 5 * time.Second // Maximum allotted time to return an explicitly requested transaction
+)
+
+// txHasFn is a callback type to check whether a transaction is already known.
+type txHasFn func(common.Hash) bool
+
+// txRequesterFn is a callback type for sending a transaction retrieval request.
+type txRequesterFn func(peer string, hashes []common.Hash) error
+
+// txAnnounce is the hash notification of the availability of a batch of new
+// transactions at a peer.
+type txAnnounce struct {
+	origin string        // Identifier of the peer originating the notification
+	hashes []common.Hash // Hashes of the transactions being announced
+}
+
+// txDelivery is a batch of transactions received from a peer.
+type txDelivery struct {
+	origin string        // Identifier of the peer delivering the transactions
+	hashes []common.Hash // Hashes of the delivered transactions
+	direct bool          // Whether this is a reply to a retrieval request
+}
+
+// txRequest is an in-flight transaction retrieval request.
+type txRequest struct {
+	hashes []common.Hash  // Transactions requested from the peer
+	time   mclock.AbsTime // Timestamp of the request
+}
+
+// TxFetcher is responsible for retrieving transactions announced by hash. Each
+// announced transaction is requested from one of the peers that announced it.
+// If the peer doesn't deliver in time or replies without the transaction, the
+// retrieval is rotated to the next peer announcing it.
+type TxFetcher struct {
+	notify  chan *txAnnounce
+	deliver chan *txDelivery
+	drop    chan string
+	quit    chan struct{}
+
+	// Announce states, only accessed by the loop
+	announces map[string]map[common.Hash]struct{} // Transactions announced by each peer, not yet retrieved
+	announced map[common.Hash]map[string]struct{} // Peers announcing each transaction
+	fetching  map[common.Hash]string              // Transactions currently being retrieved, and from whom
+	requests  map[string]*txRequest               // In-flight retrieval request of each peer
+
+	// Callbacks
+	hasTx    txHasFn       // Checks whether a transaction is already in the pool
+	fetchTxs txRequesterFn // Sends a retrieval request to a peer
+
+	clock mclock.Clock
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(hasTx txHasFn, fetchTxs txRequesterFn) *TxFetcher {
+	return newTxFetcher(hasTx, fetchTxs, mclock.System{})
+}
+
+func newTxFetcher(hasTx txHasFn, fetchTxs txRequesterFn, clock mclock.Clock) *TxFetcher {
+	return &TxFetcher{
+		notify:    make(chan *txAnnounce),
+		deliver:   make(chan *txDelivery),
+		drop:      make(chan string),
+		quit:      make(chan struct{}),
+		announces: make(map[string]map[common.Hash]struct{}),
+		announced: make(map[common.Hash]map[string]struct{}),
+		fetching:  make(map[common.Hash]string),
+		requests:  make(map[string]*txRequest),
+		hasTx:     hasTx,
+		fetchTxs:  fetchTxs,
+		clock:     clock,
+	}
+}
+
+// Start boots up the transaction fetcher.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the transaction fetcher, canceling all pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of
+// transactions at a peer.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
+	select {
+	case f.notify <- &txAnnounce{origin: peer, hashes: hashes}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue informs the fetcher about transactions received from a peer, either
+// as a reply to a retrieval request (direct) or through a broadcast. The caller
+// is responsible for adding the transactions to the pool.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	select {
+	case f.deliver <- &txDelivery{origin: peer, hashes: hashes, direct: direct}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all announcements and retrievals of a disconnected peer.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// loop is the main fetcher loop, processing announcements, deliveries and
+// timeouts, and scheduling retrievals.
+func (f *TxFetcher) loop() {
+	var timeout <-chan time.Time // Fires when the oldest in-flight request expires
+	for {
+		select {
+		case ann := <-f.notify:
+			txAnnounceInMeter.Mark(int64(len(ann.hashes)))
+			count := len(f.announces[ann.origin])
+			for _, hash := range ann.hashes {
+				if _, ok := f.announced[hash][ann.origin]; ok {
+					continue
+				}
+				if count >= maxTxAnnounces {
+					txAnnounceDOSMeter.Mark(1)
+					log.Debug("Peer exceeded outstanding transaction announces", "peer", ann.origin, "limit", maxTxAnnounces)
+					break
+				}
+				if f.hasTx(hash) {
+					continue
+				}
+				f.add(ann.origin, hash)
+				count++
+			}
+
+		case delivery := <-f.deliver:
+			txDeliveryInMeter.Mark(int64(len(delivery.hashes)))
+			for _, hash := range delivery.hashes {
+				f.complete(hash)
+			}
+			// Transactions requested but not delivered are not available at the
+			// peer, retrieve them from another peer instead.
+			if req := f.requests[delivery.origin]; req != nil && delivery.direct {
+				f.expire(delivery.origin, req)
+			}
+
+		case peer := <-f.drop:
+			if req := f.requests[peer]; req != nil {
+				f.expire(peer, req)
+			}
+			for hash := range f.announces[peer] {
+				f.forget(peer, hash)
+			}
+
+		case <-timeout:
+			timeout = nil
+			now := f.clock.Now()
+			for peer, req := range f.requests {
+				if time.Duration(now-req.time) >= txFetchTimeout {
+					txFetchTimeoutMeter.Mark(int64(len(req.hashes)))
+					log.Trace("Transaction retrieval timed out", "peer", peer, "count", len(req.hashes))
+					f.expire(peer, req)
+				}
+			}
+
+		case <-f.quit:
+			return
+		}
+		f.schedule()
+
+		// Arm the timeout for the oldest in-flight request.
+		if timeout == nil && len(f.requests) > 0 {
+			now := f.clock.Now()
+			oldest := now
+			for _, req := range f.requests {
+				if req.time < oldest {
+					oldest = req.time
+				}
+			}
+			timeout = f.clock.After(txFetchTimeout - time.Duration(now-oldest))
+		}
+	}
+}
+
+// schedule sends retrieval requests to all idle peers with announced but not
+// yet retrieved transactions.
+func (f *TxFetcher) schedule() {
+	for peer, hashes := range f.announces {
+		if f.requests[peer] != nil {
+			continue
+		}
+		var request []common.Hash
+		for hash := range hashes {
+			if _, ok := f.fetching[hash]; ok {
+				continue
+			}
+			if f.hasTx(hash) {
+				f.complete(hash)
+				continue
+			}
+			request = append(request, hash)
+			if len(request) == maxTxRetrievals {
+				break
+			}
+		}
+		if len(request) == 0 {
+			continue
+		}
+		for _, hash := range request {
+			f.fetching[hash] = peer
+		}
+		f.requests[peer] = &txRequest{hashes: request, time: f.clock.Now()}
+		txFetchMeter.Mark(int64(len(request)))
+
+		go func(peer string, hashes []common.Hash) {
+			if err := f.fetchTxs(peer, hashes); err != nil {
+				log.Debug("Failed to request transactions", "peer", peer, "err", err)
+			}
+		}(peer, request)
+	}
+}
+
+// add records the announcement of a transaction by a peer.
+func (f *TxFetcher) add(peer string, hash common.Hash) {
+	if f.announces[peer] == nil {
+		f.announces[peer] = make(map[common.Hash]struct{})
+	}
+	f.announces[peer][hash] = struct{}{}
+	if f.announced[hash] == nil {
+		f.announced[hash] = make(map[string]struct{})
+	}
+	f.announced[hash][peer] = struct{}{}
+}
+
+// forget removes the announcement of a transaction by a peer. The transaction
+// is dropped altogether if no other peer announced it.
+func (f *TxFetcher) forget(peer string, hash common.Hash) {
+	delete(f.announces[peer], hash)
+	if len(f.announces[peer]) == 0 {
+		delete(f.announces, peer)
+	}
+	delete(f.announced[hash], peer)
+	if len(f.announced[hash]) == 0 {
+		delete(f.announced, hash)
+	}
+	if f.fetching[hash] == peer {
+		delete(f.fetching, hash)
+	}
+}
+
+// complete removes all traces of a transaction which doesn't need to be
+// retrieved anymore.
+func (f *TxFetcher) complete(hash common.Hash) {
+	for peer := range f.announced[hash] {
+		f.forget(peer, hash)
+	}
+	delete(f.fetching, hash)
+}
+
+// expire ends an in-flight request. The peer is no longer considered a source
+// of the requested transactions which weren't delivered yet.
+func (f *TxFetcher) expire(peer string, req *txRequest) {
+	delete(f.requests, peer)
+	for _, hash := range req.hashes {
+		if f.fetching[hash] == peer {
+			f.forget(peer, hash)
+		}
+	}
+}
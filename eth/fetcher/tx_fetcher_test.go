@@ -0,0 +1,173 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txFetcherTester is a test simulator for the transaction fetcher, recording
+// the retrieval requests it sends.
+type txFetcherTester struct {
+	fetcher  *TxFetcher
+	clock    *mclock.Simulated
+	requests chan txFetchRequest
+}
+
+type txFetchRequest struct {
+	peer   string
+	hashes []common.Hash
+}
+
+func newTxFetcherTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		clock:    new(mclock.Simulated),
+		requests: make(chan txFetchRequest, 10),
+	}
+	hasTx := func(common.Hash) bool { return false }
+	fetchTxs := func(peer string, hashes []common.Hash) error {
+		tester.requests <- txFetchRequest{peer, hashes}
+		return nil
+	}
+	tester.fetcher = newTxFetcher(hasTx, fetchTxs, tester.clock)
+	tester.fetcher.Start()
+	return tester
+}
+
+// expectRequest waits for the next retrieval request and checks its contents.
+func (tt *txFetcherTester) expectRequest(t *testing.T, peer string, hashes ...common.Hash) {
+	t.Helper()
+	select {
+	case req := <-tt.requests:
+		sortHashes(req.hashes)
+		sortHashes(hashes)
+		if req.peer != peer || !reflect.DeepEqual(req.hashes, hashes) {
+			t.Fatalf("wrong request: have %s %x, want %s %x", req.peer, req.hashes, peer, hashes)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no request to %s", peer)
+	}
+}
+
+// expectNoRequest checks that no retrieval request is sent.
+func (tt *txFetcherTester) expectNoRequest(t *testing.T) {
+	t.Helper()
+	select {
+	case req := <-tt.requests:
+		t.Fatalf("unexpected request: %s %x", req.peer, req.hashes)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func sortHashes(hashes []common.Hash) {
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+}
+
+func newTestTxs(n int) []*types.Transaction {
+	txs := make([]*types.Transaction, n)
+	for i := range txs {
+		txs[i] = types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+	}
+	return txs
+}
+
+// Tests that announced transactions are retrieved, and that undelivered ones
+// are retrieved from the next announcing peer.
+func TestTxFetcherRotation(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+	txs := newTestTxs(3)
+
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash(), txs[1].Hash(), txs[2].Hash()})
+	tester.expectRequest(t, "A", txs[0].Hash(), txs[1].Hash(), txs[2].Hash())
+
+	// Transactions already being retrieved aren't requested again.
+	tester.fetcher.Notify("B", []common.Hash{txs[0].Hash(), txs[1].Hash()})
+	tester.expectNoRequest(t)
+
+	// A only delivers the first one, the second one is retrieved from B. The
+	// third one is forgotten as no one else announced it.
+	tester.fetcher.Enqueue("A", txs[:1], true)
+	tester.expectRequest(t, "B", txs[1].Hash())
+
+	tester.fetcher.Enqueue("B", txs[1:2], true)
+	tester.expectNoRequest(t)
+}
+
+// Tests that retrievals time out and rotate to the next announcing peer.
+func TestTxFetcherTimeout(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+	txs := newTestTxs(1)
+
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash()})
+	tester.expectRequest(t, "A", txs[0].Hash())
+	tester.fetcher.Notify("B", []common.Hash{txs[0].Hash()})
+
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+	tester.expectRequest(t, "B", txs[0].Hash())
+
+	// A late reply of A completes the retrieval.
+	tester.fetcher.Enqueue("A", txs, true)
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+	tester.expectNoRequest(t)
+}
+
+// Tests that dropped peers don't block retrievals.
+func TestTxFetcherDrop(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+	txs := newTestTxs(1)
+
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash()})
+	tester.expectRequest(t, "A", txs[0].Hash())
+	tester.fetcher.Notify("B", []common.Hash{txs[0].Hash()})
+
+	tester.fetcher.Drop("A")
+	tester.expectRequest(t, "B", txs[0].Hash())
+}
+
+// Tests that transactions broadcast in full are not retrieved anymore.
+func TestTxFetcherBroadcast(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+	txs := newTestTxs(2)
+
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash()})
+	tester.expectRequest(t, "A", txs[0].Hash())
+	tester.fetcher.Notify("A", []common.Hash{txs[1].Hash()})
+	tester.fetcher.Notify("B", []common.Hash{txs[0].Hash(), txs[1].Hash()})
+	tester.expectRequest(t, "B", txs[1].Hash())
+
+	// Both transactions arrive through a broadcast of C. The requests of A and
+	// B are still in flight, but nothing is rotated when they time out.
+	tester.fetcher.Enqueue("C", txs, false)
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+	tester.expectNoRequest(t)
+}
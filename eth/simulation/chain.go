@@ -0,0 +1,233 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ethashBlockTime is the timestamp difference between consecutive ethash
+// blocks. At this interval the difficulty stays constant, so the total
+// difficulty of a chain only depends on its length.
+const ethashBlockTime = 10
+
+// Mine makes a node produce n blocks on top of its current head, including the
+// transactions of its pool. The blocks are imported and broadcast to the node's
+// peers as if they were mined. Block contents and timestamps don't depend on the
+// wall clock, so the same sequence of calls produces the same chains.
+func (s *Simulation) Mine(i, n int) ([]*types.Block, error) {
+	backend := s.Eth(i)
+	if backend == nil {
+		return nil, fmt.Errorf("node %d not running", i)
+	}
+	blocks := make([]*types.Block, 0, n)
+	for len(blocks) < n {
+		block, err := s.mineBlock(i, backend)
+		if err != nil {
+			return blocks, err
+		}
+		if _, err := backend.BlockChain().InsertChain(types.Blocks{block}); err != nil {
+			return blocks, err
+		}
+		backend.EventMux().Post(core.NewMinedBlockEvent{Block: block})
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// mineBlock assembles and seals a block on top of the node's current head.
+func (s *Simulation) mineBlock(i int, backend *eth.Ethereum) (*types.Block, error) {
+	var (
+		chain    = backend.BlockChain()
+		engine   = backend.Engine()
+		config   = chain.Config()
+		parent   = chain.CurrentBlock()
+		coinbase = s.Address(i)
+	)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent, parent.GasLimit(), parent.GasLimit()),
+		Time:       new(big.Int).Add(parent.Time(), big.NewInt(ethashBlockTime)),
+		Coinbase:   coinbase,
+	}
+	if config.Clique != nil {
+		header.Time = new(big.Int).Add(parent.Time(), new(big.Int).SetUint64(config.Clique.Period))
+	}
+	timestamp := header.Time
+	if err := engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	// Clique moves the timestamp up to the current time, undo that to keep
+	// blocks independent of the wall clock.
+	header.Time = timestamp
+
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	pending, err := backend.TxPool().Pending()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		signer   = types.MakeSigner(config, header.Number)
+		txs      = types.NewTransactionsByPriceAndNonce(signer, pending)
+		gasPool  = new(core.GasPool).AddGas(header.GasLimit)
+		included []*types.Transaction
+		receipts []*types.Receipt
+	)
+	for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+		if gasPool.Gas() < params.TxGas {
+			break
+		}
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(included))
+		snap := statedb.Snapshot()
+		receipt, _, err := core.ApplyTransaction(config, chain, &coinbase, gasPool, statedb, header, tx, &header.GasUsed, vm.Config{})
+		switch err {
+		case nil:
+			included = append(included, tx)
+			receipts = append(receipts, receipt)
+			txs.Shift()
+		case core.ErrNonceTooLow:
+			// The transaction was included by an earlier block.
+			statedb.RevertToSnapshot(snap)
+			txs.Shift()
+		default:
+			// Skip the remaining transactions of the sender.
+			statedb.RevertToSnapshot(snap)
+			txs.Pop()
+		}
+	}
+	block, err := engine.Finalize(chain, header, statedb, included, nil, receipts)
+	if err != nil {
+		return nil, err
+	}
+	return s.seal(i, backend, block)
+}
+
+// seal seals a block. Clique blocks are signed directly, which avoids the delays
+// the clique sealer imposes on out-of-turn signers.
+func (s *Simulation) seal(i int, backend *eth.Ethereum, block *types.Block) (*types.Block, error) {
+	engine := backend.Engine()
+	if _, ok := engine.(*clique.Clique); ok {
+		header := block.Header()
+		sig, err := crypto.Sign(engine.SealHash(header).Bytes(), s.Key(i))
+		if err != nil {
+			return nil, err
+		}
+		copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+		return block.WithSeal(header), nil
+	}
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := engine.Seal(backend.BlockChain(), block, results, stop); err != nil {
+		return nil, err
+	}
+	return <-results, nil
+}
+
+// SendTransaction transfers value from the account of a node to the given
+// address. The transaction is added to the pool of the node, which includes
+// it in the next block it mines.
+func (s *Simulation) SendTransaction(i int, to common.Address, value *big.Int) (*types.Transaction, error) {
+	backend := s.Eth(i)
+	if backend == nil {
+		return nil, fmt.Errorf("node %d not running", i)
+	}
+	var (
+		pool   = backend.TxPool()
+		config = backend.BlockChain().Config()
+		head   = backend.BlockChain().CurrentBlock()
+		nonce  = pool.State().GetNonce(s.Address(i))
+	)
+	tx := types.NewTransaction(nonce, to, value, params.TxGas, big.NewInt(params.GWei), nil)
+	tx, err := types.SignTx(tx, types.MakeSigner(config, head.Number()), s.Key(i))
+	if err != nil {
+		return nil, err
+	}
+	return tx, pool.AddLocal(tx)
+}
+
+// Head returns the current head block of a node.
+func (s *Simulation) Head(i int) *types.Block {
+	backend := s.Eth(i)
+	if backend == nil {
+		return nil
+	}
+	return backend.BlockChain().CurrentBlock()
+}
+
+// Balance returns the balance of an account in the head state of a node.
+func (s *Simulation) Balance(i int, account common.Address) (*big.Int, error) {
+	backend := s.Eth(i)
+	if backend == nil {
+		return nil, fmt.Errorf("node %d not running", i)
+	}
+	statedb, err := backend.BlockChain().State()
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetBalance(account), nil
+}
+
+// WaitSync waits until the given nodes, or all nodes if none are given, have
+// the same head block and returns it.
+//
+// Nodes with fewer peers than the eth protocol wants only synchronise
+// periodically, so waiting for them to catch up with a longer chain may take
+// several seconds.
+func (s *Simulation) WaitSync(ctx context.Context, nodes ...int) (*types.Block, error) {
+	if len(nodes) == 0 {
+		nodes = s.all()
+	}
+	if _, err := s.nodeIDs(nodes); err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		head, synced := s.Head(nodes[0]), true
+		for _, i := range nodes[1:] {
+			if h := s.Head(i); head == nil || h == nil || h.Hash() != head.Hash() {
+				synced = false
+				break
+			}
+		}
+		if synced && head != nil {
+			return head, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for nodes %v to sync: %v", nodes, ctx.Err())
+		}
+	}
+}
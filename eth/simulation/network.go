@@ -0,0 +1,122 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+)
+
+// pollInterval is the interval at which the state of nodes is checked while
+// waiting for peers or chain convergence.
+const pollInterval = 50 * time.Millisecond
+
+// Connect connects two nodes and waits until they have completed the eth
+// handshake.
+func (s *Simulation) Connect(ctx context.Context, one, other int) error {
+	ids, err := s.nodeIDs([]int{one, other})
+	if err != nil {
+		return err
+	}
+	if err := s.Net.Connect(ids[0], ids[1]); err != nil {
+		return err
+	}
+	return s.waitPeer(ctx, ids[0], ids[1])
+}
+
+// ConnectAll connects each of the given nodes to all others, or all nodes of the
+// network if none are given.
+func (s *Simulation) ConnectAll(ctx context.Context, nodes ...int) error {
+	if len(nodes) == 0 {
+		nodes = s.all()
+	}
+	for i, one := range nodes {
+		for _, other := range nodes[i+1:] {
+			if err := s.Connect(ctx, one, other); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// waitPeer waits until a node runs the eth protocol with a peer.
+func (s *Simulation) waitPeer(ctx context.Context, id, peer enode.ID) error {
+	node := s.Net.GetNode(id)
+	if node == nil {
+		return fmt.Errorf("unknown node %v", id)
+	}
+	simNode, ok := node.Node.(*adapters.SimNode)
+	if !ok {
+		return fmt.Errorf("node %v is not a simulation node", id)
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if srv := simNode.Server(); srv != nil {
+			for _, info := range srv.PeersInfo() {
+				if info.ID != peer.String() {
+					continue
+				}
+				// Until the handshake is complete, the protocol info is a string.
+				if proto, ok := info.Protocols[serviceName]; ok {
+					if _, handshaking := proto.(string); !handshaking {
+						return nil
+					}
+				}
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for peer %v of node %v: %v", peer, id, ctx.Err())
+		}
+	}
+}
+
+// Partition splits the network into the given groups of nodes. Connections
+// between nodes of different groups are closed and can't be re-established until
+// the partition is healed.
+func (s *Simulation) Partition(groups ...[]int) error {
+	idGroups := make([][]enode.ID, len(groups))
+	for i, group := range groups {
+		ids, err := s.nodeIDs(group)
+		if err != nil {
+			return err
+		}
+		idGroups[i] = ids
+	}
+	return s.Net.Partition(idGroups...)
+}
+
+// Heal removes the current partition, restoring the connections it closed.
+func (s *Simulation) Heal() error {
+	return s.Net.Heal()
+}
+
+// all returns the indices of all nodes.
+func (s *Simulation) all() []int {
+	nodes := make([]int, len(s.ids))
+	for i := range nodes {
+		nodes[i] = i
+	}
+	return nodes
+}
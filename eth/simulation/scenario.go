@@ -0,0 +1,205 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// defaultStepTimeout is the time allowed for a scenario step that waits for the
+// network, if the step doesn't specify a timeout.
+const defaultStepTimeout = 30 * time.Second
+
+// Scenario is a scripted simulation, which can be loaded from JSON:
+//
+//   {
+//     "config": {"nodes": 3, "engine": "clique", "signers": [0, 1]},
+//     "steps": [
+//       {"action": "connect"},
+//       {"action": "send", "node": 0, "to": 2, "value": "1000"},
+//       {"action": "mine", "node": 0, "blocks": 1},
+//       {"action": "sync"},
+//       {"action": "expect", "number": 1, "account": 2, "balance": "1000000000000000000001000"}
+//     ]
+//   }
+type Scenario struct {
+	Config Config `json:"config"`
+	Steps  []Step `json:"steps"`
+}
+
+// Step is a single action of a scenario. The fields used depend on the action:
+//
+//   connect   connects Nodes to each other, all nodes if empty
+//   partition splits the network into Groups
+//   heal      removes the partition
+//   mine      makes Node mine Blocks blocks
+//   send      sends Value wei from the account of Node to the account of To
+//   sync      waits until Nodes, all nodes if empty, have the same head
+//   expect    checks the head of Nodes, all nodes if empty: its Number, the
+//             node whose account is the block's Author, and the Balance of the
+//             account of node Account
+//
+// Steps waiting for the network fail after Timeout seconds.
+type Step struct {
+	Action  string                `json:"action"`
+	Node    int                   `json:"node,omitempty"`
+	Nodes   []int                 `json:"nodes,omitempty"`
+	Groups  [][]int               `json:"groups,omitempty"`
+	Blocks  int                   `json:"blocks,omitempty"`
+	To      int                   `json:"to,omitempty"`
+	Value   *math.HexOrDecimal256 `json:"value,omitempty"`
+	Number  *uint64               `json:"number,omitempty"`
+	Author  *int                  `json:"author,omitempty"`
+	Account *int                  `json:"account,omitempty"`
+	Balance *math.HexOrDecimal256 `json:"balance,omitempty"`
+	Timeout int                   `json:"timeout,omitempty"`
+}
+
+// LoadScenario reads a scenario from a JSON file.
+func LoadScenario(file string) (*Scenario, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	sc := new(Scenario)
+	if err := dec.Decode(sc); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %v", file, err)
+	}
+	return sc, nil
+}
+
+// Run creates the network of the scenario and executes its steps in order,
+// stopping at the first failing step.
+func (sc *Scenario) Run(ctx context.Context) error {
+	sim, err := New(sc.Config)
+	if err != nil {
+		return err
+	}
+	defer sim.Close()
+
+	for i, step := range sc.Steps {
+		log.Debug("Running scenario step", "index", i, "action", step.Action)
+		if err := step.run(ctx, sim); err != nil {
+			return fmt.Errorf("step %d (%s): %v", i, step.Action, err)
+		}
+	}
+	return nil
+}
+
+func (step *Step) run(ctx context.Context, sim *Simulation) error {
+	timeout := defaultStepTimeout
+	if step.Timeout > 0 {
+		timeout = time.Duration(step.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := sim.nodeIDs(append([]int{step.Node}, step.Nodes...)); err != nil {
+		return err
+	}
+	switch step.Action {
+	case "connect":
+		return sim.ConnectAll(ctx, step.Nodes...)
+
+	case "partition":
+		return sim.Partition(step.Groups...)
+
+	case "heal":
+		return sim.Heal()
+
+	case "mine":
+		_, err := sim.Mine(step.Node, step.Blocks)
+		return err
+
+	case "send":
+		if _, err := sim.nodeIDs([]int{step.To}); err != nil {
+			return err
+		}
+		if step.Value == nil {
+			return fmt.Errorf("missing value")
+		}
+		_, err := sim.SendTransaction(step.Node, sim.Address(step.To), (*big.Int)(step.Value))
+		return err
+
+	case "sync":
+		_, err := sim.WaitSync(ctx, step.Nodes...)
+		return err
+
+	case "expect":
+		return step.expect(sim)
+
+	default:
+		return fmt.Errorf("unknown action %q", step.Action)
+	}
+}
+
+// expect checks the expectations of the step against the head of each node.
+func (step *Step) expect(sim *Simulation) error {
+	nodes := step.Nodes
+	if len(nodes) == 0 {
+		nodes = sim.all()
+	}
+	for _, i := range nodes {
+		head := sim.Head(i)
+		if head == nil {
+			return fmt.Errorf("node %d not running", i)
+		}
+		if step.Number != nil && head.NumberU64() != *step.Number {
+			return fmt.Errorf("node %d: head number %d, want %d", i, head.NumberU64(), *step.Number)
+		}
+		if step.Author != nil {
+			if _, err := sim.nodeIDs([]int{*step.Author}); err != nil {
+				return err
+			}
+			author, err := sim.Eth(i).Engine().Author(head.Header())
+			if err != nil {
+				return err
+			}
+			if want := sim.Address(*step.Author); author != want {
+				return fmt.Errorf("node %d: head author %x, want %x (node %d)", i, author, want, *step.Author)
+			}
+		}
+		if step.Account != nil || step.Balance != nil {
+			if step.Account == nil || step.Balance == nil {
+				return fmt.Errorf("balance expectation needs account and balance")
+			}
+			if _, err := sim.nodeIDs([]int{*step.Account}); err != nil {
+				return err
+			}
+			balance, err := sim.Balance(i, sim.Address(*step.Account))
+			if err != nil {
+				return err
+			}
+			if want := (*big.Int)(step.Balance); balance.Cmp(want) != 0 {
+				return fmt.Errorf("node %d: balance of node %d is %v, want %v", i, *step.Account, balance, want)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulation runs networks of in-process Ethereum nodes on top of the
+// p2p/simulations framework, for testing chain synchronisation and consensus
+// across multiple nodes.
+//
+// All nodes share a genesis block and have deterministic keys, so the same
+// scenario always produces the same chains. Nodes don't run the miner; blocks
+// are produced explicitly with Mine, which makes reorgs reproducible.
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	serviceName = "eth"
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for clique signer vanity
+	extraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for the clique signer seal
+)
+
+// Consensus engines supported by the simulation.
+const (
+	Ethash = "ethash" // Ethash with fake proof-of-work
+	Clique = "clique" // Clique proof-of-authority
+)
+
+var (
+	errUnknownEngine = errors.New("unknown consensus engine")
+	errNoNodes       = errors.New("simulation needs at least one node")
+)
+
+// initialBalance is the genesis balance of each node's account.
+var initialBalance = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
+
+// Config is the configuration of a simulated network.
+type Config struct {
+	Nodes   int    `json:"nodes"`             // Number of nodes in the network
+	Engine  string `json:"engine,omitempty"`  // Consensus engine, ethash if empty
+	Period  uint64 `json:"period,omitempty"`  // Clique block period in seconds
+	Signers []int  `json:"signers,omitempty"` // Indices of the initial clique signers, all nodes if empty
+}
+
+// Simulation is a network of in-process Ethereum nodes. Nodes are referred to by
+// their index, from zero to the number of nodes.
+type Simulation struct {
+	// Net is exposed to give access to the lower level functionality of the
+	// p2p/simulations network.
+	Net *simulations.Network
+
+	config  Config
+	genesis *core.Genesis
+	keys    []*ecdsa.PrivateKey
+	ids     []enode.ID
+
+	mu       sync.RWMutex
+	backends map[enode.ID]*eth.Ethereum
+}
+
+// New creates a simulated network and starts its nodes. The nodes are not
+// connected to each other.
+func New(config Config) (*Simulation, error) {
+	if config.Nodes <= 0 {
+		return nil, errNoNodes
+	}
+	s := &Simulation{
+		config:   config,
+		keys:     make([]*ecdsa.PrivateKey, config.Nodes),
+		ids:      make([]enode.ID, config.Nodes),
+		backends: make(map[enode.ID]*eth.Ethereum),
+	}
+	for i := range s.keys {
+		s.keys[i] = nodeKey(i)
+		s.ids[i] = enode.PubkeyToIDV4(&s.keys[i].PublicKey)
+	}
+	genesis, err := s.makeGenesis()
+	if err != nil {
+		return nil, err
+	}
+	s.genesis = genesis
+
+	adapter := adapters.NewSimAdapter(adapters.Services{serviceName: s.newService})
+	s.Net = simulations.NewNetwork(adapter, &simulations.NetworkConfig{
+		ID:             "eth",
+		DefaultService: serviceName,
+	})
+	for i, key := range s.keys {
+		conf := adapters.RandomNodeConfig()
+		conf.ID = s.ids[i]
+		conf.PrivateKey = key
+		conf.Name = fmt.Sprintf("node%02d", i)
+		if _, err := s.Net.NewNodeWithConfig(conf); err != nil {
+			s.Close()
+			return nil, err
+		}
+		if err := s.Net.Start(conf.ID); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// nodeKey derives the key of a node from its index. The key is used both as
+// the p2p node key and as the key of the node's account.
+func nodeKey(index int) *ecdsa.PrivateKey {
+	seed := crypto.Keccak256([]byte(fmt.Sprintf("eth/simulation node %d", index)))
+	key, err := crypto.ToECDSA(seed)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// makeGenesis creates the genesis block shared by all nodes, funding the
+// accounts of all nodes.
+func (s *Simulation) makeGenesis() (*core.Genesis, error) {
+	genesis := &core.Genesis{
+		GasLimit: 8000000,
+		Alloc:    make(core.GenesisAlloc),
+	}
+	for i := range s.keys {
+		genesis.Alloc[s.Address(i)] = core.GenesisAccount{Balance: initialBalance}
+	}
+	switch s.config.Engine {
+	case "", Ethash:
+		config := *params.AllEthashProtocolChanges
+		genesis.Config = &config
+
+	case Clique:
+		config := *params.AllCliqueProtocolChanges
+		config.Clique = &params.CliqueConfig{Period: s.config.Period, Epoch: 30000}
+		genesis.Config = &config
+		genesis.Difficulty = big.NewInt(1)
+
+		signers := s.config.Signers
+		if len(signers) == 0 {
+			for i := range s.keys {
+				signers = append(signers, i)
+			}
+		}
+		genesis.ExtraData = make([]byte, extraVanity)
+		for _, i := range signers {
+			if i < 0 || i >= len(s.keys) {
+				return nil, fmt.Errorf("signer index %d out of range", i)
+			}
+			genesis.ExtraData = append(genesis.ExtraData, s.Address(i).Bytes()...)
+		}
+		genesis.ExtraData = append(genesis.ExtraData, make([]byte, extraSeal)...)
+
+	default:
+		return nil, fmt.Errorf("%v: %q", errUnknownEngine, s.config.Engine)
+	}
+	return genesis, nil
+}
+
+// newService creates the Ethereum service of a node.
+func (s *Simulation) newService(ctx *adapters.ServiceContext) (node.Service, error) {
+	key := ctx.Config.PrivateKey
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := eth.DefaultConfig
+	config.Genesis = s.genesis
+	config.NetworkId = s.genesis.Config.ChainID.Uint64()
+	config.SyncMode = downloader.FullSync
+	config.Ethash.PowMode = ethash.ModeFake
+	config.Etherbase = address
+
+	backend, err := eth.New(ctx.NodeContext, &config)
+	if err != nil {
+		return nil, err
+	}
+	if engine, ok := backend.Engine().(*clique.Clique); ok {
+		engine.Authorize(address, func(_ accounts.Account, hash []byte) ([]byte, error) {
+			return crypto.Sign(hash, key)
+		})
+	}
+	s.mu.Lock()
+	s.backends[ctx.Config.ID] = backend
+	s.mu.Unlock()
+	return backend, nil
+}
+
+// Close shuts down all nodes of the network.
+func (s *Simulation) Close() {
+	s.Net.Shutdown()
+}
+
+// Nodes returns the number of nodes in the network.
+func (s *Simulation) Nodes() int {
+	return len(s.ids)
+}
+
+// ID returns the node ID of a node.
+func (s *Simulation) ID(i int) enode.ID {
+	return s.ids[i]
+}
+
+// Key returns the key of a node, which is also the key of its account.
+func (s *Simulation) Key(i int) *ecdsa.PrivateKey {
+	return s.keys[i]
+}
+
+// Address returns the address of a node's account, which is its etherbase and
+// clique signer address.
+func (s *Simulation) Address(i int) common.Address {
+	return crypto.PubkeyToAddress(s.keys[i].PublicKey)
+}
+
+// Genesis returns the genesis block specification shared by all nodes.
+func (s *Simulation) Genesis() *core.Genesis {
+	return s.genesis
+}
+
+// Eth returns the Ethereum service of a node.
+func (s *Simulation) Eth(i int) *eth.Ethereum {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backends[s.ids[i]]
+}
+
+// nodeIDs converts node indices to node IDs.
+func (s *Simulation) nodeIDs(indices []int) ([]enode.ID, error) {
+	ids := make([]enode.ID, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= len(s.ids) {
+			return nil, fmt.Errorf("node index %d out of range", index)
+		}
+		ids[i] = s.ids[index]
+	}
+	return ids, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"context"
+	"flag"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var scenarioDir = flag.String("scenarios", "testdata", "directory of the JSON scenarios run by TestScenarios")
+
+func newTestSimulation(t *testing.T, config Config) *Simulation {
+	sim, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sim
+}
+
+// Tests that blocks mined by one node are propagated to all others.
+func TestSimulationSync(t *testing.T) {
+	sim := newTestSimulation(t, Config{Nodes: 3})
+	defer sim.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := sim.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := sim.Mine(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := sim.WaitSync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != blocks[2].Hash() {
+		t.Fatalf("wrong head: have %x, want %x", head.Hash(), blocks[2].Hash())
+	}
+}
+
+// Tests that the same actions produce the same blocks.
+func TestSimulationDeterministic(t *testing.T) {
+	mine := func() *big.Int {
+		sim := newTestSimulation(t, Config{Nodes: 2})
+		defer sim.Close()
+		if _, err := sim.SendTransaction(0, sim.Address(1), big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+		blocks, err := sim.Mine(0, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blocks[1].Hash().Big()
+	}
+	if one, other := mine(), mine(); one.Cmp(other) != 0 {
+		t.Fatalf("different blocks mined: %x != %x", one, other)
+	}
+}
+
+// Tests that clique signers seal blocks including transactions.
+func TestSimulationClique(t *testing.T) {
+	sim := newTestSimulation(t, Config{Nodes: 3, Engine: Clique, Signers: []int{0, 1}})
+	defer sim.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := sim.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	value := big.NewInt(1000)
+	if _, err := sim.SendTransaction(0, sim.Address(2), value); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Mine(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.WaitSync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Mine(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	head, err := sim.WaitSync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.NumberU64() != 2 {
+		t.Fatalf("wrong head number %d", head.NumberU64())
+	}
+	balance, err := sim.Balance(2, sim.Address(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Add(initialBalance, value); balance.Cmp(want) != 0 {
+		t.Fatalf("wrong balance %v, want %v", balance, want)
+	}
+	// Node 2 is not a signer, and node 1 signed too recently.
+	if _, err := sim.Mine(2, 1); err == nil {
+		t.Fatal("block of unauthorized signer accepted")
+	}
+	if _, err := sim.Mine(1, 1); err == nil {
+		t.Fatal("block of recent signer accepted")
+	}
+}
+
+// Tests that the chains of a partitioned network reorg to the heavier side
+// after the partition is healed.
+func TestSimulationReorg(t *testing.T) {
+	sim := newTestSimulation(t, Config{Nodes: 4})
+	defer sim.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := sim.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Partition([]int{0, 1}, []int{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Mine(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	heavy, err := sim.Mine(2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.WaitSync(ctx, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.WaitSync(ctx, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Heal(); err != nil {
+		t.Fatal(err)
+	}
+	head, err := sim.WaitSync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != heavy[3].Hash() {
+		t.Fatalf("wrong head after reorg: have %x, want %x", head.Hash(), heavy[3].Hash())
+	}
+}
+
+// Tests the JSON scenarios of the scenario directory.
+func TestScenarios(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(*scenarioDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no scenarios found in %s", *scenarioDir)
+	}
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			sc, err := LoadScenario(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			if err := sc.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/typeddata"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
@@ -438,8 +439,13 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	if err != nil {
 		return nil, err
 	}
-	// Assemble sign the data with the wallet
-	signature, err := wallet.SignHashWithPassphrase(account, passwd, signHash(data))
+	// Assemble sign the data with the wallet, external signers hash it themselves
+	var signature []byte
+	if signer, ok := wallet.(accounts.TextSigner); ok {
+		signature, err = signer.SignText(account, data)
+	} else {
+		signature, err = wallet.SignHashWithPassphrase(account, passwd, signHash(data))
+	}
 	if err != nil {
 		log.Warn("Failed data sign attempt", "address", addr, "err", err)
 		return nil, err
@@ -448,6 +454,35 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	return signature, nil
 }
 
+// SignTypedData calculates an ECDSA signature for structured data according to
+// EIP-712: keccak256("\x19\x01" + domainSeparator + hashStruct(message)).
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The key used to calculate the signature is decrypted with the given password.
+func (s *PrivateAccountAPI) SignTypedData(ctx context.Context, data typeddata.TypedData, addr common.Address, passwd string) (hexutil.Bytes, error) {
+	hash, _, err := data.Hash()
+	if err != nil {
+		return nil, err
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	// Assemble sign the data with the wallet
+	signature, err := wallet.SignHashWithPassphrase(account, passwd, hash[:])
+	if err != nil {
+		log.Warn("Failed typed data sign attempt", "address", addr, "err", err)
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
 // EcRecover returns the address for the account that was used to create the signature.
 // Note, this function is compatible with eth_sign and personal_sign. As such it recovers
 // the address of:
@@ -977,6 +1012,12 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
+// RPCMarshalTransaction converts the given transaction to the RPC output, with
+// the given location metadata set (if available).
+func RPCMarshalTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
+	return newRPCTransaction(tx, blockHash, blockNumber, index)
+}
+
 // newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
 func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0)
@@ -1082,18 +1123,32 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 	return (*hexutil.Uint64)(&nonce), state.Error()
 }
 
+// txIndexUnavailable returns an error if a transaction that isn't indexed might
+// still exist in an old block whose lookup entries were deleted due to the
+// transaction index limit. Otherwise nil is returned, e.g. for transactions which
+// are still pending in the pool.
+func txIndexUnavailable(b Backend, hash common.Hash) error {
+	if b.GetPoolTransaction(hash) != nil {
+		return nil
+	}
+	if tail := rawdb.ReadTxIndexTail(b.ChainDb()); tail != nil && *tail > 0 {
+		return fmt.Errorf("transaction index not available: only transactions since block #%d are indexed", *tail)
+	}
+	return nil
+}
+
 // GetTransactionByHash returns the transaction for the given hash
-func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) *RPCTransaction {
+func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (*RPCTransaction, error) {
 	// Try to return an already finalized transaction
 	if tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash); tx != nil {
-		return newRPCTransaction(tx, blockHash, blockNumber, index)
+		return newRPCTransaction(tx, blockHash, blockNumber, index), nil
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx)
+		return newRPCPendingTransaction(tx), nil
 	}
-	// Transaction unknown, return as such
-	return nil
+	// Transaction unknown, return as such (unless it might have been unindexed)
+	return nil, txIndexUnavailable(s.b, hash)
 }
 
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
@@ -1104,7 +1159,7 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 	if tx, _, _, _ = rawdb.ReadTransaction(s.b.ChainDb(), hash); tx == nil {
 		if tx = s.b.GetPoolTransaction(hash); tx == nil {
 			// Transaction not found anywhere, abort
-			return nil, nil
+			return nil, txIndexUnavailable(s.b, hash)
 		}
 	}
 	// Serialize to RLP and return
@@ -1115,7 +1170,7 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash)
 	if tx == nil {
-		return nil, nil
+		return nil, txIndexUnavailable(s.b, hash)
 	}
 	receipts, err := s.b.GetReceipts(ctx, blockHash)
 	if err != nil {
@@ -1330,8 +1385,40 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 	if err != nil {
 		return nil, err
 	}
-	// Sign the requested hash with the wallet
-	signature, err := wallet.SignHash(account, signHash(data))
+	// Sign the requested hash with the wallet, external signers hash it themselves
+	var signature []byte
+	if signer, ok := wallet.(accounts.TextSigner); ok {
+		signature, err = signer.SignText(account, data)
+	} else {
+		signature, err = wallet.SignHash(account, signHash(data))
+	}
+	if err == nil {
+		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	}
+	return signature, err
+}
+
+// SignTypedData calculates an ECDSA signature for structured data according to
+// EIP-712: keccak256("\x19\x01" + domainSeparator + hashStruct(message)).
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The account associated with addr must be unlocked.
+func (s *PublicTransactionPoolAPI) SignTypedData(addr common.Address, data typeddata.TypedData) (hexutil.Bytes, error) {
+	hash, _, err := data.Hash()
+	if err != nil {
+		return nil, err
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	// Sign the typed data hash with the wallet
+	signature, err := wallet.SignHash(account, hash[:])
 	if err == nil {
 		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
 	}
@@ -0,0 +1,83 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// txPoolBackend implements the parts of Backend needed for looking up
+// transactions. Calling any other method panics.
+type txPoolBackend struct {
+	Backend
+	db   ethdb.Database
+	pool map[common.Hash]*types.Transaction
+}
+
+func (b *txPoolBackend) ChainDb() ethdb.Database { return b.db }
+
+func (b *txPoolBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	return b.pool[hash]
+}
+
+// This test checks that lookups of transactions which aren't in the index only
+// fail if the index is incomplete and the transaction isn't pending.
+func TestTransactionLookupIndexTail(t *testing.T) {
+	var (
+		pending = types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		unknown = common.Hash{0xff}
+		backend = &txPoolBackend{
+			db:   ethdb.NewMemDatabase(),
+			pool: map[common.Hash]*types.Transaction{pending.Hash(): pending},
+		}
+		api = NewPublicTransactionPoolAPI(backend, nil)
+		ctx = context.Background()
+	)
+
+	// With a complete index, unknown transactions don't exist.
+	if tx, err := api.GetTransactionByHash(ctx, unknown); tx != nil || err != nil {
+		t.Fatalf("unknown transaction with complete index: got %v, %v", tx, err)
+	}
+
+	// Unindex the first blocks.
+	rawdb.WriteTxIndexTail(backend.db, 100)
+
+	if _, err := api.GetTransactionByHash(ctx, unknown); err == nil {
+		t.Error("GetTransactionByHash: no error for unknown transaction with incomplete index")
+	}
+	if _, err := api.GetRawTransactionByHash(ctx, unknown); err == nil {
+		t.Error("GetRawTransactionByHash: no error for unknown transaction with incomplete index")
+	}
+	if _, err := api.GetTransactionReceipt(ctx, unknown); err == nil {
+		t.Error("GetTransactionReceipt: no error for unknown transaction with incomplete index")
+	}
+
+	// Pending transactions are found, but have no receipt yet.
+	if tx, err := api.GetTransactionByHash(ctx, pending.Hash()); tx == nil || err != nil {
+		t.Errorf("GetTransactionByHash: pending transaction not found: %v", err)
+	}
+	if receipt, err := api.GetTransactionReceipt(ctx, pending.Hash()); receipt != nil || err != nil {
+		t.Errorf("GetTransactionReceipt: got %v, %v for pending transaction, want nil, nil", receipt, err)
+	}
+}
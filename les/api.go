@@ -0,0 +1,149 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	errNoClientPool = errors.New("client pool not running")
+	errNoCheckpoint = errors.New("no local checkpoint provided")
+	errNoOracle     = errors.New("checkpoint oracle is not configured")
+)
+
+// PrivateLightServerAPI provides an API to manage the clients of a light server.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new API to manage the clients of a light server.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server}
+}
+
+// ClientBalance is the result of a client balance change, containing the balance
+// (in nanoseconds of serving time) before and after the change.
+type ClientBalance struct {
+	Old hexutil.Uint64 `json:"old"`
+	New hexutil.Uint64 `json:"new"`
+}
+
+// AddBalance changes the serving time balance of a client by the given amount
+// (in nanoseconds, possibly negative). Clients having a positive balance are
+// served with priority over free ones until their balance runs out.
+func (api *PrivateLightServerAPI) AddBalance(id enode.ID, amount int64) (*ClientBalance, error) {
+	pool := api.server.protocolManager.clientPool
+	if pool == nil {
+		return nil, errNoClientPool
+	}
+	old, balance, err := pool.addBalance(id, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientBalance{Old: hexutil.Uint64(old), New: hexutil.Uint64(balance)}, nil
+}
+
+// GetBalance returns the current serving time balance of a client in nanoseconds.
+func (api *PrivateLightServerAPI) GetBalance(id enode.ID) (hexutil.Uint64, error) {
+	pool := api.server.protocolManager.clientPool
+	if pool == nil {
+		return 0, errNoClientPool
+	}
+	return hexutil.Uint64(pool.balance(id)), nil
+}
+
+// RequestCost is the cost of a request type advertised to clients, consisting
+// of a base cost and a cost per requested item (in nanoseconds of serving time).
+type RequestCost struct {
+	BaseCost hexutil.Uint64 `json:"baseCost"`
+	ReqCost  hexutil.Uint64 `json:"reqCost"`
+}
+
+// ServerInfo contains the flow control parameters and request costs derived from
+// the measured serving costs, as handed out to newly connected clients.
+type ServerInfo struct {
+	Capacity    hexutil.Uint64         `json:"capacity"`
+	BufLimit    hexutil.Uint64         `json:"bufLimit"`
+	MinRecharge hexutil.Uint64         `json:"minRecharge"`
+	Costs       map[string]RequestCost `json:"costs"`
+}
+
+// ServerInfo returns the total serving capacity (in nanoseconds of serving time
+// per millisecond), the current flow control parameters handed out to clients and
+// the measured cost of each request type.
+func (api *PrivateLightServerAPI) ServerInfo() *ServerInfo {
+	ct := api.server.costTracker
+	params, costs := ct.current()
+
+	info := &ServerInfo{
+		Capacity:    hexutil.Uint64(ct.capacity),
+		BufLimit:    hexutil.Uint64(params.BufLimit),
+		MinRecharge: hexutil.Uint64(params.MinRecharge),
+		Costs:       make(map[string]RequestCost),
+	}
+	for _, c := range costs {
+		info.Costs[requests[c.MsgCode].name] = RequestCost{BaseCost: hexutil.Uint64(c.BaseCost), ReqCost: hexutil.Uint64(c.ReqCost)}
+	}
+	return info
+}
+
+// PrivateLightAPI provides an API to access the checkpoints of a light server
+// or light client.
+type PrivateLightAPI struct {
+	backend *lesCommons
+}
+
+// NewPrivateLightAPI creates a new API to access the checkpoints of a light
+// server or light client.
+func NewPrivateLightAPI(backend *lesCommons) *PrivateLightAPI {
+	return &PrivateLightAPI{backend}
+}
+
+// LatestCheckpoint returns the latest locally generated checkpoint.
+func (api *PrivateLightAPI) LatestCheckpoint() (*params.TrustedCheckpoint, error) {
+	checkpoint := api.backend.latestLocalCheckpoint()
+	if checkpoint.Empty() {
+		return nil, errNoCheckpoint
+	}
+	return &checkpoint, nil
+}
+
+// GetCheckpoint returns the locally generated checkpoint of the given section,
+// which is what checkpoint oracle admins sign and register.
+func (api *PrivateLightAPI) GetCheckpoint(index uint64) (*params.TrustedCheckpoint, error) {
+	checkpoint := api.backend.getLocalCheckpoint(index)
+	if checkpoint.Empty() {
+		return nil, errNoCheckpoint
+	}
+	return &checkpoint, nil
+}
+
+// GetCheckpointContractAddress returns the address of the configured checkpoint
+// oracle contract.
+func (api *PrivateLightAPI) GetCheckpointContractAddress() (common.Address, error) {
+	oracle := api.backend.protocolManager.oracle
+	if oracle == nil {
+		return common.Address{}, errNoOracle
+	}
+	return oracle.config.Address, nil
+}
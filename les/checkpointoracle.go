@@ -0,0 +1,194 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// checkpointRefreshInterval is the time between two lookups of the latest
+// checkpoint registered in the oracle contract.
+const checkpointRefreshInterval = time.Minute
+
+// checkpointOracle is responsible for offering the latest checkpoint registered
+// in the on-chain oracle contract. Servers look it up in the contract along with
+// the admin votes it was registered with and advertise both to their clients,
+// which verify the votes against the configured admins before trusting it.
+type checkpointOracle struct {
+	config   *params.CheckpointOracleConfig
+	getLocal func(uint64) params.TrustedCheckpoint
+
+	lock       sync.RWMutex
+	contract   *checkpointoracle.CheckpointOracle
+	checkpoint *params.TrustedCheckpoint // Latest registered checkpoint matching the local one
+	signatures [][]byte                  // Admin votes the checkpoint was registered with
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newCheckpointOracle creates a checkpoint oracle handler from the configuration,
+// returning nil if none or an unusable one is given.
+func newCheckpointOracle(config *params.CheckpointOracleConfig, getLocal func(uint64) params.TrustedCheckpoint) *checkpointOracle {
+	if config == nil {
+		log.Info("Checkpoint oracle is not enabled")
+		return nil
+	}
+	if config.Address == (common.Address{}) || uint64(len(config.Signers)) < config.Threshold || config.Threshold == 0 {
+		log.Warn("Invalid checkpoint oracle config", "address", config.Address, "signers", len(config.Signers), "threshold", config.Threshold)
+		return nil
+	}
+	log.Info("Configured checkpoint oracle", "address", config.Address, "signers", len(config.Signers), "threshold", config.Threshold)
+
+	return &checkpointOracle{
+		config:   config,
+		getLocal: getLocal,
+		quit:     make(chan struct{}),
+	}
+}
+
+// start binds the oracle contract through the given backend and starts looking
+// up newly registered checkpoints periodically.
+func (oracle *checkpointOracle) start(backend bind.ContractBackend) {
+	contract, err := checkpointoracle.NewCheckpointOracle(oracle.config.Address, backend)
+	if err != nil {
+		log.Error("Failed to bind checkpoint oracle", "err", err)
+		return
+	}
+	oracle.lock.Lock()
+	started := oracle.contract != nil
+	oracle.contract = contract
+	oracle.lock.Unlock()
+
+	if started {
+		return
+	}
+	oracle.wg.Add(1)
+	go func() {
+		defer oracle.wg.Done()
+
+		ticker := time.NewTicker(checkpointRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			oracle.refresh()
+			select {
+			case <-ticker.C:
+			case <-oracle.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop terminates the checkpoint lookup loop.
+func (oracle *checkpointOracle) stop() {
+	close(oracle.quit)
+	oracle.wg.Wait()
+}
+
+// stableCheckpoint returns the latest checkpoint registered in the oracle along
+// with the admin votes it was registered with, or nil if there's none matching
+// the locally generated one.
+func (oracle *checkpointOracle) stableCheckpoint() (*params.TrustedCheckpoint, [][]byte) {
+	oracle.lock.RLock()
+	defer oracle.lock.RUnlock()
+
+	return oracle.checkpoint, oracle.signatures
+}
+
+// refresh looks up the latest checkpoint registered in the oracle, accepting it
+// if it matches the local one and is signed by enough admins.
+func (oracle *checkpointOracle) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	oracle.lock.RLock()
+	contract := oracle.contract
+	oracle.lock.RUnlock()
+
+	index, hash, height, err := contract.Contract().GetLatestCheckpoint(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		log.Debug("Failed to retrieve latest checkpoint", "err", err)
+		return
+	}
+	if hash == [32]byte{} {
+		return
+	}
+	if current, _ := oracle.stableCheckpoint(); current != nil && current.SectionIndex == index {
+		return
+	}
+	local := oracle.getLocal(index)
+	if local.Empty() || local.Hash() != common.Hash(hash) {
+		log.Warn("Registered checkpoint doesn't match local one", "section", index, "registered", common.Hash(hash), "local", local.Hash())
+		return
+	}
+	sigs, err := contract.LookupCheckpointVotes(ctx, index, hash, height.Uint64())
+	if err != nil {
+		log.Debug("Failed to retrieve checkpoint votes", "section", index, "err", err)
+		return
+	}
+	if ok, _ := oracle.verifySigners(index, hash, sigs); !ok {
+		log.Warn("Registered checkpoint has too few valid votes", "section", index, "votes", len(sigs))
+		return
+	}
+	oracle.lock.Lock()
+	oracle.checkpoint, oracle.signatures = &local, sigs
+	oracle.lock.Unlock()
+
+	log.Info("Found new registered checkpoint", "section", index, "hash", common.Hash(hash), "height", height)
+}
+
+// verifySigners recovers the signers of the given checkpoint votes, returning
+// whether enough distinct admins signed it along with the recovered admins.
+func (oracle *checkpointOracle) verifySigners(index uint64, hash common.Hash, signatures [][]byte) (bool, []common.Address) {
+	if uint64(len(signatures)) < oracle.config.Threshold {
+		return false, nil
+	}
+	var (
+		signers []common.Address
+		checked = make(map[common.Address]struct{})
+		sighash = checkpointoracle.SignatureHash(oracle.config.Address, index, hash)
+	)
+	for _, sig := range signatures {
+		pubkey, err := crypto.SigToPub(sighash.Bytes(), sig)
+		if err != nil {
+			return false, nil
+		}
+		signer := crypto.PubkeyToAddress(*pubkey)
+		if _, exist := checked[signer]; exist {
+			continue
+		}
+		for _, s := range oracle.config.Signers {
+			if s == signer {
+				signers = append(signers, signer)
+				checked[signer] = struct{}{}
+				break
+			}
+		}
+	}
+	return uint64(len(signers)) >= oracle.config.Threshold, signers
+}
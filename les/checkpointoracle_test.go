@@ -0,0 +1,82 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCheckpointVerifySigners(t *testing.T) {
+	var (
+		keys   []*ecdsa.PrivateKey
+		config = &params.CheckpointOracleConfig{
+			Address:   common.HexToAddress("0x1234"),
+			Threshold: 2,
+		}
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		if i < 3 {
+			config.Signers = append(config.Signers, crypto.PubkeyToAddress(key.PublicKey))
+		}
+	}
+	oracle := newCheckpointOracle(config, nil)
+
+	checkpoint := params.TrustedCheckpoint{
+		SectionIndex: 1,
+		SectionHead:  common.HexToHash("0x01"),
+		CHTRoot:      common.HexToHash("0x02"),
+		BloomRoot:    common.HexToHash("0x03"),
+	}
+	sign := func(key *ecdsa.PrivateKey, address common.Address, index uint64) []byte {
+		sig, _ := crypto.Sign(checkpointoracle.SignatureHash(address, index, checkpoint.Hash()).Bytes(), key)
+		return sig
+	}
+	tests := []struct {
+		sigs    [][]byte
+		valid   bool
+		signers int
+	}{
+		// Enough admin votes
+		{[][]byte{sign(keys[0], config.Address, 1), sign(keys[1], config.Address, 1)}, true, 2},
+		{[][]byte{sign(keys[2], config.Address, 1), sign(keys[0], config.Address, 1), sign(keys[1], config.Address, 1)}, true, 3},
+		// Too few votes
+		{[][]byte{sign(keys[0], config.Address, 1)}, false, 0},
+		// Duplicate votes
+		{[][]byte{sign(keys[0], config.Address, 1), sign(keys[0], config.Address, 1)}, false, 1},
+		// Votes from non-admins
+		{[][]byte{sign(keys[0], config.Address, 1), sign(keys[3], config.Address, 1)}, false, 1},
+		// Votes for a different oracle or section
+		{[][]byte{sign(keys[0], common.HexToAddress("0x4321"), 1), sign(keys[1], config.Address, 1)}, false, 1},
+		{[][]byte{sign(keys[0], config.Address, 2), sign(keys[1], config.Address, 1)}, false, 1},
+		// Malformed votes
+		{[][]byte{sign(keys[0], config.Address, 1), {0x01, 0x02}}, false, 0},
+	}
+	for i, tt := range tests {
+		valid, signers := oracle.verifySigners(checkpoint.SectionIndex, checkpoint.Hash(), tt.sigs)
+		if valid != tt.valid || len(signers) != tt.signers {
+			t.Errorf("test %d: verification mismatch: have %v/%d, want %v/%d", i, valid, len(signers), tt.valid, tt.signers)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	errNegativeBalance = errors.New("balance would become negative")
+	errBalanceOverflow = errors.New("balance would overflow")
+)
+
+// priorityClientPool implements a client database that gives priority to clients
+// having a positive balance over free ones. The balance of a client is denominated
+// in serving time (nanoseconds) and is charged with the real cost of each request
+// served to it. Clients running out of balance are disconnected and may reconnect as
+// free clients.
+//
+// Priority clients are identified by their node ID, so that the balance can't be
+// used by anyone else. Free clients are handled by an underlying freeClientPool,
+// which is given whatever connection slots are not used by priority clients. If
+// the pool is full, free clients are kicked out first to make room for priority
+// ones; if there are no free clients left, the priority client with the lowest
+// balance is kicked out in favor of one with a higher balance.
+type priorityClientPool struct {
+	lock   sync.Mutex
+	db     ethdb.Database
+	child  *freeClientPool
+	closed bool
+
+	connectedLimit int                          // Total number of clients allowed to be connected
+	balances       map[enode.ID]uint64          // Serving time balances of all known clients
+	priority       map[enode.ID]*priorityClient // Connected clients served with priority
+	free           map[enode.ID]*priorityClient // Connected clients served by the free pool
+}
+
+// priorityClient represents a client connected to the priority pool.
+type priorityClient struct {
+	address      string
+	disconnectFn func()
+}
+
+// priorityClientBalance is the RLP representation of a client balance in the
+// pool's database storage.
+type priorityClientBalance struct {
+	ID      enode.ID
+	Balance uint64
+}
+
+// newPriorityClientPool creates a new priority client pool on top of the given
+// free client pool.
+func newPriorityClientPool(db ethdb.Database, connectedLimit int, child *freeClientPool) *priorityClientPool {
+	pool := &priorityClientPool{
+		db:             db,
+		child:          child,
+		connectedLimit: connectedLimit,
+		balances:       make(map[enode.ID]uint64),
+		priority:       make(map[enode.ID]*priorityClient),
+		free:           make(map[enode.ID]*priorityClient),
+	}
+	pool.loadFromDb()
+	return pool
+}
+
+func (p *priorityClientPool) stop() {
+	p.lock.Lock()
+	p.closed = true
+	p.saveToDb()
+	p.lock.Unlock()
+
+	p.child.stop()
+}
+
+// connect should be called after a successful handshake. If the connection was
+// rejected, there is no need to call disconnect.
+//
+// Note: the disconnectFn callback should not block.
+func (p *priorityClientPool) connect(id enode.ID, address string, disconnectFn func()) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return false
+	}
+	if p.priority[id] != nil || p.free[id] != nil {
+		log.Debug("Client already connected", "id", id)
+		return false
+	}
+	client := &priorityClient{address: address, disconnectFn: disconnectFn}
+
+	balance := p.balances[id]
+	if balance == 0 {
+		if !p.child.connect(address, disconnectFn) {
+			return false
+		}
+		p.free[id] = client
+		return true
+	}
+	if !p.makeRoom(balance) {
+		log.Debug("Priority client rejected", "id", id, "balance", balance)
+		return false
+	}
+	p.priority[id] = client
+	p.child.setConnectedLimit(p.connectedLimit - len(p.priority))
+
+	log.Debug("Priority client accepted", "id", id, "balance", balance)
+	return true
+}
+
+// makeRoom ensures there is a free slot for a new priority client with the given
+// balance by kicking out the connected priority client with the lowest balance,
+// if needed and if that is lower than the new one. Free clients are taken care of
+// by lowering the connection limit of the free pool afterwards.
+func (p *priorityClientPool) makeRoom(balance uint64) bool {
+	if len(p.priority) < p.connectedLimit {
+		return true
+	}
+	var (
+		lowestID enode.ID
+		lowest   *priorityClient
+	)
+	for id, client := range p.priority {
+		if lowest == nil || p.balances[id] < p.balances[lowestID] {
+			lowestID, lowest = id, client
+		}
+	}
+	if lowest == nil || p.balances[lowestID] >= balance {
+		return false
+	}
+	delete(p.priority, lowestID)
+	log.Debug("Priority client kicked out", "id", lowestID, "balance", p.balances[lowestID])
+	lowest.disconnectFn()
+	return true
+}
+
+// disconnect should be called when a connection is terminated. If the disconnection
+// was initiated by the pool itself using disconnectFn then calling disconnect is
+// not necessary but permitted.
+func (p *priorityClientPool) disconnect(id enode.ID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return
+	}
+	if client := p.free[id]; client != nil {
+		delete(p.free, id)
+		p.child.disconnect(client.address)
+		return
+	}
+	if p.priority[id] != nil {
+		delete(p.priority, id)
+		p.child.setConnectedLimit(p.connectedLimit - len(p.priority))
+		p.saveToDb()
+		log.Debug("Priority client disconnected", "id", id)
+	}
+}
+
+// charge deducts the serving time of a request from the balance of a connected
+// priority client, disconnecting it if the balance is depleted.
+func (p *priorityClientPool) charge(id enode.ID, cost uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	client := p.priority[id]
+	if client == nil {
+		return
+	}
+	if balance := p.balances[id]; balance > cost {
+		p.balances[id] = balance - cost
+		return
+	}
+	delete(p.balances, id)
+	delete(p.priority, id)
+	p.child.setConnectedLimit(p.connectedLimit - len(p.priority))
+	p.saveToDb()
+
+	log.Debug("Priority client balance depleted", "id", id)
+	client.disconnectFn()
+}
+
+// balance returns the current serving time balance of a client.
+func (p *priorityClientPool) balance(id enode.ID) uint64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.balances[id]
+}
+
+// addBalance changes the balance of a client by the given (possibly negative)
+// amount, returning the old and new balances. A connected free client gaining a
+// balance is upgraded to a priority one in place, while a priority client losing
+// all of its balance is disconnected.
+func (p *priorityClientPool) addBalance(id enode.ID, amount int64) (uint64, uint64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	old := p.balances[id]
+	switch {
+	case amount < 0 && uint64(-amount) > old:
+		return old, old, errNegativeBalance
+	case amount > 0 && uint64(amount) > math.MaxUint64-old:
+		return old, old, errBalanceOverflow
+	}
+	var balance uint64
+	if amount < 0 {
+		balance = old - uint64(-amount)
+	} else {
+		balance = old + uint64(amount)
+	}
+	if balance == 0 {
+		delete(p.balances, id)
+	} else {
+		p.balances[id] = balance
+	}
+	// Move the client between the free and priority pools if necessary
+	if client := p.free[id]; client != nil && balance > 0 {
+		delete(p.free, id)
+		p.child.disconnect(client.address)
+
+		p.priority[id] = client
+		p.child.setConnectedLimit(p.connectedLimit - len(p.priority))
+		log.Debug("Client upgraded to priority", "id", id, "balance", balance)
+	}
+	if client := p.priority[id]; client != nil && balance == 0 {
+		delete(p.priority, id)
+		p.child.setConnectedLimit(p.connectedLimit - len(p.priority))
+		client.disconnectFn()
+	}
+	p.saveToDb()
+	return old, balance, nil
+}
+
+// loadFromDb restores the client balances from the database storage
+// (automatically called at initialization)
+func (p *priorityClientPool) loadFromDb() {
+	enc, err := p.db.Get([]byte("priorityClientPool"))
+	if err != nil {
+		return
+	}
+	var list []priorityClientBalance
+	if err := rlp.DecodeBytes(enc, &list); err != nil {
+		log.Error("Failed to decode client balances", "err", err)
+		return
+	}
+	for _, entry := range list {
+		p.balances[entry.ID] = entry.Balance
+	}
+}
+
+// saveToDb saves the client balances to the database storage
+// (called at shutdown and whenever a balance is changed externally)
+func (p *priorityClientPool) saveToDb() {
+	list := make([]priorityClientBalance, 0, len(p.balances))
+	for id, balance := range p.balances {
+		list = append(list, priorityClientBalance{ID: id, Balance: balance})
+	}
+	enc, err := rlp.EncodeToBytes(list)
+	if err != nil {
+		log.Error("Failed to encode client balances", "err", err)
+	} else {
+		p.db.Put([]byte("priorityClientPool"), enc)
+	}
+}
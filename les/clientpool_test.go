@@ -0,0 +1,105 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestPriorityClientPool(t *testing.T) {
+	var (
+		clock   mclock.Simulated
+		db      = ethdb.NewMemDatabase()
+		pool    = newPriorityClientPool(db, 4, newFreeClientPool(db, 4, 10000, &clock))
+		kicked  = make(map[enode.ID]bool)
+		address = func(i int) string { return fmt.Sprintf("test peer #%d", i) }
+	)
+	id := func(i int) enode.ID {
+		return enode.ID{byte(i)}
+	}
+	connect := func(i int) bool {
+		return pool.connect(id(i), address(i), func() { kicked[id(i)] = true })
+	}
+	// Fill up the pool with free clients, and ensure new ones are rejected
+	for i := 0; i < 4; i++ {
+		if !connect(i) {
+			t.Fatalf("free client #%d rejected", i)
+		}
+	}
+	if connect(4) {
+		t.Fatalf("free client accepted over the connected limit")
+	}
+	// Give balances to some new clients and ensure they kick out the free ones
+	for i := 10; i < 14; i++ {
+		if _, _, err := pool.addBalance(id(i), int64(i*1000)); err != nil {
+			t.Fatalf("failed to add balance to client #%d: %v", i, err)
+		}
+		if !connect(i) {
+			t.Fatalf("priority client #%d rejected", i)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if !kicked[id(i)] {
+			t.Errorf("free client #%d not kicked out", i)
+		}
+		pool.disconnect(id(i))
+	}
+	// A client with a lower balance should be rejected, a higher one accepted
+	pool.addBalance(id(20), 5000)
+	if connect(20) {
+		t.Fatalf("priority client with lowest balance accepted")
+	}
+	pool.addBalance(id(21), 50000)
+	if !connect(21) {
+		t.Fatalf("priority client with highest balance rejected")
+	}
+	if !kicked[id(10)] {
+		t.Fatalf("priority client with lowest balance not kicked out")
+	}
+	pool.disconnect(id(10))
+
+	// Charge a client until its balance depletes and ensure it's disconnected
+	pool.charge(id(11), 10000)
+	if balance := pool.balance(id(11)); balance != 1000 {
+		t.Fatalf("balance mismatch after charge: have %d, want %d", balance, 1000)
+	}
+	pool.charge(id(11), 10000)
+	if !kicked[id(11)] {
+		t.Fatalf("depleted client not disconnected")
+	}
+	if balance := pool.balance(id(11)); balance != 0 {
+		t.Fatalf("depleted client balance mismatch: have %d, want 0", balance)
+	}
+	// Negative balances should be refused
+	if _, _, err := pool.addBalance(id(12), -20000); err != errNegativeBalance {
+		t.Fatalf("negative balance error mismatch: have %v, want %v", err, errNegativeBalance)
+	}
+	// Restart the pool and ensure balances are persisted
+	pool.stop()
+	pool = newPriorityClientPool(db, 4, newFreeClientPool(db, 4, 10000, &clock))
+	for i, want := range map[int]uint64{11: 0, 12: 12000, 13: 13000, 20: 5000, 21: 50000} {
+		if balance := pool.balance(id(i)); balance != want {
+			t.Errorf("client #%d: balance mismatch after restart: have %d, want %d", i, balance, want)
+		}
+	}
+	pool.stop()
+}
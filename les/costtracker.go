@@ -0,0 +1,318 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/les/flowcontrol"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// costUpdateInterval is the time between two recalculations of the flow
+	// control parameters from the measured request costs.
+	costUpdateInterval = time.Minute
+
+	// bufLimitRatio is the ratio between the buffer limit and the minimum recharge
+	// rate handed out to clients, i.e. the time in milliseconds it takes for an
+	// empty buffer to fully recharge.
+	bufLimitRatio = 6000
+
+	// benchmarkRounds is the number of times each benchmarked request type is
+	// served from the local database at startup, both as a single item and as a
+	// maximum sized request.
+	benchmarkRounds = 50
+)
+
+// requestInfo contains the name of a request type (used for metrics and RPC
+// reporting) and the maximum number of items a single request may contain.
+type requestInfo struct {
+	name     string
+	maxCount uint64
+}
+
+var requests = map[uint64]requestInfo{
+	GetBlockHeadersMsg:     {"GetBlockHeaders", MaxHeaderFetch},
+	GetBlockBodiesMsg:      {"GetBlockBodies", MaxBodyFetch},
+	GetCodeMsg:             {"GetCode", MaxCodeFetch},
+	GetReceiptsMsg:         {"GetReceipts", MaxReceiptFetch},
+	GetProofsV1Msg:         {"GetProofsV1", MaxProofsFetch},
+	SendTxMsg:              {"SendTx", MaxTxSend},
+	SendTxV2Msg:            {"SendTxV2", MaxTxSend},
+	GetTxStatusMsg:         {"GetTxStatus", MaxTxStatus},
+	GetHeaderProofsMsg:     {"GetHeaderProofs", MaxHelperTrieProofsFetch},
+	GetProofsV2Msg:         {"GetProofsV2", MaxProofsFetch},
+	GetHelperTrieProofsMsg: {"GetHelperTrieProofs", MaxHelperTrieProofsFetch},
+}
+
+// costTracker measures the real serving cost of each request type and derives
+// the flow control parameters handed out to clients from them. The total serving
+// capacity is shared equally between the allowed number of clients, while the
+// buffer limit is raised if needed so that even the most expensive permitted
+// request fits into it.
+//
+// The parameters are recalculated periodically and only apply to clients that
+// connect afterwards, as connected ones keep what they were told in the handshake.
+// The capacity of the client manager is adjusted at the same time: if serving
+// requests took longer than clients were charged for, the manager admits
+// proportionally less work.
+type costTracker struct {
+	stats     *requestCostStats
+	manager   *flowcontrol.ClientManager // Client manager whose capacity is adjusted, may be nil
+	lightServ uint64                     // Configured serving capacity in percent
+	capacity  uint64                     // Total serving capacity in nanoseconds of serving time per millisecond
+	maxPeers  int                        // Number of clients to share the capacity between
+
+	lock       sync.RWMutex
+	params     *flowcontrol.ServerParams // Flow control parameters handed out to new clients
+	costs      RequestCostList           // Request costs handed out to new clients
+	table      requestCostTable          // Request costs handed out to new clients, by message code
+	fcCapacity uint64                    // Current serving capacity of the client manager in percent
+
+	servedLock            sync.Mutex
+	realCost, chargedCost uint64 // Real and charged cost of the requests served since the last update
+
+	baseGauges, reqGauges map[uint64]metrics.Gauge
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newCostTracker creates a cost tracker from the request cost statistics stored
+// in the database, sharing the given percentage of a single CPU thread between
+// a number of clients. The capacity of the given client manager is adjusted to
+// the measured costs.
+func newCostTracker(db ethdb.Database, manager *flowcontrol.ClientManager, lightServ, maxPeers int) *costTracker {
+	if maxPeers < 1 {
+		maxPeers = 1
+	}
+	ct := &costTracker{
+		stats:      newCostStats(db),
+		manager:    manager,
+		lightServ:  uint64(lightServ),
+		capacity:   uint64(lightServ) * uint64(time.Millisecond) / 100,
+		maxPeers:   maxPeers,
+		baseGauges: make(map[uint64]metrics.Gauge),
+		reqGauges:  make(map[uint64]metrics.Gauge),
+		quit:       make(chan struct{}),
+	}
+	for code, req := range requests {
+		ct.baseGauges[code] = metrics.GetOrRegisterGauge("les/server/cost/"+req.name+"/base", nil)
+		ct.reqGauges[code] = metrics.GetOrRegisterGauge("les/server/cost/"+req.name+"/req", nil)
+	}
+	ct.update()
+	return ct
+}
+
+// start benchmarks the serving of requests from the local database and starts
+// periodically updating the flow control parameters afterwards.
+func (ct *costTracker) start(pm *ProtocolManager) {
+	ct.wg.Add(1)
+	go func() {
+		defer ct.wg.Done()
+
+		if ct.benchmark(pm) {
+			ct.update()
+		}
+		ticker := time.NewTicker(costUpdateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ct.update()
+			case <-ct.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop terminates the update loop and saves the measured costs to the database.
+func (ct *costTracker) stop() {
+	close(ct.quit)
+	ct.wg.Wait()
+	ct.stats.store()
+}
+
+// current returns the flow control parameters and request costs to be handed
+// out to a newly connected client.
+func (ct *costTracker) current() (*flowcontrol.ServerParams, RequestCostList) {
+	ct.lock.RLock()
+	defer ct.lock.RUnlock()
+
+	return ct.params, ct.costs
+}
+
+// served records the measured serving cost of a processed request.
+func (ct *costTracker) served(msgCode, reqCnt, cost uint64) {
+	ct.stats.update(msgCode, reqCnt, cost)
+
+	ct.lock.RLock()
+	var charged uint64
+	if c := ct.table[msgCode]; c != nil {
+		charged = c.baseCost + reqCnt*c.reqCost
+	}
+	ct.lock.RUnlock()
+
+	ct.servedLock.Lock()
+	ct.realCost += cost
+	ct.chargedCost += charged
+	ct.servedLock.Unlock()
+}
+
+// managerCapacity returns the serving capacity of the client manager for the
+// requests served since the last update, and resets the served request totals.
+// The configured capacity is scaled down by the ratio of the charged and the real
+// serving cost if serving took longer than clients were charged for.
+func (ct *costTracker) managerCapacity() uint64 {
+	ct.servedLock.Lock()
+	realCost, charged := ct.realCost, ct.chargedCost
+	ct.realCost, ct.chargedCost = 0, 0
+	ct.servedLock.Unlock()
+
+	capacity := ct.lightServ
+	if realCost > charged {
+		capacity = uint64(float64(capacity) * float64(charged) / float64(realCost))
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	return capacity
+}
+
+// update recalculates the flow control parameters from the current request cost
+// statistics.
+func (ct *costTracker) update() {
+	costs := ct.stats.getCurrentList()
+
+	minRecharge := ct.capacity / uint64(ct.maxPeers)
+	if minRecharge == 0 {
+		minRecharge = 1
+	}
+	bufLimit := minRecharge * bufLimitRatio
+	for _, c := range costs {
+		if cost := c.BaseCost + requests[c.MsgCode].maxCount*c.ReqCost; cost > bufLimit {
+			bufLimit = cost
+		}
+		ct.baseGauges[c.MsgCode].Update(int64(c.BaseCost))
+		ct.reqGauges[c.MsgCode].Update(int64(c.ReqCost))
+	}
+	serverCapacityGauge.Update(int64(ct.capacity))
+	serverBufLimitGauge.Update(int64(bufLimit))
+	serverMinRechargeGauge.Update(int64(minRecharge))
+
+	fcCapacity := ct.managerCapacity()
+	if ct.manager != nil {
+		ct.manager.SetCapacity(fcCapacity)
+	}
+
+	ct.lock.Lock()
+	ct.params = &flowcontrol.ServerParams{BufLimit: bufLimit, MinRecharge: minRecharge}
+	ct.costs = costs
+	ct.table = costs.decode()
+	ct.fcCapacity = fcCapacity
+	ct.lock.Unlock()
+
+	log.Debug("Updated flow control parameters", "buflimit", bufLimit, "minrecharge", minRecharge, "capacity", fcCapacity)
+}
+
+// benchmark measures the serving time of the request types answered purely from
+// the local chain database, feeding the results into the cost statistics. The
+// remaining request types are only measured while serving real clients. It
+// returns false if the benchmark was interrupted.
+func (ct *costTracker) benchmark(pm *ProtocolManager) bool {
+	var (
+		db     = pm.chainDb
+		head   = pm.blockchain.CurrentHeader()
+		random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	)
+	// canonical returns the hash and number of a random canonical block
+	canonical := func() (common.Hash, uint64) {
+		number := uint64(random.Int63n(head.Number.Int64() + 1))
+		return rawdb.ReadCanonicalHash(db, number), number
+	}
+	benchmarks := map[uint64]func(count uint64){
+		GetBlockHeadersMsg: func(count uint64) {
+			first := uint64(random.Int63n(head.Number.Int64() + 1))
+			for n := first; n < first+count && n <= head.Number.Uint64(); n++ {
+				rawdb.ReadHeaderRLP(db, rawdb.ReadCanonicalHash(db, n), n)
+			}
+		},
+		GetBlockBodiesMsg: func(count uint64) {
+			for i := uint64(0); i < count; i++ {
+				hash, number := canonical()
+				rawdb.ReadBodyRLP(db, hash, number)
+			}
+		},
+		GetReceiptsMsg: func(count uint64) {
+			for i := uint64(0); i < count; i++ {
+				hash, number := canonical()
+				rlp.EncodeToBytes(rawdb.ReadReceipts(db, hash, number))
+			}
+		},
+		GetProofsV2Msg: func(count uint64) {
+			statedb, err := pm.blockchain.State()
+			if err != nil {
+				return
+			}
+			trie, err := statedb.Database().OpenTrie(head.Root)
+			if err != nil {
+				return
+			}
+			nodes := light.NewNodeSet()
+			for i := uint64(0); i < count; i++ {
+				key := make([]byte, 32)
+				random.Read(key)
+				trie.Prove(crypto.Keccak256(key), 0, nodes)
+			}
+		},
+	}
+	start := mclock.Now()
+	for i := 0; i < benchmarkRounds; i++ {
+		for code, serve := range benchmarks {
+			for _, count := range []uint64{1, requests[code].maxCount} {
+				select {
+				case <-ct.quit:
+					return false
+				default:
+				}
+				t := mclock.Now()
+				serve(count)
+				cost := uint64(mclock.Now() - t)
+
+				ct.stats.update(code, count, cost)
+				if code == GetProofsV2Msg {
+					ct.stats.update(GetProofsV1Msg, count, cost)
+				}
+			}
+		}
+	}
+	log.Info("Benchmarked request serving costs", "elapsed", common.PrettyDuration(mclock.Now()-start))
+	return true
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/les/flowcontrol"
+)
+
+// feedCosts records serving costs which grow linearly with the request size.
+func feedCosts(ct *costTracker, code uint64) {
+	for i := 0; i < 200; i++ {
+		ct.stats.update(code, 1, 2000)
+		ct.stats.update(code, 100, 101000)
+	}
+}
+
+func TestCostTrackerParams(t *testing.T) {
+	ct := newCostTracker(nil, nil, 50, 10)
+	feedCosts(ct, GetBlockHeadersMsg)
+	ct.update()
+
+	params, costs := ct.current()
+	wantRecharge := uint64(50) * uint64(time.Millisecond) / 100 / 10
+	if params.MinRecharge != wantRecharge {
+		t.Errorf("wrong min recharge %d, want %d", params.MinRecharge, wantRecharge)
+	}
+	if params.BufLimit < params.MinRecharge*bufLimitRatio {
+		t.Errorf("buffer limit %d below %d", params.BufLimit, params.MinRecharge*bufLimitRatio)
+	}
+	if len(costs) != len(reqList) {
+		t.Fatalf("wrong number of request costs %d, want %d", len(costs), len(reqList))
+	}
+	for _, c := range costs {
+		if max := c.BaseCost + requests[c.MsgCode].maxCount*c.ReqCost; max > params.BufLimit {
+			t.Errorf("maximum cost %d of request %d exceeds buffer limit %d", max, c.MsgCode, params.BufLimit)
+		}
+		if c.MsgCode == GetBlockHeadersMsg && (c.BaseCost == 0 || c.ReqCost == 0) {
+			t.Errorf("zero cost of measured request: %+v", c)
+		}
+	}
+}
+
+func TestCostTrackerStore(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	ct := newCostTracker(db, nil, 50, 10)
+	feedCosts(ct, GetReceiptsMsg)
+	ct.stats.store()
+
+	want := ct.stats.getCurrentList()
+	if got := newCostTracker(db, nil, 50, 10).stats.getCurrentList(); !costListEqual(got, want) {
+		t.Errorf("stored costs not loaded:\n got %v\nwant %v", got, want)
+	}
+}
+
+func costListEqual(a, b RequestCostList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCostTrackerManagerCapacity(t *testing.T) {
+	cm := flowcontrol.NewClientManager(50, 10, 1000000000)
+	defer cm.Stop()
+	ct := newCostTracker(nil, cm, 50, 10)
+	feedCosts(ct, GetBlockHeadersMsg)
+	ct.update()
+
+	ct.lock.RLock()
+	c := ct.table[GetBlockHeadersMsg]
+	ct.lock.RUnlock()
+	charged := c.baseCost + 10*c.reqCost
+
+	tests := []struct {
+		real uint64 // real cost of a request charged with the cost above
+		want uint64
+	}{
+		{real: charged / 2, want: 50},
+		{real: charged, want: 50},
+		{real: charged * 4, want: 12},
+		{real: charged * 1000, want: 1},
+	}
+	for _, test := range tests {
+		ct.served(GetBlockHeadersMsg, 10, test.real)
+		ct.update()
+		if ct.fcCapacity != test.want {
+			t.Errorf("real cost %d, charged %d: capacity %d, want %d", test.real, charged, ct.fcCapacity, test.want)
+		}
+		// Update the charged cost, as serving changes the statistics.
+		ct.lock.RLock()
+		c = ct.table[GetBlockHeadersMsg]
+		ct.lock.RUnlock()
+		charged = c.baseCost + 10*c.reqCost
+	}
+
+	// Without requests served, the configured capacity applies.
+	ct.update()
+	if ct.fcCapacity != 50 {
+		t.Errorf("capacity %d without requests, want 50", ct.fcCapacity)
+	}
+}
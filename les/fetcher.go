@@ -92,6 +92,7 @@ type fetcherTreeNode struct {
 // fetchRequest represents a header download request
 type fetchRequest struct {
 	hash    common.Hash
+	td      *big.Int
 	amount  uint64
 	peer    *peer
 	sent    mclock.AbsTime
@@ -182,7 +183,7 @@ func (f *lightFetcher) syncLoop() {
 			if ok {
 				f.pm.serverPool.adjustResponseTime(req.peer.poolEntry, time.Duration(mclock.Now()-req.sent), true)
 				req.peer.Log().Debug("Fetching data timed out hard")
-				go f.pm.removePeer(req.peer.id)
+				go f.pm.dropPeer(req.peer.id)
 			}
 		case resp := <-f.deliverChn:
 			f.reqMu.Lock()
@@ -200,7 +201,7 @@ func (f *lightFetcher) syncLoop() {
 			f.lock.Lock()
 			if !ok || !(f.syncing || f.processResponse(req, resp)) {
 				resp.peer.Log().Debug("Failed processing response")
-				go f.pm.removePeer(resp.peer.id)
+				go f.pm.dropPeer(resp.peer.id)
 			}
 			f.lock.Unlock()
 		case p := <-f.syncDone:
@@ -253,11 +254,16 @@ func (f *lightFetcher) announce(p *peer, head *announceData) {
 		p.Log().Debug("Announcement from unknown peer")
 		return
 	}
+	if f.pm.ulc != nil && !p.trusted {
+		// ultra-light clients only follow the heads of their trusted servers
+		p.Log().Debug("Ignoring announcement from untrusted server")
+		return
+	}
 
 	if fp.lastAnnounced != nil && head.Td.Cmp(fp.lastAnnounced.td) <= 0 {
 		// announced tds should be strictly monotonic
 		p.Log().Debug("Received non-monotonic td", "current", head.Td, "previous", fp.lastAnnounced.td)
-		go f.pm.removePeer(p.id)
+		go f.pm.dropPeer(p.id)
 		return
 	}
 
@@ -383,6 +389,10 @@ func (f *lightFetcher) peerHasBlock(p *peer, hash common.Hash, number uint64, ha
 // requestAmount calculates the amount of headers to be downloaded starting
 // from a certain head backwards
 func (f *lightFetcher) requestAmount(p *peer, n *fetcherTreeNode) uint64 {
+	if f.pm.ulc != nil {
+		// ultra-light clients only ever download the announced head itself
+		return 1
+	}
 	amount := uint64(0)
 	nn := n
 	for nn != nil && !f.checkKnownNode(p, nn) {
@@ -415,13 +425,16 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 
 	for p, fp := range f.peers {
 		for hash, n := range fp.nodeByHash {
+			if f.pm.ulc != nil && !f.trustedAgreed(hash, n.td) {
+				continue
+			}
 			if !f.checkKnownNode(p, n) && !n.requested && (bestTd == nil || n.td.Cmp(bestTd) >= 0) {
 				amount := f.requestAmount(p, n)
 				if bestTd == nil || n.td.Cmp(bestTd) > 0 || amount < bestAmount {
 					bestHash = hash
 					bestAmount = amount
 					bestTd = n.td
-					bestSyncing = fp.bestConfirmed == nil || fp.root == nil || !f.checkKnownNode(p, fp.root)
+					bestSyncing = f.pm.ulc == nil && (fp.bestConfirmed == nil || fp.root == nil || !f.checkKnownNode(p, fp.root))
 				}
 			}
 		}
@@ -490,7 +503,7 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 				cost := p.GetRequestCost(GetBlockHeadersMsg, int(bestAmount))
 				p.fcServer.QueueRequest(reqID, cost)
 				f.reqMu.Lock()
-				f.requested[reqID] = fetchRequest{hash: bestHash, amount: bestAmount, peer: p, sent: mclock.Now()}
+				f.requested[reqID] = fetchRequest{hash: bestHash, td: bestTd, amount: bestAmount, peer: p, sent: mclock.Now()}
 				f.reqMu.Unlock()
 				go func() {
 					time.Sleep(hardRequestTimeout)
@@ -503,6 +516,21 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 	return rq, reqID
 }
 
+// trustedAgreed returns whether enough trusted servers have announced the given
+// head with the same total difficulty for an ultra-light client to accept it.
+func (f *lightFetcher) trustedAgreed(hash common.Hash, td *big.Int) bool {
+	var count int
+	for p, fp := range f.peers {
+		if !p.trusted {
+			continue
+		}
+		if n := fp.nodeByHash[hash]; n != nil && n.td != nil && n.td.Cmp(td) == 0 {
+			count++
+		}
+	}
+	return f.pm.ulc.agreed(count)
+}
+
 // deliverHeaders delivers header download request responses for processing
 func (f *lightFetcher) deliverHeaders(peer *peer, reqID uint64, headers []*types.Header) {
 	f.deliverChn <- fetchResponse{reqID: reqID, headers: headers, peer: peer}
@@ -518,6 +546,16 @@ func (f *lightFetcher) processResponse(req fetchRequest, resp fetchResponse) boo
 	for i, header := range resp.headers {
 		headers[int(req.amount)-1-i] = header
 	}
+	if f.pm.ulc != nil {
+		// The hash and td of the head were agreed upon by enough trusted servers,
+		// accept them without downloading the ancestors
+		if err := f.chain.InsertTrustedHeader(headers[0], req.td); err != nil {
+			log.Debug("Failed to insert trusted header", "err", err)
+			return false
+		}
+		f.newHeaders(headers, []*big.Int{req.td})
+		return true
+	}
 	if _, err := f.chain.InsertHeaderChain(headers, 1); err != nil {
 		if err == consensus.ErrFutureBlock {
 			return true
@@ -545,7 +583,7 @@ func (f *lightFetcher) newHeaders(headers []*types.Header, tds []*big.Int) {
 	for p, fp := range f.peers {
 		if !f.checkAnnouncedHeaders(fp, headers, tds) {
 			p.Log().Debug("Inconsistent announcement")
-			go f.pm.removePeer(p.id)
+			go f.pm.dropPeer(p.id)
 		}
 		if fp.confirmedTd != nil && (maxTd == nil || maxTd.Cmp(fp.confirmedTd) > 0) {
 			maxTd = fp.confirmedTd
@@ -580,6 +618,10 @@ func (f *lightFetcher) checkAnnouncedHeaders(fp *fetcherPeerInfo, headers []*typ
 			td = f.chain.GetTd(hash, number)
 			header = f.chain.GetHeader(hash, number)
 			if header == nil || td == nil {
+				if f.pm.ulc != nil {
+					// ultra-light clients don't have the ancestors of trusted heads
+					return true
+				}
 				log.Error("Missing parent of validated header", "hash", hash, "number", number)
 				return false
 			}
@@ -649,7 +691,7 @@ func (f *lightFetcher) checkSyncedHeaders(p *peer) {
 	// now n is the latest downloaded header after syncing
 	if n == nil {
 		p.Log().Debug("Synchronisation failed")
-		go f.pm.removePeer(p.id)
+		go f.pm.dropPeer(p.id)
 	} else {
 		header := f.chain.GetHeader(n.hash, n.number)
 		f.newHeaders([]*types.Header{header}, []*big.Int{td})
@@ -680,7 +722,7 @@ func (f *lightFetcher) checkKnownNode(p *peer, n *fetcherTreeNode) bool {
 	}
 	if !f.checkAnnouncedHeaders(fp, []*types.Header{header}, []*big.Int{td}) {
 		p.Log().Debug("Inconsistent announcement")
-		go f.pm.removePeer(p.id)
+		go f.pm.dropPeer(p.id)
 	}
 	if fp.confirmedTd != nil {
 		f.updateMaxConfirmedTd(fp.confirmedTd)
@@ -81,7 +81,7 @@ func NewClientManager(rcTarget, maxSimReq, maxRcSum uint64) *ClientManager {
 	cm := &ClientManager{
 		nodes:       make(map[*cmNode]struct{}),
 		resumeQueue: make(chan chan bool),
-		rcRecharge:  rcConst * rcConst / (100*rcConst/rcTarget - rcConst),
+		rcRecharge:  rechargeRate(rcTarget),
 		maxSimReq:   maxSimReq,
 		maxRcSum:    maxRcSum,
 	}
@@ -89,6 +89,33 @@ func NewClientManager(rcTarget, maxSimReq, maxRcSum uint64) *ClientManager {
 	return cm
 }
 
+// rechargeRate converts a serving capacity, the percentage of time spent serving
+// requests, to the recharge rate of the client buffers.
+func rechargeRate(rcTarget uint64) uint64 {
+	if rcTarget < 1 {
+		rcTarget = 1
+	}
+	if rcTarget > 99 {
+		rcTarget = 99
+	}
+	return rcConst * rcConst / (100*rcConst/rcTarget - rcConst)
+}
+
+// SetCapacity changes the serving capacity of the manager. The new recharge rate
+// applies to recharging client buffers immediately.
+func (self *ClientManager) SetCapacity(rcTarget uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.update(mclock.Now())
+	self.rcRecharge = rechargeRate(rcTarget)
+	for node := range self.nodes {
+		if node.recharging {
+			node.set(node.serving, self.simReqCnt, self.sumWeight)
+		}
+	}
+}
+
 func (self *ClientManager) Stop() {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -96,6 +96,10 @@ func (f *freeClientPool) connect(address string, disconnectFn func()) bool {
 	if f.closed {
 		return false
 	}
+	if f.connectedLimit == 0 {
+		log.Debug("Client rejected", "address", address)
+		return false
+	}
 	e := f.addressMap[address]
 	now := f.clock.Now()
 	var recentUsage int64
@@ -151,7 +155,7 @@ func (f *freeClientPool) disconnect(address string) {
 	}
 	e := f.addressMap[address]
 	now := f.clock.Now()
-	if !e.connected {
+	if e == nil || !e.connected {
 		log.Debug("Client already disconnected", "address", address)
 		return
 	}
@@ -163,6 +167,24 @@ func (f *freeClientPool) disconnect(address string) {
 	log.Debug("Client disconnected", "address", address)
 }
 
+// setConnectedLimit changes the number of clients allowed to be connected at the
+// same time, kicking out the ones with the highest recent usage if necessary.
+func (f *freeClientPool) setConnectedLimit(limit int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.connectedLimit = limit
+	now := f.clock.Now()
+	for f.connPool.Size() > f.connectedLimit {
+		i := f.connPool.PopItem().(*freeClientPoolEntry)
+		f.calcLogUsage(i, now)
+		i.connected = false
+		f.disconnPool.Push(i, -i.logUsage)
+		log.Debug("Client kicked out", "address", i.address)
+		i.disconnectFn()
+	}
+}
+
 // logOffset calculates the time-dependent offset for the logarithmic
 // representation of recent usage
 func (f *freeClientPool) logOffset(now mclock.AbsTime) int64 {
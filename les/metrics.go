@@ -62,6 +62,10 @@ var (
 	miscInTrafficMeter  = metrics.NewRegisteredMeter("les/misc/in/traffic", nil)
 	miscOutPacketsMeter = metrics.NewRegisteredMeter("les/misc/out/packets", nil)
 	miscOutTrafficMeter = metrics.NewRegisteredMeter("les/misc/out/traffic", nil)
+
+	serverCapacityGauge    = metrics.NewRegisteredGauge("les/server/capacity", nil)
+	serverBufLimitGauge    = metrics.NewRegisteredGauge("les/server/bufLimit", nil)
+	serverMinRechargeGauge = metrics.NewRegisteredGauge("les/server/minRecharge", nil)
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/les/flowcontrol"
 	"github.com/ethereum/go-ethereum/light"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -69,11 +70,17 @@ type peer struct {
 	poolEntry      *poolEntry
 	hasBlock       func(common.Hash, uint64, bool) bool
 	responseErrors int
+	trusted        bool // whether the peer is a trusted server of an ultra-light client
 
 	fcClient       *flowcontrol.ClientNode // nil if the peer is server only
 	fcServer       *flowcontrol.ServerNode // nil if the peer is client only
 	fcServerParams *flowcontrol.ServerParams
 	fcCosts        requestCostTable
+
+	// Latest checkpoint registered in the oracle as advertised by the server,
+	// along with the admin votes it was registered with
+	checkpoint           params.TrustedCheckpoint
+	checkpointSignatures [][]byte
 }
 
 func newPeer(version int, network uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -405,13 +412,25 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		send = send.add("serveChainSince", uint64(0))
 		send = send.add("serveStateSince", uint64(0))
 		send = send.add("txRelay", nil)
-		send = send.add("flowControl/BL", server.defParams.BufLimit)
-		send = send.add("flowControl/MRR", server.defParams.MinRecharge)
-		list := server.fcCostStats.getCurrentList()
+		params, list := server.costTracker.current()
+		send = send.add("flowControl/BL", params.BufLimit)
+		send = send.add("flowControl/MRR", params.MinRecharge)
 		send = send.add("flowControl/MRC", list)
-		p.fcCosts = list.decode()
+		p.fcServerParams, p.fcCosts = params, list.decode()
+
+		// Advertise the latest registered checkpoint if there's one
+		if oracle := server.protocolManager.oracle; oracle != nil {
+			if checkpoint, sigs := oracle.stableCheckpoint(); checkpoint != nil {
+				send = send.add("checkpoint/value", checkpoint)
+				send = send.add("checkpoint/signatures", sigs)
+			}
+		}
 	} else {
-		p.requestAnnounceType = announceTypeSimple // set to default until "very light" client mode is implemented
+		// Ultra-light clients need signed announcements from their trusted servers
+		p.requestAnnounceType = announceTypeSimple
+		if p.trusted {
+			p.requestAnnounceType = announceTypeSigned
+		}
 		send = send.add("announceType", p.requestAnnounceType)
 	}
 	recvList, err := p.sendReceiveHandshake(send)
@@ -460,7 +479,7 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		if recv.get("announceType", &p.announceType) != nil {
 			p.announceType = announceTypeSimple
 		}
-		p.fcClient = flowcontrol.NewClientNode(server.fcManager, server.defParams)
+		p.fcClient = flowcontrol.NewClientNode(server.fcManager, p.fcServerParams)
 	} else {
 		if recv.get("serveChainSince", nil) != nil {
 			return errResp(ErrUselessPeer, "peer cannot serve chain")
@@ -485,6 +504,11 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		p.fcServerParams = params
 		p.fcServer = flowcontrol.NewServerNode(params)
 		p.fcCosts = MRC.decode()
+
+		// The registered checkpoint is optional, servers without oracle don't send it
+		if recv.get("checkpoint/value", &p.checkpoint) == nil {
+			recv.get("checkpoint/signatures", &p.checkpointSignatures)
+		}
 	}
 
 	p.headInfo = &announceData{Td: rTd, Hash: rHash, Number: rNum}
@@ -23,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 // syncer is responsible for periodically synchronising with the network, both
@@ -72,8 +73,35 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 
+	// Jump to a newer checkpoint registered in the oracle if the peer advertised one
+	if pm.oracle != nil {
+		pm.updateCheckpoint(peer)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	pm.blockchain.(*light.LightChain).SyncCht(ctx)
 	pm.downloader.Synchronise(peer.id, peer.Head(), peer.Td(), downloader.LightSync)
 }
+
+// updateCheckpoint adds the checkpoint registered in the oracle as advertised by
+// the given peer to the trusted ones, if it is newer than the local checkpoint
+// and enough oracle admins voted for it.
+func (pm *ProtocolManager) updateCheckpoint(peer *peer) {
+	checkpoint := peer.checkpoint
+	if checkpoint.Empty() {
+		return
+	}
+	chain := pm.blockchain.(*light.LightChain)
+	if indexer := chain.Odr().ChtIndexer(); indexer != nil {
+		if sections, _, _ := indexer.Sections(); checkpoint.SectionIndex < sections {
+			return
+		}
+	}
+	valid, signers := pm.oracle.verifySigners(checkpoint.SectionIndex, checkpoint.Hash(), peer.checkpointSignatures)
+	if !valid {
+		log.Debug("Rejected advertised checkpoint", "peer", peer.id, "section", checkpoint.SectionIndex, "signers", len(signers))
+		return
+	}
+	log.Info("Verified advertised checkpoint", "peer", peer.id, "section", checkpoint.SectionIndex, "signers", len(signers))
+	chain.AddTrustedCheckpoint(&checkpoint)
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+var errNoTrustedServers = errors.New("no trusted servers specified for ultra-light client")
+
+// ulc contains the configuration of the ultra-light client mode, in which the
+// client doesn't download and verify the header chain, but accepts new heads if
+// enough of its trusted servers sign an announcement of them.
+type ulc struct {
+	servers            []*enode.Node         // Trusted servers to keep connected to
+	trusted            map[enode.ID]struct{} // Node IDs of the trusted servers
+	minTrustedFraction int                   // Minimum percentage of trusted servers to agree on a head
+}
+
+// newULC creates the ultra-light client configuration from the user settings,
+// returning nil if the mode is not enabled.
+func newULC(config *eth.ULCConfig) (*ulc, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if len(config.TrustedServers) == 0 {
+		return nil, errNoTrustedServers
+	}
+	if config.MinTrustedFraction <= 0 || config.MinTrustedFraction > 100 {
+		return nil, fmt.Errorf("invalid minimum trusted fraction %d%%, must be within (0, 100]", config.MinTrustedFraction)
+	}
+	u := &ulc{
+		trusted:            make(map[enode.ID]struct{}),
+		minTrustedFraction: config.MinTrustedFraction,
+	}
+	for _, url := range config.TrustedServers {
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted server %q: %v", url, err)
+		}
+		if _, ok := u.trusted[node.ID()]; !ok {
+			u.servers = append(u.servers, node)
+			u.trusted[node.ID()] = struct{}{}
+		}
+	}
+	return u, nil
+}
+
+// isTrusted returns whether the given node is one of the trusted servers.
+func (u *ulc) isTrusted(id enode.ID) bool {
+	_, ok := u.trusted[id]
+	return ok
+}
+
+// agreed returns whether the given number of trusted servers is enough to accept
+// a head announced by them.
+func (u *ulc) agreed(count int) bool {
+	return count*100 >= u.minTrustedFraction*len(u.trusted)
+}
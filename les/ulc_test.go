@@ -0,0 +1,148 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"crypto/rand"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestULCConfig(t *testing.T) {
+	var (
+		urls []string
+		ids  []enode.ID
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		node := enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303+i, 30303+i)
+		urls, ids = append(urls, node.String()), append(ids, node.ID())
+	}
+	// Invalid configurations should be rejected, a missing one disables the mode
+	if u, err := newULC(nil); u != nil || err != nil {
+		t.Fatalf("missing config: have %v/%v, want nil/nil", u, err)
+	}
+	for i, config := range []*eth.ULCConfig{
+		{MinTrustedFraction: 50},
+		{TrustedServers: urls, MinTrustedFraction: 0},
+		{TrustedServers: urls, MinTrustedFraction: 101},
+		{TrustedServers: []string{"invalid"}, MinTrustedFraction: 50},
+	} {
+		if _, err := newULC(config); err == nil {
+			t.Errorf("config %d: invalid config accepted", i)
+		}
+	}
+	// Ensure duplicate servers are ignored and agreement is counted correctly
+	u, err := newULC(&eth.ULCConfig{TrustedServers: append(urls, urls[0]), MinTrustedFraction: 75})
+	if err != nil {
+		t.Fatalf("failed to create ultra-light config: %v", err)
+	}
+	if len(u.servers) != len(urls) {
+		t.Fatalf("trusted server count mismatch: have %d, want %d", len(u.servers), len(urls))
+	}
+	for i, id := range ids {
+		if !u.isTrusted(id) {
+			t.Errorf("server %d not trusted", i)
+		}
+	}
+	if u.isTrusted(enode.ID{}) {
+		t.Errorf("unknown server trusted")
+	}
+	for count, want := range []bool{false, false, false, true, true} {
+		if have := u.agreed(count); have != want {
+			t.Errorf("%d/4 servers: agreement mismatch: have %v, want %v", count, have, want)
+		}
+	}
+}
+
+// Tests that an ultra-light client only requests heads whose hash and td were
+// announced by enough trusted servers, ignoring untrusted announcements.
+func TestULCAnnounceTd(t *testing.T) {
+	var urls []string
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateKey()
+		urls = append(urls, enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303+i, 30303+i).String())
+	}
+	u, err := newULC(&eth.ULCConfig{TrustedServers: urls, MinTrustedFraction: 100})
+	if err != nil {
+		t.Fatalf("failed to create ultra-light config: %v", err)
+	}
+	db := ethdb.NewMemDatabase()
+	(&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := light.NewLightChain(NewLesOdr(db, light.TestClientIndexerConfig, nil), params.TestChainConfig, ethash.NewFaker())
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	f := &lightFetcher{
+		pm:             &ProtocolManager{ulc: u},
+		chain:          chain,
+		peers:          make(map[*peer]*fetcherPeerInfo),
+		requested:      make(map[uint64]fetchRequest),
+		requestChn:     make(chan bool, 100),
+		maxConfirmedTd: big.NewInt(0),
+	}
+	newPeer := func(trusted bool) *peer {
+		var id enode.ID
+		rand.Read(id[:])
+		p := &peer{Peer: p2p.NewPeer(id, "peer", nil), id: id.String(), trusted: trusted}
+		f.peers[p] = &fetcherPeerInfo{nodeByHash: make(map[common.Hash]*fetcherTreeNode)}
+		return p
+	}
+	var (
+		trusted1  = newPeer(true)
+		trusted2  = newPeer(true)
+		untrusted = newPeer(false)
+		hash      = common.Hash{1}
+	)
+	// An untrusted server re-announcing the head with a larger td is ignored
+	f.announce(trusted1, &announceData{Hash: hash, Number: 1, Td: big.NewInt(10)})
+	f.announce(untrusted, &announceData{Hash: hash, Number: 1, Td: big.NewInt(100)})
+	if fp := f.peers[untrusted]; fp.lastAnnounced != nil || len(fp.nodeByHash) != 0 {
+		t.Fatalf("untrusted announcement recorded")
+	}
+	if rq, _ := f.nextRequest(); rq != nil {
+		t.Fatalf("head requested without trusted agreement")
+	}
+	// Trusted servers announcing the same hash with different tds don't agree
+	f.announce(trusted2, &announceData{Hash: hash, Number: 1, Td: big.NewInt(9)})
+	if rq, _ := f.nextRequest(); rq != nil {
+		t.Fatalf("head requested with mismatching trusted tds")
+	}
+	// Once the trusted servers agree on both hash and td, the head is requested
+	f.announce(trusted2, &announceData{Hash: hash, Number: 1, Td: big.NewInt(10)})
+	if !f.trustedAgreed(hash, big.NewInt(10)) {
+		t.Fatalf("trusted announcements not agreed")
+	}
+	if f.trustedAgreed(hash, big.NewInt(100)) {
+		t.Fatalf("untrusted td agreed")
+	}
+	if rq, _ := f.nextRequest(); rq == nil {
+		t.Fatalf("agreed head not requested")
+	}
+}
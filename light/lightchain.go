@@ -101,7 +101,7 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 		return nil, core.ErrNoGenesis
 	}
 	if cp, ok := trustedCheckpoints[bc.genesisBlock.Hash()]; ok {
-		bc.addTrustedCheckpoint(cp)
+		bc.AddTrustedCheckpoint(cp)
 	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
@@ -117,8 +117,8 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	return bc, nil
 }
 
-// addTrustedCheckpoint adds a trusted checkpoint to the blockchain
-func (self *LightChain) addTrustedCheckpoint(cp *params.TrustedCheckpoint) {
+// AddTrustedCheckpoint adds a trusted checkpoint to the blockchain
+func (self *LightChain) AddTrustedCheckpoint(cp *params.TrustedCheckpoint) {
 	if self.odr.ChtIndexer() != nil {
 		StoreChtRoot(self.chainDb, cp.SectionIndex, cp.SectionHead, cp.CHTRoot)
 		self.odr.ChtIndexer().AddCheckpoint(cp.SectionIndex, cp.SectionHead)
@@ -393,6 +393,56 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	return i, err
 }
 
+// InsertTrustedHeader writes a header vouched for by trusted servers into the
+// local chain together with its total difficulty, making it the new head if its
+// difficulty is higher than the current one. Neither the header nor its ancestry
+// is verified, so this should only be used by ultra-light clients.
+func (self *LightChain) InsertTrustedHeader(header *types.Header, td *big.Int) error {
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	self.wg.Add(1)
+	defer self.wg.Done()
+
+	self.mu.Lock()
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+		head   = self.hc.CurrentHeader()
+		headTd = self.GetTd(head.Hash(), head.Number.Uint64())
+		batch  = self.chainDb.NewBatch()
+	)
+	rawdb.WriteHeader(batch, header)
+	rawdb.WriteTd(batch, hash, number, td)
+
+	canon := headTd == nil || td.Cmp(headTd) > 0
+	if canon {
+		// Drop any canonical hashes above the new head, the chain in between is
+		// unknown anyway
+		for n := number + 1; n <= head.Number.Uint64(); n++ {
+			rawdb.DeleteCanonicalHash(batch, n)
+		}
+		rawdb.WriteCanonicalHash(batch, hash, number)
+	}
+	if err := batch.Write(); err != nil {
+		self.mu.Unlock()
+		return err
+	}
+	var events []interface{}
+	if canon {
+		self.hc.SetCurrentHeader(header)
+		log.Debug("Inserted new trusted header", "number", number, "hash", hash)
+		events = append(events, core.ChainEvent{Block: types.NewBlockWithHeader(header), Hash: hash})
+	} else {
+		log.Debug("Inserted forked trusted header", "number", number, "hash", hash)
+		events = append(events, core.ChainSideEvent{Block: types.NewBlockWithHeader(header)})
+	}
+	self.mu.Unlock()
+
+	self.postChainEvents(events)
+	return nil
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (self *LightChain) CurrentHeader() *types.Header {
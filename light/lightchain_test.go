@@ -352,3 +352,38 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// Tests that trusted headers can be inserted without their ancestors, becoming
+// the new head only if their total difficulty is higher than the current one.
+func TestInsertTrustedHeader(t *testing.T) {
+	db, lightchain, err := newCanonical(10)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer lightchain.Stop()
+
+	head := lightchain.CurrentHeader()
+	headTd := lightchain.GetTd(head.Hash(), head.Number.Uint64())
+
+	trusted := &types.Header{Number: big.NewInt(100), ParentHash: common.Hash{0x01}, Difficulty: big.NewInt(1)}
+	if err := lightchain.InsertTrustedHeader(trusted, new(big.Int).Add(headTd, big.NewInt(1000))); err != nil {
+		t.Fatalf("failed to insert trusted header: %v", err)
+	}
+	if hash := lightchain.CurrentHeader().Hash(); hash != trusted.Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", hash, trusted.Hash())
+	}
+	if hash := rawdb.ReadCanonicalHash(db, 100); hash != trusted.Hash() {
+		t.Fatalf("canonical hash mismatch: have %x, want %x", hash, trusted.Hash())
+	}
+	// Insert a lower difficulty header and ensure the head stays in place
+	side := &types.Header{Number: big.NewInt(101), ParentHash: common.Hash{0x02}, Difficulty: big.NewInt(1)}
+	if err := lightchain.InsertTrustedHeader(side, headTd); err != nil {
+		t.Fatalf("failed to insert side header: %v", err)
+	}
+	if hash := lightchain.CurrentHeader().Hash(); hash != trusted.Hash() {
+		t.Fatalf("head changed by lower difficulty header: have %x, want %x", hash, trusted.Hash())
+	}
+	if header := lightchain.GetHeaderByHash(side.Hash()); header == nil {
+		t.Fatalf("side header not stored")
+	}
+}
@@ -71,6 +71,15 @@ type NodeConfig struct {
 	// It has the form "nodename:secret@host:port"
 	EthereumNetStats string
 
+	// UltraLightServers is the list of trusted LES servers to run the ultra-light
+	// client mode with. If set, new chain heads are accepted without downloading
+	// the header chain when enough of these servers sign them.
+	UltraLightServers *Enodes
+
+	// UltraLightFraction is the minimum percentage of the trusted servers that need
+	// to announce a new head for the ultra-light client to accept it.
+	UltraLightFraction int
+
 	// WhisperEnabled specifies whether the node should run the Whisper protocol.
 	WhisperEnabled bool
 
@@ -86,6 +95,7 @@ var defaultNodeConfig = &NodeConfig{
 	EthereumEnabled:       true,
 	EthereumNetworkID:     1,
 	EthereumDatabaseCache: 16,
+	UltraLightFraction:    75,
 }
 
 // NewNodeConfig creates a new node option set, initialized to the default values.
@@ -160,6 +170,12 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		ethConf.SyncMode = downloader.LightSync
 		ethConf.NetworkId = uint64(config.EthereumNetworkID)
 		ethConf.DatabaseCache = config.EthereumDatabaseCache
+		if config.UltraLightServers != nil && config.UltraLightServers.Size() > 0 {
+			ethConf.ULC = &eth.ULCConfig{MinTrustedFraction: config.UltraLightFraction}
+			for _, node := range config.UltraLightServers.nodes {
+				ethConf.ULC.TrustedServers = append(ethConf.ULC.TrustedServers, node.String())
+			}
+		}
 		if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			return les.New(ctx, &ethConf)
 		}); err != nil {
@@ -106,6 +106,19 @@ func (api *PrivateAdminAPI) RemoveTrustedPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// SetBandwidthLimits changes the bandwidth limits of the p2p server.
+func (api *PrivateAdminAPI) SetBandwidthLimits(limits p2p.BandwidthLimits) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if err := server.SetBandwidthLimits(limits); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -282,6 +295,26 @@ func (api *PublicAdminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// PeerStats retrieves the traffic counters of each connected peer, broken down
+// by protocol and message code.
+func (api *PublicAdminAPI) PeerStats() ([]*p2p.PeerStats, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeersStats(), nil
+}
+
+// BandwidthLimits retrieves the current bandwidth limits of the p2p server.
+func (api *PublicAdminAPI) BandwidthLimits() (*p2p.BandwidthLimits, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	limits := server.BandwidthLimits()
+	return &limits, nil
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *PublicAdminAPI) NodeInfo() (*p2p.NodeInfo, error) {
@@ -26,7 +26,9 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts/pkcs11"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -39,6 +41,7 @@ import (
 const (
 	datadirPrivateKey      = "nodekey"            // Path within the datadir to the node's private key
 	datadirDefaultKeyStore = "keystore"           // Path within the datadir to the keystore
+	keystoreHDWalletDir    = "hd"                 // Path within the keystore to the HD wallets
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
@@ -86,6 +89,14 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the IPC path or HTTP endpoint of an external signer (e.g.
+	// clef) whose accounts are made available through the account manager.
+	ExternalSigner string `toml:",omitempty"`
+
+	// PKCS11Module is the path of a PKCS#11 module (e.g. of a hardware security
+	// module) whose tokens' secp256k1 keys are made available as accounts.
+	PKCS11Module string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -423,6 +434,21 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	// Assemble the account manager and supported backends
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
+		keystore.NewHDKeyStore(filepath.Join(keydir, keystoreHDWalletDir), scryptN, scryptP),
+	}
+	if conf.ExternalSigner != "" {
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	}
+	if conf.PKCS11Module != "" {
+		hub, err := pkcs11.NewHub(conf.PKCS11Module)
+		if err != nil {
+			return nil, "", fmt.Errorf("error loading PKCS#11 module: %v", err)
+		}
+		backends = append(backends, hub)
 	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
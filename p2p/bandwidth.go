@@ -0,0 +1,285 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// BandwidthLimits configures the rate limits of peer traffic. All rates are in
+// bytes per second of message payload, zero means unlimited. Messages of the base
+// protocol (handshakes, pings and disconnects) are never throttled.
+type BandwidthLimits struct {
+	MaxUpload       int `json:"maxUpload"`       // upload limit of all peers together
+	MaxDownload     int `json:"maxDownload"`     // download limit of all peers together
+	MaxPeerUpload   int `json:"maxPeerUpload"`   // upload limit of each peer
+	MaxPeerDownload int `json:"maxPeerDownload"` // download limit of each peer
+
+	// Shares caps the traffic of protocols to a percentage of the global limits,
+	// keyed by protocol name (e.g. "eth": 70, "les": 20). This prevents a single
+	// protocol from starving the others. Protocols without a share are only
+	// subject to the global limits.
+	Shares map[string]int `json:"shares,omitempty" toml:",omitempty"`
+}
+
+func (l BandwidthLimits) validate() error {
+	if l.MaxUpload < 0 || l.MaxDownload < 0 || l.MaxPeerUpload < 0 || l.MaxPeerDownload < 0 {
+		return fmt.Errorf("negative bandwidth limit")
+	}
+	total := 0
+	for name, share := range l.Shares {
+		if share <= 0 || share > 100 {
+			return fmt.Errorf("bandwidth share of protocol %q out of range: %d%%", name, share)
+		}
+		total += share
+	}
+	if total > 100 {
+		return fmt.Errorf("bandwidth shares add up to %d%%", total)
+	}
+	return nil
+}
+
+func (l BandwidthLimits) copy() BandwidthLimits {
+	cpy := l
+	if l.Shares != nil {
+		cpy.Shares = make(map[string]int, len(l.Shares))
+		for name, share := range l.Shares {
+			cpy.Shares[name] = share
+		}
+	}
+	return cpy
+}
+
+// tokenBucket is a rate limiter that tracks the available bytes of a traffic
+// direction. The rate is supplied on every use so limits can be changed while
+// the bucket is in use. Buckets may go into debt, which allows messages larger
+// than the burst size to pass.
+type tokenBucket struct {
+	tokens  float64
+	last    mclock.AbsTime
+	started bool
+}
+
+// take removes n bytes from the bucket and returns the time to wait until the
+// bucket is out of debt. The burst size is one second worth of traffic.
+func (b *tokenBucket) take(now mclock.AbsTime, n uint32, rate int) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	b.refill(now, rate)
+	b.tokens -= float64(n)
+	return b.debt(rate)
+}
+
+// wait returns the time to wait until the bucket is out of debt, without taking
+// anything from it.
+func (b *tokenBucket) wait(now mclock.AbsTime, rate int) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	b.refill(now, rate)
+	return b.debt(rate)
+}
+
+// refill adds the tokens accumulated since the last use of the bucket.
+func (b *tokenBucket) refill(now mclock.AbsTime, rate int) {
+	if b.started {
+		b.tokens += float64(rate) * time.Duration(now-b.last).Seconds()
+	} else {
+		b.tokens, b.started = float64(rate), true
+	}
+	if b.tokens > float64(rate) {
+		b.tokens = float64(rate)
+	}
+	b.last = now
+}
+
+// debt returns the time until the bucket is out of debt at the given rate.
+func (b *tokenBucket) debt(rate int) time.Duration {
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / float64(rate) * float64(time.Second))
+}
+
+// bandwidth enforces the bandwidth limits of a server.
+type bandwidth struct {
+	clock mclock.Clock
+
+	mu        sync.Mutex
+	limits    BandwidthLimits
+	up, down  tokenBucket
+	protoUp   map[string]*tokenBucket
+	protoDown map[string]*tokenBucket
+}
+
+func newBandwidth(limits BandwidthLimits, clock mclock.Clock) *bandwidth {
+	return &bandwidth{
+		clock:     clock,
+		limits:    limits.copy(),
+		protoUp:   make(map[string]*tokenBucket),
+		protoDown: make(map[string]*tokenBucket),
+	}
+}
+
+func (bw *bandwidth) setLimits(limits BandwidthLimits) error {
+	if err := limits.validate(); err != nil {
+		return err
+	}
+	bw.mu.Lock()
+	bw.limits = limits.copy()
+	bw.mu.Unlock()
+	return nil
+}
+
+func (bw *bandwidth) getLimits() BandwidthLimits {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.limits.copy()
+}
+
+// newConnLimiter creates the limiter of a connection running the given protocols.
+func (bw *bandwidth) newConnLimiter(protos map[string]*protoRW) *connLimiter {
+	cl := &connLimiter{bw: bw}
+	for _, proto := range protos {
+		cl.protos = append(cl.protos, protoRange{proto.Name, proto.offset, proto.offset + proto.Length})
+	}
+	return cl
+}
+
+// connLimiter throttles the traffic of a single connection.
+type connLimiter struct {
+	bw       *bandwidth
+	protos   []protoRange
+	up, down tokenBucket // guarded by bw.mu
+}
+
+type protoRange struct {
+	name       string
+	start, end uint64
+}
+
+// shareDelay returns how long to wait before sending a message, until the upload
+// share of its protocol is out of debt. It is applied before the message takes
+// the write slot of the connection, so protocols exceeding their share don't hold
+// up the others.
+func (cl *connLimiter) shareDelay(code uint64) time.Duration {
+	if cl == nil || code < baseProtocolLength {
+		return 0
+	}
+	bw := cl.bw
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	bucket, rate := cl.share(code, bw.protoUp, bw.limits.MaxUpload)
+	if bucket == nil {
+		return 0
+	}
+	return bucket.wait(bw.clock.Now(), rate)
+}
+
+// egressDelay returns how long to wait before sending a message, until the global
+// and peer upload limits are out of debt.
+func (cl *connLimiter) egressDelay(code uint64) time.Duration {
+	if cl == nil || code < baseProtocolLength {
+		return 0
+	}
+	bw := cl.bw
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	now := bw.clock.Now()
+	d := bw.up.wait(now, bw.limits.MaxUpload)
+	if pd := cl.up.wait(now, bw.limits.MaxPeerUpload); pd > d {
+		d = pd
+	}
+	return d
+}
+
+// sent accounts for an outgoing message once it has been written.
+func (cl *connLimiter) sent(code uint64, size uint32) {
+	if cl == nil || code < baseProtocolLength {
+		return
+	}
+	bw := cl.bw
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	now := bw.clock.Now()
+	bw.up.take(now, size, bw.limits.MaxUpload)
+	cl.up.take(now, size, bw.limits.MaxPeerUpload)
+	if bucket, rate := cl.share(code, bw.protoUp, bw.limits.MaxUpload); bucket != nil {
+		bucket.take(now, size, rate)
+	}
+}
+
+// ingressDelay accounts for an incoming message and returns how long to wait
+// before reading the next one.
+func (cl *connLimiter) ingressDelay(code uint64, size uint32) time.Duration {
+	if cl == nil || code < baseProtocolLength {
+		return 0
+	}
+	bw := cl.bw
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	now := bw.clock.Now()
+	d := bw.down.take(now, size, bw.limits.MaxDownload)
+	if pd := cl.down.take(now, size, bw.limits.MaxPeerDownload); pd > d {
+		d = pd
+	}
+	if bucket, rate := cl.share(code, bw.protoDown, bw.limits.MaxDownload); bucket != nil {
+		if pd := bucket.take(now, size, rate); pd > d {
+			d = pd
+		}
+	}
+	return d
+}
+
+// share returns the bucket and rate of the bandwidth share of the protocol a
+// message code belongs to, or nil if the protocol has no share. The caller must
+// hold bw.mu.
+func (cl *connLimiter) share(code uint64, buckets map[string]*tokenBucket, globalRate int) (*tokenBucket, int) {
+	name := cl.protocol(code)
+	if name == "" || globalRate <= 0 {
+		return nil, 0
+	}
+	share := cl.bw.limits.Shares[name]
+	if share <= 0 {
+		return nil, 0
+	}
+	b := buckets[name]
+	if b == nil {
+		b = new(tokenBucket)
+		buckets[name] = b
+	}
+	return b, globalRate * share / 100
+}
+
+// protocol returns the name of the protocol a message code belongs to.
+func (cl *connLimiter) protocol(code uint64) string {
+	for _, p := range cl.protos {
+		if code >= p.start && code < p.end {
+			return p.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var b tokenBucket
+	now := mclock.AbsTime(0)
+
+	if d := b.take(now, 50, 100); d != 0 {
+		t.Fatalf("wrong delay with full bucket: %v", d)
+	}
+	if d := b.take(now, 100, 100); d != 500*time.Millisecond {
+		t.Fatalf("wrong delay with bucket in debt: %v", d)
+	}
+	now += mclock.AbsTime(time.Second)
+	if d := b.take(now, 50, 100); d != 0 {
+		t.Fatalf("wrong delay after refill: %v", d)
+	}
+	// The bucket doesn't fill beyond one second worth of traffic.
+	now += mclock.AbsTime(time.Hour)
+	if d := b.take(now, 200, 100); d != time.Second {
+		t.Fatalf("wrong delay after long idle time: %v", d)
+	}
+	// Zero rate means unlimited.
+	if d := b.take(now, 1000, 0); d != 0 {
+		t.Fatalf("wrong delay without limit: %v", d)
+	}
+}
+
+func TestConnLimiter(t *testing.T) {
+	var (
+		clock  = new(mclock.Simulated)
+		limits = BandwidthLimits{MaxUpload: 1000, MaxPeerUpload: 600, Shares: map[string]int{"eth": 50}}
+		bw     = newBandwidth(limits, clock)
+		protos = []Protocol{{Name: "eth", Version: 63, Length: 8}, {Name: "les", Version: 2, Length: 8}}
+		caps   = []Cap{{"eth", 63}, {"les", 2}}
+		c1     = bw.newConnLimiter(matchProtocols(protos, caps, nil))
+		c2     = bw.newConnLimiter(matchProtocols(protos, caps, nil))
+		eth    = uint64(baseProtocolLength)
+		les    = uint64(baseProtocolLength + 8)
+	)
+	// Every message waits for its share and the upload limits, and is
+	// accounted for once sent.
+	tests := []struct {
+		cl           *connLimiter
+		code         uint64
+		size         uint32
+		share, delay time.Duration
+	}{
+		{c1, pingMsg, 10000, 0, 0},                  // base protocol isn't throttled
+		{c1, eth, 500, 0, 0},                        // within all limits
+		{c2, eth, 250, 0, 0},                        // eth share used up, but not in debt
+		{c2, eth + 1, 0, 500 * time.Millisecond, 0}, // eth share in debt
+		{c2, les, 250, 0, 0},                        // les has no share
+		{c1, les, 200, 0, 0},                        // global limit used up, but not in debt
+		{c1, les, 0, 0, 200 * time.Millisecond},     // global and peer limit in debt
+	}
+	for i, test := range tests {
+		if d := test.cl.shareDelay(test.code); d != test.share {
+			t.Errorf("test %d: wrong share delay %v, want %v", i, d, test.share)
+		}
+		if d := test.cl.egressDelay(test.code); d != test.delay {
+			t.Errorf("test %d: wrong delay %v, want %v", i, d, test.delay)
+		}
+		test.cl.sent(test.code, test.size)
+	}
+	// Waiting doesn't use up the limits, only sent messages do.
+	for i := 0; i < 3; i++ {
+		if d := c2.shareDelay(eth); d != 500*time.Millisecond {
+			t.Errorf("wrong share delay without sending: %v", d)
+		}
+		if d := c2.egressDelay(les); d != 200*time.Millisecond {
+			t.Errorf("wrong delay without sending: %v", d)
+		}
+	}
+	if d := c1.ingressDelay(eth, 10000); d != 0 {
+		t.Errorf("download throttled without limit: %v", d)
+	}
+
+	// Changing the limits applies to existing connections.
+	limits.MaxDownload = 100
+	if err := bw.setLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	if d := c1.ingressDelay(les, 200); d != time.Second {
+		t.Errorf("wrong download delay after limit change: %v", d)
+	}
+}
+
+// This test checks that protocols wait for their bandwidth share before taking
+// the write slot of the connection.
+func TestProtoRWShareDelay(t *testing.T) {
+	var (
+		bw     = newBandwidth(BandwidthLimits{MaxUpload: 1000, Shares: map[string]int{"eth": 50}}, new(mclock.Simulated))
+		protos = []Protocol{{Name: "eth", Version: 63, Length: 8}}
+		cl     = bw.newConnLimiter(matchProtocols(protos, []Cap{{"eth", 63}}, nil))
+		closed = make(chan struct{})
+		wstart = make(chan struct{}, 1)
+	)
+	cl.sent(baseProtocolLength, 1000) // eth share is in debt for one second
+	wstart <- struct{}{}
+	close(closed)
+
+	rw := &protoRW{Protocol: protos[0], offset: baseProtocolLength, closed: closed, wstart: wstart, limiter: cl}
+	if err := rw.WriteMsg(Msg{Code: 0}); err != ErrShuttingDown {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if len(wstart) != 1 {
+		t.Fatal("write slot taken while waiting for the bandwidth share")
+	}
+}
+
+func TestBandwidthLimitsValidate(t *testing.T) {
+	tests := []struct {
+		limits BandwidthLimits
+		ok     bool
+	}{
+		{BandwidthLimits{}, true},
+		{BandwidthLimits{MaxUpload: 1000, Shares: map[string]int{"eth": 70, "les": 20, "bzz": 10}}, true},
+		{BandwidthLimits{MaxPeerDownload: -1}, false},
+		{BandwidthLimits{Shares: map[string]int{"eth": 0}}, false},
+		{BandwidthLimits{Shares: map[string]int{"eth": 70, "les": 40}}, false},
+	}
+	for i, test := range tests {
+		if err := test.limits.validate(); (err == nil) != test.ok {
+			t.Errorf("test %d: wrong result %v", i, err)
+		}
+	}
+}
@@ -37,6 +37,14 @@ const (
 	// once every few seconds.
 	lookupInterval = 4 * time.Second
 
+	// A discovery lookup collects at most this many dial candidates from the
+	// discovery sources.
+	lookupBatchSize = 16
+
+	// The discovery mix waits this long for the fairly chosen source before
+	// taking a dial candidate from any other source.
+	discmixTimeout = 5 * time.Second
+
 	// If no peers are found for this amount of time, the initial bootnodes are
 	// attempted to be connected.
 	fallbackInterval = 20 * time.Second
@@ -76,7 +84,6 @@ type dialstate struct {
 	lookupRunning bool
 	dialing       map[enode.ID]connFlag
 	lookupBuf     []*enode.Node // current discovery lookup results
-	randomNodes   []*enode.Node // filled from Table
 	static        map[enode.ID]*dialTask
 	hist          *dialHistory
 
@@ -87,8 +94,7 @@ type dialstate struct {
 type discoverTable interface {
 	Close()
 	Resolve(*enode.Node) *enode.Node
-	LookupRandom() []*enode.Node
-	ReadRandomNodes([]*enode.Node) int
+	RandomNodes() enode.Iterator
 }
 
 // the dial history remembers recent dials.
@@ -113,9 +119,9 @@ type dialTask struct {
 	resolveDelay time.Duration
 }
 
-// discoverTask runs discovery table operations.
+// discoverTask collects dial candidates from the discovery sources.
 // Only one discoverTask is active at any time.
-// discoverTask.Do performs a random lookup.
+// discoverTask.Do waits for the next candidates of the discovery mix.
 type discoverTask struct {
 	results []*enode.Node
 }
@@ -135,7 +141,6 @@ func newDialState(self enode.ID, static []*enode.Node, bootnodes []*enode.Node,
 		static:      make(map[enode.ID]*dialTask),
 		dialing:     make(map[enode.ID]connFlag),
 		bootnodes:   make([]*enode.Node, len(bootnodes)),
-		randomNodes: make([]*enode.Node, maxdyn/2),
 		hist:        new(dialHistory),
 	}
 	copy(s.bootnodes, bootnodes)
@@ -215,18 +220,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[enode.ID]*Peer, now time.Ti
 			needDynDials--
 		}
 	}
-	// Use random nodes from the table for half of the necessary
-	// dynamic dials.
-	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
-		n := s.ntab.ReadRandomNodes(s.randomNodes)
-		for i := 0; i < randomCandidates && i < n; i++ {
-			if addDial(dynDialedConn, s.randomNodes[i]) {
-				needDynDials--
-			}
-		}
-	}
-	// Create dynamic dials from random lookup results, removing tried
+	// Create dynamic dials from discovery results, removing tried
 	// items from the result buffer.
 	i := 0
 	for ; i < len(s.lookupBuf) && needDynDials > 0; i++ {
@@ -368,7 +362,22 @@ func (t *discoverTask) Do(srv *Server) {
 		time.Sleep(next.Sub(now))
 	}
 	srv.lastLookup = time.Now()
-	t.results = srv.ntab.LookupRandom()
+
+	// Wait for the first candidate, then take the ones already queued.
+	select {
+	case n := <-srv.candidates:
+		t.results = append(t.results, n)
+	case <-srv.quit:
+		return
+	}
+	for len(t.results) < lookupBatchSize {
+		select {
+		case n := <-srv.candidates:
+			t.results = append(t.results, n)
+		default:
+			return
+		}
+	}
 }
 
 func (t *discoverTask) String() string {
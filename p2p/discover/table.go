@@ -172,10 +172,11 @@ func (tab *Table) ReadRandomNodes(buf []*enode.Node) (n int) {
 			buckets = append(buckets[:j], buckets[j+1:]...)
 		}
 		if len(buckets) == 0 {
+			i++
 			break
 		}
 	}
-	return i + 1
+	return i
 }
 
 // Close terminates the network listener and flushes the node database.
@@ -244,6 +245,68 @@ func (tab *Table) LookupRandom() []*enode.Node {
 	return unwrapNodes(tab.lookup(target, true))
 }
 
+// RandomNodes returns an iterator which finds random nodes in the network. Its
+// results alternate between random nodes of the table and the results of random
+// lookups. The iterator ends when it is closed or the table is closed.
+func (tab *Table) RandomNodes() enode.Iterator {
+	return &randomIterator{tab: tab, closing: make(chan struct{})}
+}
+
+// randomIterator is the enode.Iterator returned by RandomNodes.
+type randomIterator struct {
+	tab    *Table
+	buf    []*enode.Node
+	cur    *enode.Node
+	lookup bool // whether the buffer is refilled by a lookup next
+
+	closeOnce sync.Once
+	closing   chan struct{}
+}
+
+// Next implements enode.Iterator, refilling the result buffer when it runs empty.
+func (it *randomIterator) Next() bool {
+	it.cur = nil
+	for len(it.buf) == 0 {
+		select {
+		case <-it.closing:
+			return false
+		case <-it.tab.closed:
+			return false
+		default:
+		}
+		if it.lookup {
+			it.buf = it.tab.LookupRandom()
+		} else {
+			buf := make([]*enode.Node, bucketSize)
+			it.buf = buf[:it.tab.ReadRandomNodes(buf)]
+		}
+		it.lookup = !it.lookup
+
+		if len(it.buf) == 0 && !it.lookup {
+			// Both the table and the lookup came up empty, wait a bit.
+			select {
+			case <-it.closing:
+				return false
+			case <-it.tab.closed:
+				return false
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// Node implements enode.Iterator.
+func (it *randomIterator) Node() *enode.Node {
+	return it.cur
+}
+
+// Close implements enode.Iterator.
+func (it *randomIterator) Close() {
+	it.closeOnce.Do(func() { close(it.closing) })
+}
+
 // lookup performs a network search for nodes close to the given target. It approaches the
 // target by querying nodes that are closer to it on each iteration. The given target does
 // not need to be an actual node identifier.
@@ -258,6 +258,32 @@ func TestTable_ReadRandomNodesGetAll(t *testing.T) {
 	}
 }
 
+func TestTable_RandomNodes(t *testing.T) {
+	transport := newPingRecorder()
+	tab, db := newTestTable(transport)
+	defer tab.Close()
+	defer db.Close()
+	<-tab.initDone
+
+	for i := 0; i < 5; i++ {
+		tab.stuff([]*node{nodeAtDistance(tab.self().ID(), 256-i, intIP(i))})
+	}
+	it := tab.RandomNodes()
+	nodes := enode.ReadNodes(it, tab.len())
+	if len(nodes) != tab.len() {
+		t.Fatalf("wrong number of nodes, got %d, want %d", len(nodes), tab.len())
+	}
+	for _, n := range nodes {
+		if !contains(tab.bucket(n.ID()).entries, n.ID()) {
+			t.Errorf("node %v not in table", n.ID())
+		}
+	}
+	it.Close()
+	if it.Next() {
+		t.Fatal("Next returned true after Close")
+	}
+}
+
 type closeTest struct {
 	Self   enode.ID
 	Target enode.ID
@@ -0,0 +1,343 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via signed trees of node records
+// published in DNS TXT records (EIP-1459).
+package dnsdisc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+// Config holds the settings of a DNS discovery client.
+type Config struct {
+	Timeout         time.Duration      // timeout used for DNS lookups (default 5s)
+	RecheckInterval time.Duration      // time between tree root update checks (default 30min)
+	ValidSchemes    enr.IdentityScheme // acceptable ENR identity schemes (default enode.ValidSchemes)
+	Resolver        Resolver           // the DNS resolver to use (defaults to system DNS)
+	Logger          log.Logger         // destination of client log messages (defaults to root logger)
+}
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.RecheckInterval == 0 {
+		cfg.RecheckInterval = 30 * time.Minute
+	}
+	if cfg.ValidSchemes == nil {
+		cfg.ValidSchemes = enode.ValidSchemes
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+	return cfg
+}
+
+// Client discovers nodes by querying DNS servers.
+type Client struct {
+	cfg Config
+}
+
+// NewClient creates a DNS discovery client.
+func NewClient(cfg Config) *Client {
+	return &Client{cfg: cfg.withDefaults()}
+}
+
+// SyncTree downloads the entire tree at the given URL, verifying the signature
+// of its root. Linked trees are not followed.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	loc, err := parseLink(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrtree URL: %v", err)
+	}
+	return c.syncTree(context.Background(), loc, nil)
+}
+
+// syncTree downloads the tree at the given location. Entries of the previous
+// version of the tree are reused instead of resolving them again, and the
+// previous version is returned as is if the root did not change.
+func (c *Client) syncTree(ctx context.Context, loc *linkEntry, prev *Tree) (*Tree, error) {
+	root, err := c.resolveRoot(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil && prev.root.String() == root.String() {
+		return prev, nil
+	}
+	var cache map[string]entry
+	if prev != nil {
+		cache = prev.entries
+	}
+	t := &Tree{root: root, entries: make(map[string]entry)}
+	if err := c.syncSubtree(ctx, loc.domain, root.eroot, false, t.entries, cache); err != nil {
+		return nil, err
+	}
+	if err := c.syncSubtree(ctx, loc.domain, root.lroot, true, t.entries, cache); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncSubtree adds the entry with the given hash and all entries below it to dst.
+// The link flag selects whether the subtree may contain links or node records.
+func (c *Client) syncSubtree(ctx context.Context, domain, hash string, link bool, dst, cache map[string]entry) error {
+	if _, ok := dst[hash]; ok {
+		return nil
+	}
+	e, ok := cache[hash]
+	if !ok {
+		var err error
+		if e, err = c.resolveEntry(ctx, domain, hash); err != nil {
+			return err
+		}
+	}
+	switch e := e.(type) {
+	case *enrEntry:
+		if link {
+			return nameError{hash + "." + domain, errENRInLinkTree}
+		}
+	case *linkEntry:
+		if !link {
+			return nameError{hash + "." + domain, errLinkInENRTree}
+		}
+	case *branchEntry:
+		dst[hash] = e
+		for _, child := range e.children {
+			if err := c.syncSubtree(ctx, domain, child, link, dst, cache); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	dst[hash] = e
+	return nil
+}
+
+// resolveRoot retrieves the root entry of the tree at the given location and
+// verifies its signature.
+func (c *Client) resolveRoot(ctx context.Context, loc *linkEntry) (*rootEntry, error) {
+	txts, err := c.lookupTXT(ctx, loc.domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			root, err := parseRoot(txt)
+			if err != nil {
+				return nil, nameError{loc.domain, err}
+			}
+			if !root.verifySignature(loc.pubkey) {
+				return nil, nameError{loc.domain, errInvalidSig}
+			}
+			return root, nil
+		}
+	}
+	return nil, nameError{loc.domain, errNoRoot}
+}
+
+// resolveEntry retrieves the tree entry with the given hash, verifying that its
+// content matches the hash.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	name := hash + "." + domain
+	txts, err := c.lookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt, c.cfg.ValidSchemes)
+		if err == errUnknownEntry {
+			continue
+		}
+		if err != nil {
+			return nil, nameError{name, err}
+		}
+		if b32format.EncodeToString(crypto.Keccak256([]byte(txt))[:hashAbbrev]) != hash {
+			return nil, nameError{name, errHashMismatch}
+		}
+		return e, nil
+	}
+	return nil, nameError{name, errNoEntry}
+}
+
+// lookupTXT queries the TXT records of a name, bounded by the lookup timeout.
+func (c *Client) lookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	return c.cfg.Resolver.LookupTXT(ctx, name)
+}
+
+// randomIterator provides the nodes of a set of trees and of all trees linked
+// from them in random order. The trees are synced in the background and
+// re-checked periodically until the iterator is closed.
+type randomIterator struct {
+	client *Client
+	roots  []*linkEntry
+	trees  map[string]*Tree // Last synced version of the trees by URL, owned by loop
+	cur    *enode.Node      // Current node of the iterator, owned by the consumer
+
+	nodes   []*enode.Node // Nodes of all synced trees
+	updated chan struct{} // Closed when the node list is replaced
+	lock    sync.Mutex    // Protects the node list
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+// NewIterator creates an iterator returning random nodes of the trees at the
+// given URLs and of all trees linked from them. The iterator never ends unless
+// it is closed.
+func (c *Client) NewIterator(urls ...string) (enode.Iterator, error) {
+	it := &randomIterator{
+		client:  c,
+		trees:   make(map[string]*Tree),
+		updated: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	for _, url := range urls {
+		loc, err := parseLink(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enrtree URL %q: %v", url, err)
+		}
+		it.roots = append(it.roots, loc)
+	}
+	it.ctx, it.cancel = context.WithCancel(context.Background())
+	go it.loop()
+	return it, nil
+}
+
+// Next implements enode.Iterator, waiting for the first sync if no nodes are
+// known yet.
+func (it *randomIterator) Next() bool {
+	it.cur = nil
+	for {
+		it.lock.Lock()
+		nodes, updated := it.nodes, it.updated
+		it.lock.Unlock()
+
+		if it.ctx.Err() != nil {
+			return false
+		}
+		if len(nodes) > 0 {
+			it.cur = nodes[rand.Intn(len(nodes))]
+			return true
+		}
+		select {
+		case <-updated:
+		case <-it.ctx.Done():
+			return false
+		}
+	}
+}
+
+// Node implements enode.Iterator.
+func (it *randomIterator) Node() *enode.Node {
+	return it.cur
+}
+
+// Close implements enode.Iterator, stopping the sync of the trees.
+func (it *randomIterator) Close() {
+	it.cancel()
+	<-it.closed
+}
+
+// loop syncs the trees every recheck interval until the iterator is closed.
+func (it *randomIterator) loop() {
+	defer close(it.closed)
+
+	for {
+		it.refresh()
+
+		timer := time.NewTimer(it.client.cfg.RecheckInterval)
+		select {
+		case <-timer.C:
+		case <-it.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// refresh syncs all trees reachable from the root URLs and replaces the node list
+// with their nodes. Trees failing to sync keep their previous version.
+func (it *randomIterator) refresh() {
+	var (
+		queue = append([]*linkEntry{}, it.roots...)
+		trees = make(map[string]*Tree)
+		nodes = make(map[enode.ID]*enode.Node)
+	)
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+
+		url := loc.String()
+		if _, ok := trees[url]; ok {
+			continue
+		}
+		t, err := it.client.syncTree(it.ctx, loc, it.trees[url])
+		if it.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			it.client.cfg.Logger.Debug("Failed to sync DNS discovery tree", "url", url, "err", err)
+			if t = it.trees[url]; t == nil {
+				continue
+			}
+		}
+		trees[url] = t
+		for _, n := range t.Nodes() {
+			nodes[n.ID()] = n
+		}
+		for _, e := range t.entries {
+			if link, ok := e.(*linkEntry); ok {
+				queue = append(queue, link)
+			}
+		}
+	}
+	it.trees = trees
+
+	list := make([]*enode.Node, 0, len(nodes))
+	for _, n := range nodes {
+		list = append(list, n)
+	}
+	it.lock.Lock()
+	it.nodes = list
+	close(it.updated)
+	it.updated = make(chan struct{})
+	it.lock.Unlock()
+
+	it.client.cfg.Logger.Debug("Synced DNS discovery trees", "trees", len(trees), "nodes", len(list))
+}
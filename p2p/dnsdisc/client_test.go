@@ -0,0 +1,223 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// mapResolver is an in-memory resolver serving the TXT records of signed trees.
+type mapResolver struct {
+	records map[string]string
+	lock    sync.Mutex
+}
+
+func newMapResolver() *mapResolver {
+	return &mapResolver{records: make(map[string]string)}
+}
+
+func (mr *mapResolver) add(records map[string]string) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	for name, txt := range records {
+		mr.records[name] = txt
+	}
+}
+
+func (mr *mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	if txt, ok := mr.records[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, nil
+}
+
+// publish signs a tree of the given nodes and links, serving it via the resolver.
+func (mr *mapResolver) publish(t *testing.T, key *ecdsa.PrivateKey, domain string, seq uint, nodes []*enode.Node, links []string) string {
+	tree, err := MakeTree(seq, nodes, links)
+	if err != nil {
+		t.Fatalf("can't create tree: %v", err)
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatalf("can't sign tree: %v", err)
+	}
+	mr.add(tree.ToTXT(domain))
+	return url
+}
+
+// Tests that a published tree is downloaded completely.
+func TestClientSyncTree(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		resolver = newMapResolver()
+		nodes    = testNodes(1, 40)
+		link     = (&linkEntry{domain: "other.example.org", pubkey: &other.PublicKey}).String()
+		url      = resolver.publish(t, key, "nodes.example.org", 1, nodes, []string{link})
+		client   = NewClient(Config{Resolver: resolver})
+	)
+	tree, err := client.SyncTree(url)
+	if err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	want, _ := MakeTree(1, nodes, nil)
+	if !reflect.DeepEqual(tree.Nodes(), want.Nodes()) {
+		t.Errorf("node mismatch: have %d nodes, want %d", len(tree.Nodes()), len(nodes))
+	}
+	if !reflect.DeepEqual(tree.Links(), []string{link}) {
+		t.Errorf("link mismatch: have %v, want %v", tree.Links(), []string{link})
+	}
+	if tree.Seq() != 1 {
+		t.Errorf("seq mismatch: have %d, want 1", tree.Seq())
+	}
+}
+
+// Tests that trees are rejected unless signed by the key in the URL.
+func TestClientSyncTreeBadSignature(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		resolver = newMapResolver()
+		client   = NewClient(Config{Resolver: resolver})
+	)
+	resolver.publish(t, key, "nodes.example.org", 1, testNodes(1, 3), nil)
+
+	url := (&linkEntry{domain: "nodes.example.org", pubkey: &other.PublicKey}).String()
+	if _, err := client.SyncTree(url); err == nil || !strings.Contains(err.Error(), errInvalidSig.Error()) {
+		t.Fatalf("wrong error for bad signature: %v", err)
+	}
+}
+
+// Tests that entries not matching their hash are rejected.
+func TestClientSyncTreeHashMismatch(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		resolver = newMapResolver()
+		client   = NewClient(Config{Resolver: resolver})
+		nodes    = testNodes(1, 3)
+		url      = resolver.publish(t, key, "nodes.example.org", 1, nodes, nil)
+	)
+	// Replace the record of a node with the record of another node.
+	hash := subdomain(&enrEntry{nodes[0]})
+	resolver.add(map[string]string{hash + ".nodes.example.org": (&enrEntry{testNodes(2, 1)[0]}).String()})
+
+	if _, err := client.SyncTree(url); err == nil || !strings.Contains(err.Error(), errHashMismatch.Error()) {
+		t.Fatalf("wrong error for hash mismatch: %v", err)
+	}
+}
+
+// Tests that a source provides the nodes of linked trees and picks up updates.
+func TestIteratorLinks(t *testing.T) {
+	var (
+		key1, _  = crypto.GenerateKey()
+		key2, _  = crypto.GenerateKey()
+		resolver = newMapResolver()
+		nodes1   = testNodes(1, 20)
+		nodes2   = testNodes(2, 20)
+		url2     = resolver.publish(t, key2, "b.example.org", 1, nodes2, nil)
+		url1     = resolver.publish(t, key1, "a.example.org", 1, nodes1, []string{url2})
+		client   = NewClient(Config{Resolver: resolver, RecheckInterval: 50 * time.Millisecond})
+	)
+	// Link the second tree back to the first, which must not loop.
+	resolver.publish(t, key2, "b.example.org", 2, nodes2, []string{url1})
+
+	it, err := client.NewIterator(url1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	waitNodes(t, it, append(append([]*enode.Node{}, nodes1...), nodes2...))
+
+	// Updating the linked tree replaces its nodes.
+	nodes3 := testNodes(3, 5)
+	resolver.publish(t, key2, "b.example.org", 3, nodes3, nil)
+	waitNodes(t, it, append(append([]*enode.Node{}, nodes1...), nodes3...))
+}
+
+// This test checks that closing the iterator interrupts a blocked Next.
+func TestIteratorClose(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	client := NewClient(Config{Resolver: newMapResolver()})
+	url := (&linkEntry{domain: "nodes.example.org", pubkey: &key.PublicKey}).String()
+	it, err := client.NewIterator(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan bool)
+	go func() { done <- it.Next() }()
+
+	time.Sleep(10 * time.Millisecond)
+	it.Close()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("Next returned true after Close")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Next didn't unblock on Close")
+	}
+}
+
+// waitNodes waits until the iterator provides exactly the given nodes.
+func waitNodes(t *testing.T, it enode.Iterator, want []*enode.Node) {
+	t.Helper()
+
+	wantIDs := make(map[enode.ID]bool)
+	for _, n := range want {
+		wantIDs[n.ID()] = true
+	}
+	ri := it.(*randomIterator)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		ri.lock.Lock()
+		nodes := ri.nodes
+		ri.lock.Unlock()
+		if len(nodes) != len(want) {
+			continue
+		}
+		match := true
+		for _, node := range nodes {
+			match = match && wantIDs[node.ID()]
+		}
+		if !match {
+			continue
+		}
+		for i := 0; i < len(want); i++ {
+			if !it.Next() {
+				t.Fatal("Next returned false")
+			}
+			if !wantIDs[it.Node().ID()] {
+				t.Fatalf("iterator returned unexpected node %v", it.Node().ID())
+			}
+		}
+		return
+	}
+	t.Fatalf("iterator did not provide the expected %d nodes", len(want))
+}
@@ -0,0 +1,350 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	rootPrefix   = "enrtree-root:v1"
+	linkPrefix   = "enrtree://"
+	branchPrefix = "enrtree-branch:"
+	enrPrefix    = "enr:"
+
+	hashAbbrev  = 16 // Bytes of the keccak256 hash kept in entry names
+	maxChildren = 13 // Maximum number of child hashes in a branch, keeping TXT records small
+	sigLength   = 65 // Length of a recoverable secp256k1 signature
+)
+
+var (
+	b32format = base32.StdEncoding.WithPadding(base32.NoPadding)
+	b64format = base64.RawURLEncoding
+)
+
+// Tree is a merkle tree of node records and links to other trees, as published
+// in DNS TXT records.
+type Tree struct {
+	root    *rootEntry
+	entries map[string]entry
+}
+
+// MakeTree creates a tree containing the given nodes and links. The tree must be
+// signed before it can be published.
+func MakeTree(seq uint, nodes []*enode.Node, links []string) (*Tree, error) {
+	// Sort the records so the tree is deterministic.
+	records := make([]*enode.Node, len(nodes))
+	copy(records, nodes)
+	sort.Slice(records, func(i, j int) bool {
+		return bytes.Compare(records[i].ID().Bytes(), records[j].ID().Bytes()) < 0
+	})
+	enrEntries := make([]entry, len(records))
+	for i, n := range records {
+		enrEntries[i] = &enrEntry{n}
+	}
+	sortedLinks := make([]string, len(links))
+	copy(sortedLinks, links)
+	sort.Strings(sortedLinks)
+
+	linkEntries := make([]entry, len(sortedLinks))
+	for i, url := range sortedLinks {
+		le, err := parseLink(url)
+		if err != nil {
+			return nil, err
+		}
+		linkEntries[i] = le
+	}
+	// Build the subtrees and the root referencing them.
+	t := &Tree{entries: make(map[string]entry)}
+	eroot := t.build(enrEntries)
+	t.entries[subdomain(eroot)] = eroot
+	lroot := t.build(linkEntries)
+	t.entries[subdomain(lroot)] = lroot
+	t.root = &rootEntry{eroot: subdomain(eroot), lroot: subdomain(lroot), seq: seq}
+	return t, nil
+}
+
+// build creates the subtree for the given entries, adding all of its entries
+// except the returned subtree root to the tree.
+func (t *Tree) build(entries []entry) entry {
+	if len(entries) == 1 {
+		return entries[0]
+	}
+	if len(entries) <= maxChildren {
+		hashes := make([]string, len(entries))
+		for i, e := range entries {
+			hashes[i] = subdomain(e)
+			t.entries[hashes[i]] = e
+		}
+		return &branchEntry{hashes}
+	}
+	var subtrees []entry
+	for len(entries) > 0 {
+		n := maxChildren
+		if len(entries) < n {
+			n = len(entries)
+		}
+		subtrees = append(subtrees, t.build(entries[:n]))
+		entries = entries[n:]
+	}
+	return t.build(subtrees)
+}
+
+// Sign signs the tree with the given private key, returning the URL of the tree
+// when published under domain.
+func (t *Tree) Sign(key *ecdsa.PrivateKey, domain string) (url string, err error) {
+	root := *t.root
+	sig, err := crypto.Sign(root.sigHash(), key)
+	if err != nil {
+		return "", err
+	}
+	root.sig = sig
+	t.root = &root
+	return (&linkEntry{domain: domain, pubkey: &key.PublicKey}).String(), nil
+}
+
+// SetSignature verifies the given base64 encoded signature against the public key
+// and assigns it as the signature of the tree if valid.
+func (t *Tree) SetSignature(pubkey *ecdsa.PublicKey, signature string) error {
+	sig, err := b64format.DecodeString(signature)
+	if err != nil || len(sig) != sigLength {
+		return errInvalidSig
+	}
+	root := *t.root
+	root.sig = sig
+	if !root.verifySignature(pubkey) {
+		return errInvalidSig
+	}
+	t.root = &root
+	return nil
+}
+
+// Seq returns the sequence number of the tree.
+func (t *Tree) Seq() uint {
+	return t.root.seq
+}
+
+// Signature returns the base64 encoded signature of the tree, or the empty string
+// if the tree is not signed.
+func (t *Tree) Signature() string {
+	if t.root.sig == nil {
+		return ""
+	}
+	return b64format.EncodeToString(t.root.sig)
+}
+
+// Nodes returns all nodes contained in the tree.
+func (t *Tree) Nodes() []*enode.Node {
+	var nodes []*enode.Node
+	for _, e := range t.entries {
+		if ee, ok := e.(*enrEntry); ok {
+			nodes = append(nodes, ee.node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].ID().Bytes(), nodes[j].ID().Bytes()) < 0
+	})
+	return nodes
+}
+
+// Links returns the URLs of all trees linked from the tree.
+func (t *Tree) Links() []string {
+	var links []string
+	for _, e := range t.entries {
+		if le, ok := e.(*linkEntry); ok {
+			links = append(links, le.String())
+		}
+	}
+	sort.Strings(links)
+	return links
+}
+
+// ToTXT returns the TXT records of the signed tree, keyed by their DNS names
+// below domain.
+func (t *Tree) ToTXT(domain string) map[string]string {
+	records := map[string]string{domain: t.root.String()}
+	for hash, e := range t.entries {
+		name := hash
+		if domain != "" {
+			name = hash + "." + domain
+		}
+		records[name] = e.String()
+	}
+	return records
+}
+
+// entry is a record of the tree, identified by the hash of its text form.
+type entry interface {
+	fmt.Stringer
+}
+
+type (
+	rootEntry struct {
+		eroot string // Hash of the node record subtree root
+		lroot string // Hash of the link subtree root
+		seq   uint   // Sequence number, increased on every update of the tree
+		sig   []byte // Signature over the other fields
+	}
+	branchEntry struct {
+		children []string
+	}
+	enrEntry struct {
+		node *enode.Node
+	}
+	linkEntry struct {
+		domain string
+		pubkey *ecdsa.PublicKey
+	}
+)
+
+// subdomain returns the DNS name of an entry, the abbreviated hash of its text.
+func subdomain(e entry) string {
+	return b32format.EncodeToString(crypto.Keccak256([]byte(e.String()))[:hashAbbrev])
+}
+
+func (e *rootEntry) String() string {
+	return fmt.Sprintf(rootPrefix+" e=%s l=%s seq=%d sig=%s", e.eroot, e.lroot, e.seq, b64format.EncodeToString(e.sig))
+}
+
+// sigHash returns the hash of the root entry signed by the tree's key.
+func (e *rootEntry) sigHash() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf(rootPrefix+" e=%s l=%s seq=%d", e.eroot, e.lroot, e.seq)))
+}
+
+func (e *rootEntry) verifySignature(pubkey *ecdsa.PublicKey) bool {
+	if len(e.sig) != sigLength {
+		return false
+	}
+	return crypto.VerifySignature(crypto.FromECDSAPub(pubkey), e.sigHash(), e.sig[:sigLength-1])
+}
+
+func (e *branchEntry) String() string {
+	return branchPrefix + strings.Join(e.children, ",")
+}
+
+func (e *enrEntry) String() string {
+	enc, _ := rlp.EncodeToBytes(e.node.Record())
+	return enrPrefix + b64format.EncodeToString(enc)
+}
+
+func (e *linkEntry) String() string {
+	return linkPrefix + b32format.EncodeToString(crypto.CompressPubkey(e.pubkey)) + "@" + e.domain
+}
+
+// parseRoot decodes a root entry. The signature is not verified.
+func parseRoot(e string) (*rootEntry, error) {
+	var (
+		eroot, lroot, sig string
+		seq               uint
+	)
+	if _, err := fmt.Sscanf(e, rootPrefix+" e=%s l=%s seq=%d sig=%s", &eroot, &lroot, &seq, &sig); err != nil {
+		return nil, entryError{"root", errSyntax}
+	}
+	if !isValidHash(eroot) || !isValidHash(lroot) {
+		return nil, entryError{"root", errInvalidChild}
+	}
+	sigb, err := b64format.DecodeString(sig)
+	if err != nil || len(sigb) != sigLength {
+		return nil, entryError{"root", errInvalidSig}
+	}
+	return &rootEntry{eroot: eroot, lroot: lroot, seq: seq, sig: sigb}, nil
+}
+
+// parseEntry decodes a non-root entry.
+func parseEntry(e string, validSchemes enr.IdentityScheme) (entry, error) {
+	switch {
+	case strings.HasPrefix(e, linkPrefix):
+		return parseLink(e)
+	case strings.HasPrefix(e, branchPrefix):
+		return parseBranch(e)
+	case strings.HasPrefix(e, enrPrefix):
+		return parseENR(e, validSchemes)
+	default:
+		return nil, errUnknownEntry
+	}
+}
+
+func parseBranch(e string) (entry, error) {
+	e = e[len(branchPrefix):]
+	if e == "" {
+		return &branchEntry{}, nil
+	}
+	hashes := strings.Split(e, ",")
+	for _, h := range hashes {
+		if !isValidHash(h) {
+			return nil, entryError{"branch", errInvalidChild}
+		}
+	}
+	return &branchEntry{hashes}, nil
+}
+
+func parseENR(e string, validSchemes enr.IdentityScheme) (entry, error) {
+	n, err := enode.Parse(validSchemes, e)
+	if err != nil {
+		return nil, entryError{"enr", err}
+	}
+	return &enrEntry{n}, nil
+}
+
+// ParseURL parses an enrtree:// URL, returning the domain name of the tree and the
+// public key it is signed with.
+func ParseURL(url string) (domain string, pubkey *ecdsa.PublicKey, err error) {
+	le, err := parseLink(url)
+	if err != nil {
+		return "", nil, err
+	}
+	return le.domain, le.pubkey, nil
+}
+
+// parseLink decodes a tree URL of the form enrtree://<key>@<domain>.
+func parseLink(e string) (*linkEntry, error) {
+	if !strings.HasPrefix(e, linkPrefix) {
+		return nil, fmt.Errorf("wrong/missing scheme 'enrtree' in URL")
+	}
+	e = e[len(linkPrefix):]
+	pos := strings.IndexByte(e, '@')
+	if pos == -1 {
+		return nil, entryError{"link", errNoPubkey}
+	}
+	keystring, domain := e[:pos], e[pos+1:]
+	keybytes, err := b32format.DecodeString(keystring)
+	if err != nil {
+		return nil, entryError{"link", errBadPubkey}
+	}
+	key, err := crypto.DecompressPubkey(keybytes)
+	if err != nil {
+		return nil, entryError{"link", errBadPubkey}
+	}
+	return &linkEntry{domain: domain, pubkey: key}, nil
+}
+
+// isValidHash reports whether h is an abbreviated entry hash.
+func isValidHash(h string) bool {
+	dec, err := b32format.DecodeString(h)
+	return err == nil && len(dec) == hashAbbrev
+}
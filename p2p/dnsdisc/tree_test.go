@@ -0,0 +1,140 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+// Tests that the entries of the tree survive their text encoding.
+func TestParseEntry(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := testNodes(1, 1)[0]
+
+	tests := []entry{
+		&branchEntry{},
+		&branchEntry{children: []string{"2XS2367YHAXJFGLZHVAWLQD4ZY", "H4FHT4B454P6UXFD7JCYQ5PWDY"}},
+		&enrEntry{node},
+		&linkEntry{domain: "nodes.example.org", pubkey: &key.PublicKey},
+	}
+	for i, want := range tests {
+		have, err := parseEntry(want.String(), enode.ValidSchemes)
+		if err != nil {
+			t.Errorf("test %d: parse error: %v", i, err)
+			continue
+		}
+		if have.String() != want.String() {
+			t.Errorf("test %d: encoding mismatch:\nhave %s\nwant %s", i, have, want)
+		}
+	}
+	invalid := []string{
+		"",
+		"enrtree-foo:",
+		"enrtree-branch:2XS2367YHAXJFGLZHVAWLQD4ZYX",
+		"enrtree://foo",
+		"enrtree://AAAA@nodes.example.org",
+		"enr:AAAA",
+	}
+	for _, input := range invalid {
+		if _, err := parseEntry(input, enode.ValidSchemes); err == nil {
+			t.Errorf("invalid entry %q accepted", input)
+		}
+	}
+}
+
+// Tests that the root entry is signed and verified.
+func TestRootSignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, err := MakeTree(3, testNodes(2, 5), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Sign(key, "nodes.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseRoot(tree.root.String())
+	if err != nil {
+		t.Fatalf("can't parse root: %v", err)
+	}
+	if root.seq != 3 || !root.verifySignature(&key.PublicKey) {
+		t.Fatalf("root mismatch: seq %d, valid signature %t", root.seq, root.verifySignature(&key.PublicKey))
+	}
+	other, _ := crypto.GenerateKey()
+	if root.verifySignature(&other.PublicKey) {
+		t.Fatalf("signature valid for the wrong key")
+	}
+	// Assigning the signature to an unsigned copy must verify it.
+	unsigned, _ := MakeTree(3, testNodes(2, 5), nil)
+	if err := unsigned.SetSignature(&other.PublicKey, tree.Signature()); err != errInvalidSig {
+		t.Fatalf("wrong error for bad signature: %v", err)
+	}
+	if err := unsigned.SetSignature(&key.PublicKey, tree.Signature()); err != nil {
+		t.Fatalf("can't set signature: %v", err)
+	}
+	if !reflect.DeepEqual(unsigned.ToTXT("nodes.example.org"), tree.ToTXT("nodes.example.org")) {
+		t.Fatalf("records of re-signed tree differ")
+	}
+}
+
+// Tests that large trees are split into branches of bounded size.
+func TestMakeTreeBranches(t *testing.T) {
+	nodes := testNodes(3, 200)
+	tree, err := MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hash, e := range tree.entries {
+		if b, ok := e.(*branchEntry); ok && len(b.children) > maxChildren {
+			t.Errorf("branch %s has %d children", hash, len(b.children))
+		}
+		if !strings.HasPrefix(e.String(), enrPrefix) && len(e.String()) > 400 {
+			t.Errorf("entry %s too large: %d bytes", hash, len(e.String()))
+		}
+	}
+	if have := tree.Nodes(); len(have) != len(nodes) {
+		t.Fatalf("node count mismatch: have %d, want %d", len(have), len(nodes))
+	}
+}
+
+// testNodes creates n nodes with deterministic keys derived from seed.
+func testNodes(seed byte, n int) []*enode.Node {
+	nodes := make([]*enode.Node, n)
+	for i := range nodes {
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte{seed, byte(i >> 8), byte(i)}))
+		if err != nil {
+			panic(err)
+		}
+		var r enr.Record
+		r.Set(enr.IP(net.IP{127, 0, 0, 1}))
+		r.Set(enr.TCP(30303))
+		r.Set(enr.UDP(30303))
+		if err := enode.SignV4(&r, key); err != nil {
+			panic(err)
+		}
+		if nodes[i], err = enode.New(enode.ValidSchemes, &r); err != nil {
+			panic(err)
+		}
+	}
+	return nodes
+}
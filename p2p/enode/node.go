@@ -18,6 +18,7 @@ package enode
 
 import (
 	"crypto/ecdsa"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -27,6 +28,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 // Node represents a host on the network.
@@ -48,6 +50,23 @@ func New(validSchemes enr.IdentityScheme, r *enr.Record) (*Node, error) {
 	return node, nil
 }
 
+// Parse decodes and verifies a textual node description. Both base64 encoded
+// node records ("enr:...") and node URLs ("enode://...") are accepted.
+func Parse(validSchemes enr.IdentityScheme, input string) (*Node, error) {
+	if !strings.HasPrefix(input, "enr:") {
+		return ParseV4(input)
+	}
+	bin, err := base64.RawURLEncoding.DecodeString(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	var r enr.Record
+	if err := rlp.DecodeBytes(bin, &r); err != nil {
+		return nil, err
+	}
+	return New(validSchemes, &r)
+}
+
 // ID returns the node identifier.
 func (n *Node) ID() ID {
 	return n.id
@@ -68,27 +87,57 @@ func (n *Node) Load(k enr.Entry) error {
 	return n.r.Load(k)
 }
 
-// IP returns the IP address of the node.
+// IP returns the IP address of the node. The IPv4 address is preferred if the
+// record has both an IPv4 and an IPv6 address.
 func (n *Node) IP() net.IP {
 	var ip net.IP
-	n.Load((*enr.IP)(&ip))
+	if n.Load((*enr.IP)(&ip)) == nil {
+		return ip
+	}
+	return n.IPv6()
+}
+
+// IPv6 returns the IPv6 address of the node, if present.
+func (n *Node) IPv6() net.IP {
+	var ip net.IP
+	n.Load((*enr.IPv6)(&ip))
 	return ip
 }
 
-// UDP returns the UDP port of the node.
+// UDP returns the UDP port of the node. For nodes reachable only via IPv6, the
+// IPv6-specific port is returned if the record has one.
 func (n *Node) UDP() int {
 	var port enr.UDP
+	if n.ipv6Only() {
+		var port6 enr.UDP6
+		if n.Load(&port6) == nil {
+			return int(port6)
+		}
+	}
 	n.Load(&port)
 	return int(port)
 }
 
-// UDP returns the TCP port of the node.
+// TCP returns the TCP port of the node. For nodes reachable only via IPv6, the
+// IPv6-specific port is returned if the record has one.
 func (n *Node) TCP() int {
 	var port enr.TCP
+	if n.ipv6Only() {
+		var port6 enr.TCP6
+		if n.Load(&port6) == nil {
+			return int(port6)
+		}
+	}
 	n.Load(&port)
 	return int(port)
 }
 
+// ipv6Only reports whether the record has an ip6 entry but no ip entry.
+func (n *Node) ipv6Only() bool {
+	var ip net.IP
+	return n.Load((*enr.IP)(&ip)) != nil && n.IPv6() != nil
+}
+
 // Pubkey returns the secp256k1 public key of the node, if present.
 func (n *Node) Pubkey() *ecdsa.PublicKey {
 	var key ecdsa.PublicKey
@@ -17,8 +17,10 @@
 package enode
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/p2p/enr"
@@ -60,3 +62,99 @@ func TestPythonInterop(t *testing.T) {
 		}
 	}
 }
+
+// Tests that node records are parsed from their base64 text encoding, with node
+// URLs as the fallback.
+func TestParse(t *testing.T) {
+	n, err := Parse(ValidSchemes, "enr:"+base64.RawURLEncoding.EncodeToString(pyRecord))
+	if err != nil {
+		t.Fatalf("can't parse record: %v", err)
+	}
+	if want := HexID("a448f24c6d18e575453db13171562b71999873db5b286df957af199ec94617f7"); n.ID() != want {
+		t.Errorf("wrong id: got %x, want %x", n.ID(), want)
+	}
+	if _, err := Parse(ValidSchemes, "enr:"+base64.RawURLEncoding.EncodeToString(pyRecord[:len(pyRecord)-1])); err == nil {
+		t.Errorf("truncated record parsed without error")
+	}
+	url := "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@127.0.0.1:30303"
+	if n, err := Parse(ValidSchemes, url); err != nil || n.String() != url {
+		t.Errorf("can't parse node URL: %v %v", n, err)
+	}
+}
+
+func TestNodeEndpoints(t *testing.T) {
+	id := ID{1}
+	type endpointTest struct {
+		name    string
+		node    *Node
+		wantIP  net.IP
+		wantUDP int
+		wantTCP int
+	}
+	tests := []endpointTest{
+		{
+			name: "no-addr",
+			node: func() *Node {
+				var r enr.Record
+				r.Set(enr.UDP(9000))
+				return SignNull(&r, id)
+			}(),
+			wantUDP: 9000,
+		},
+		{
+			name: "ipv4-only",
+			node: func() *Node {
+				var r enr.Record
+				r.Set(enr.IP{99, 22, 22, 1})
+				r.Set(enr.UDP(9000))
+				r.Set(enr.TCP(30303))
+				r.Set(enr.UDP6(9001))
+				return SignNull(&r, id)
+			}(),
+			wantIP:  net.IP{99, 22, 22, 1},
+			wantUDP: 9000,
+			wantTCP: 30303,
+		},
+		{
+			name: "ipv6-only",
+			node: func() *Node {
+				var r enr.Record
+				r.Set(enr.IPv6(net.ParseIP("2001::ff00:0042:8329")))
+				r.Set(enr.UDP(9000))
+				r.Set(enr.TCP(30303))
+				r.Set(enr.UDP6(9001))
+				return SignNull(&r, id)
+			}(),
+			wantIP:  net.ParseIP("2001::ff00:0042:8329"),
+			wantUDP: 9001,
+			wantTCP: 30303,
+		},
+		{
+			name: "ipv4-and-ipv6",
+			node: func() *Node {
+				var r enr.Record
+				r.Set(enr.IP{99, 22, 22, 1})
+				r.Set(enr.IPv6(net.ParseIP("2001::ff00:0042:8329")))
+				r.Set(enr.UDP(9000))
+				r.Set(enr.UDP6(9001))
+				r.Set(enr.TCP6(30304))
+				return SignNull(&r, id)
+			}(),
+			wantIP:  net.IP{99, 22, 22, 1},
+			wantUDP: 9000,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.wantIP.Equal(test.node.IP()) {
+				t.Errorf("node has wrong IP %v, want %v", test.node.IP(), test.wantIP)
+			}
+			if test.wantUDP != test.node.UDP() {
+				t.Errorf("node has wrong UDP port %d, want %d", test.node.UDP(), test.wantUDP)
+			}
+			if test.wantTCP != test.node.TCP() {
+				t.Errorf("node has wrong TCP port %d, want %d", test.node.TCP(), test.wantTCP)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"os"
 	"sync"
 	"time"
@@ -47,6 +48,9 @@ const (
 	dbDiscoverFindFails = dbDiscoverRoot + ":findfail"
 	dbLocalRoot         = ":local"
 	dbLocalSeq          = dbLocalRoot + ":seq"
+	dbPeerRoot          = ":peer"
+	dbPeerScore         = dbPeerRoot + ":score"
+	dbPeerScoreTime     = dbPeerRoot + ":scoretime"
 )
 
 var (
@@ -326,6 +330,23 @@ func (db *DB) UpdateFindFails(id ID, fails int) error {
 	return db.storeInt64(makeKey(id, dbDiscoverFindFails), int64(fails))
 }
 
+// PeerScore retrieves the reputation score of a node and the time it was last
+// updated.
+func (db *DB) PeerScore(id ID) (score float64, updated time.Time) {
+	score = math.Float64frombits(db.fetchUint64(makeKey(id, dbPeerScore)))
+	updated = time.Unix(0, db.fetchInt64(makeKey(id, dbPeerScoreTime)))
+	return score, updated
+}
+
+// UpdatePeerScore stores the reputation score of a node and the time it was
+// computed, with nanosecond precision.
+func (db *DB) UpdatePeerScore(id ID, score float64, updated time.Time) error {
+	if err := db.storeUint64(makeKey(id, dbPeerScore), math.Float64bits(score)); err != nil {
+		return err
+	}
+	return db.storeInt64(makeKey(id, dbPeerScoreTime), updated.UnixNano())
+}
+
 // LocalSeq retrieves the local record sequence counter.
 func (db *DB) localSeq(id ID) uint64 {
 	return db.fetchUint64(makeKey(id, dbLocalSeq))
@@ -142,6 +142,17 @@ func TestDBFetchStore(t *testing.T) {
 	if stored := db.FindFails(node.ID()); stored != num {
 		t.Errorf("find-node fails: value mismatch: have %v, want %v", stored, num)
 	}
+	// Check fetch/store operations on a peer score object
+	if score, updated := db.PeerScore(node.ID()); score != 0 || updated.UnixNano() != 0 {
+		t.Errorf("peer score: non-existing object: %v %v", score, updated)
+	}
+	score := -float64(num) / 3
+	if err := db.UpdatePeerScore(node.ID(), score, inst); err != nil {
+		t.Errorf("peer score: failed to update: %v", err)
+	}
+	if stored, updated := db.PeerScore(node.ID()); stored != score || !updated.Equal(inst) {
+		t.Errorf("peer score: value mismatch: have %v %v, want %v %v", stored, updated, score, inst)
+	}
 	// Check fetch/store operations on an actual node object
 	if stored := db.Node(node.ID()); stored != nil {
 		t.Errorf("node: non-existing object: %v", stored)
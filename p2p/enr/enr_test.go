@@ -70,6 +70,21 @@ func TestGetSetIP6(t *testing.T) {
 	assert.Equal(t, ip, ip2)
 }
 
+// TestGetSetIPv6 tests encoding/decoding and setting/getting of the ip6 key.
+func TestGetSetIPv6(t *testing.T) {
+	ip := IPv6{0x20, 0x01, 0x48, 0x60, 0, 0, 0x20, 0x01, 0, 0, 0, 0, 0, 0, 0x00, 0x68}
+	var r Record
+	r.Set(ip)
+
+	var ip2 IPv6
+	require.NoError(t, r.Load(&ip2))
+	assert.Equal(t, ip, ip2)
+
+	// IPv4 addresses belong in the ip key.
+	r.Set(WithEntry("ip6", []byte{192, 168, 0, 3}))
+	assert.Error(t, r.Load(&ip2))
+}
+
 // TestGetSetDiscPort tests encoding/decoding and setting/getting of the DiscPort key.
 func TestGetSetUDP(t *testing.T) {
 	port := UDP(30309)
@@ -37,6 +37,8 @@ const (
 	MetricsInboundTraffic   = "p2p/InboundTraffic"   // Name for the registered inbound traffic meter
 	MetricsOutboundConnects = "p2p/OutboundConnects" // Name for the registered outbound connects meter
 	MetricsOutboundTraffic  = "p2p/OutboundTraffic"  // Name for the registered outbound traffic meter
+	MetricsInboundMessages  = "p2p/InboundMessages"  // Prefix for the inbound meters of each protocol message type
+	MetricsOutboundMessages = "p2p/OutboundMessages" // Prefix for the outbound meters of each protocol message type
 
 	MeteredPeerLimit = 1024 // This amount of peers are individually metered
 )
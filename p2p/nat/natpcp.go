@@ -0,0 +1,336 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Port Control Protocol (RFC 6887) constants.
+const (
+	pcpPort       = 5351
+	pcpVersion    = 2
+	pcpOpMap      = 1
+	pcpResponse   = 0x80 // R bit of the opcode field
+	pcpHeaderSize = 24
+	pcpMapSize    = 36
+	pcpPacketSize = pcpHeaderSize + pcpMapSize
+	pcpMaxPacket  = 1100
+
+	pcpProtoTCP = 6
+	pcpProtoUDP = 17
+
+	// pcpProbeLifetime is the lifetime of the temporary mapping which is
+	// created to learn the external IP when no other mapping exists.
+	pcpProbeLifetime = 60 * time.Second
+)
+
+// pcpTimeouts are the response timeouts of a request's transmissions. They are
+// a lot shorter than the ones recommended by RFC 6887 because the gateway is on
+// the local network and discovery needs to be quick.
+var pcpTimeouts = []time.Duration{
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2 * time.Second,
+}
+
+var pcpResultCodes = []string{
+	"SUCCESS", "UNSUPP_VERSION", "NOT_AUTHORIZED", "MALFORMED_REQUEST",
+	"UNSUPP_OPCODE", "UNSUPP_OPTION", "MALFORMED_OPTION", "NETWORK_FAILURE",
+	"NO_RESOURCES", "UNSUPP_PROTOCOL", "USER_EX_QUOTA", "CANNOT_PROVIDE_EXTERNAL",
+	"ADDRESS_MISMATCH", "EXCESSIVE_REMOTE_PEERS",
+}
+
+var (
+	errPCPTimeout = errors.New("PCP request timed out")
+	// errPCPIgnore is returned by decodePCPMap for packets which aren't a
+	// response to the current request.
+	errPCPIgnore = errors.New("unrelated PCP packet")
+)
+
+// pcpError is a non-success result code returned by a PCP server.
+type pcpError uint8
+
+func (code pcpError) Error() string {
+	if int(code) < len(pcpResultCodes) {
+		return "PCP error " + pcpResultCodes[code]
+	}
+	return fmt.Sprintf("PCP error %d", uint8(code))
+}
+
+// pcp implements Interface using the Port Control Protocol, the successor of
+// NAT-PMP. It works with both IPv4 and IPv6 gateways.
+type pcp struct {
+	gw *net.UDPAddr
+
+	mu     sync.Mutex
+	nonces map[pcpMapping][12]byte // nonces of the mappings added by us
+	extIP  net.IP                  // external IP of the last mapping
+}
+
+// pcpMapping identifies a mapping. The server identifies mappings by their
+// internal port and protocol, and requires the same nonce for renewing and
+// deleting them.
+type pcpMapping struct {
+	proto   uint8
+	intport uint16
+}
+
+// pcpMapResult is the mapping assigned by a PCP server.
+type pcpMapResult struct {
+	lifetime time.Duration
+	extport  int
+	extIP    net.IP
+}
+
+func newPCP(gw *net.UDPAddr) *pcp {
+	return &pcp{gw: gw, nonces: make(map[pcpMapping][12]byte)}
+}
+
+func (n *pcp) String() string {
+	return fmt.Sprintf("PCP(%v)", n.gw.IP)
+}
+
+func (n *pcp) ExternalIP() (net.IP, error) {
+	n.mu.Lock()
+	ip := n.extIP
+	n.mu.Unlock()
+	if ip != nil {
+		return ip, nil
+	}
+
+	// PCP can't query the external address directly. Map the port of the
+	// request socket for a short while instead, the answer contains the
+	// external address.
+	conn, err := net.DialUDP("udp", nil, n.gw)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var (
+		m     = pcpMapping{pcpProtoUDP, uint16(conn.LocalAddr().(*net.UDPAddr).Port)}
+		nonce = n.nonce(m)
+	)
+	res, err := n.request(conn, m, nonce, 0, pcpProbeLifetime)
+	if err != nil {
+		return nil, err
+	}
+	n.request(conn, m, nonce, 0, 0)
+	n.forget(m)
+	return res.extIP, nil
+}
+
+func (n *pcp) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	if lifetime <= 0 {
+		return fmt.Errorf("lifetime must not be <= 0")
+	}
+	m, err := makePCPMapping(protocol, intport)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialUDP("udp", nil, n.gw)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := n.request(conn, m, n.nonce(m), extport, lifetime)
+	if err != nil {
+		return err
+	}
+	if res.extport != extport {
+		log.Debug("PCP server assigned different external port", "proto", protocol, "want", extport, "got", res.extport)
+	}
+	return nil
+}
+
+func (n *pcp) DeleteMapping(protocol string, extport, intport int) error {
+	m, err := makePCPMapping(protocol, intport)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialUDP("udp", nil, n.gw)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// A mapping is deleted by requesting it again with zero lifetime.
+	_, err = n.request(conn, m, n.nonce(m), 0, 0)
+	n.forget(m)
+	return err
+}
+
+func makePCPMapping(protocol string, intport int) (pcpMapping, error) {
+	m := pcpMapping{intport: uint16(intport)}
+	switch strings.ToLower(protocol) {
+	case "tcp":
+		m.proto = pcpProtoTCP
+	case "udp":
+		m.proto = pcpProtoUDP
+	default:
+		return m, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+	return m, nil
+}
+
+// nonce returns the nonce of a mapping, creating one if the mapping is new.
+func (n *pcp) nonce(m pcpMapping) [12]byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	nonce, ok := n.nonces[m]
+	if !ok {
+		rand.Read(nonce[:])
+		n.nonces[m] = nonce
+	}
+	return nonce
+}
+
+func (n *pcp) forget(m pcpMapping) {
+	n.mu.Lock()
+	delete(n.nonces, m)
+	n.mu.Unlock()
+}
+
+// request sends a MAP request and waits for the matching response, retransmitting
+// the request on timeout.
+func (n *pcp) request(conn *net.UDPConn, m pcpMapping, nonce [12]byte, extport int, lifetime time.Duration) (*pcpMapResult, error) {
+	clientIP := conn.LocalAddr().(*net.UDPAddr).IP
+	req := encodePCPMap(clientIP, m, nonce, extport, lifetime)
+	buf := make([]byte, pcpMaxPacket)
+	for _, timeout := range pcpTimeouts {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		conn.SetReadDeadline(deadline)
+		for {
+			nbytes, err := conn.Read(buf)
+			if err != nil {
+				if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			res, err := decodePCPMap(buf[:nbytes], nonce)
+			if err == errPCPIgnore {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if lifetime > 0 {
+				n.mu.Lock()
+				n.extIP = res.extIP
+				n.mu.Unlock()
+			}
+			return res, nil
+		}
+	}
+	return nil, errPCPTimeout
+}
+
+func encodePCPMap(clientIP net.IP, m pcpMapping, nonce [12]byte, extport int, lifetime time.Duration) []byte {
+	req := make([]byte, pcpPacketSize)
+	req[0] = pcpVersion
+	req[1] = pcpOpMap
+	binary.BigEndian.PutUint32(req[4:], uint32(lifetime/time.Second))
+	copy(req[8:24], clientIP.To16())
+
+	body := req[pcpHeaderSize:]
+	copy(body[0:12], nonce[:])
+	body[12] = m.proto
+	binary.BigEndian.PutUint16(body[16:], m.intport)
+	binary.BigEndian.PutUint16(body[18:], uint16(extport))
+	// The suggested external address is left unspecified. For IPv4 clients,
+	// the IPv4-mapped form of 0.0.0.0 says that an IPv4 address is wanted.
+	if clientIP.To4() != nil {
+		copy(body[20:36], net.IPv4zero.To16())
+	}
+	return req
+}
+
+func decodePCPMap(resp []byte, nonce [12]byte) (*pcpMapResult, error) {
+	if len(resp) < pcpHeaderSize || resp[1] != pcpResponse|pcpOpMap {
+		return nil, errPCPIgnore
+	}
+	if resp[0] != pcpVersion {
+		return nil, fmt.Errorf("unsupported PCP version %d", resp[0])
+	}
+	if len(resp) < pcpPacketSize {
+		return nil, errors.New("PCP response too short")
+	}
+	// Error responses carry the nonce as well, so the result code is only
+	// meaningful for responses to this request.
+	body := resp[pcpHeaderSize:]
+	if !bytes.Equal(body[0:12], nonce[:]) {
+		return nil, errPCPIgnore
+	}
+	if code := resp[3]; code != 0 {
+		return nil, pcpError(code)
+	}
+	extIP := make(net.IP, net.IPv6len)
+	copy(extIP, body[20:36])
+	if ip4 := extIP.To4(); ip4 != nil {
+		extIP = ip4
+	}
+	return &pcpMapResult{
+		lifetime: time.Duration(binary.BigEndian.Uint32(resp[4:])) * time.Second,
+		extport:  int(binary.BigEndian.Uint16(body[18:])),
+		extIP:    extIP,
+	}, nil
+}
+
+func discoverPCP() Interface {
+	// Probe all potential gateways and use the one that responds first.
+	gws := potentialGateways()
+	found := make(chan *pcp, len(gws))
+	for i := range gws {
+		n := newPCP(&net.UDPAddr{IP: gws[i], Port: pcpPort})
+		go func() {
+			if _, err := n.ExternalIP(); err != nil {
+				found <- nil
+			} else {
+				found <- n
+			}
+		}()
+	}
+	timeout := time.NewTimer(1 * time.Second)
+	defer timeout.Stop()
+	for range gws {
+		select {
+		case n := <-found:
+			if n != nil {
+				return n
+			}
+		case <-timeout.C:
+			return nil
+		}
+	}
+	return nil
+}
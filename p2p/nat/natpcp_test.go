@@ -0,0 +1,112 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/nat/nattest"
+)
+
+func TestPCP(t *testing.T) {
+	extIP := net.IP{33, 44, 55, 66}
+	srv, err := nattest.NewPCPServer("127.0.0.1:0", extIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	n := newPCP(srv.Addr())
+
+	// ExternalIP works without any mapping and doesn't leave one behind.
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal("ExternalIP failed:", err)
+	}
+	if !ip.Equal(extIP) {
+		t.Errorf("wrong external IP %v, want %v", ip, extIP)
+	}
+	if m := srv.Mappings(); len(m) != 0 {
+		t.Fatalf("probe mapping wasn't deleted: %v", m)
+	}
+
+	// Add and refresh a mapping.
+	if err := n.AddMapping("TCP", 30303, 30304, "test", 10*time.Minute); err != nil {
+		t.Fatal("AddMapping failed:", err)
+	}
+	if err := n.AddMapping("TCP", 30303, 30304, "test", 20*time.Minute); err != nil {
+		t.Fatal("refreshing mapping failed:", err)
+	}
+	want := []nattest.Mapping{{
+		Protocol:     "TCP",
+		InternalIP:   net.IP{127, 0, 0, 1},
+		InternalPort: 30304,
+		ExternalPort: 30303,
+		Lifetime:     20 * time.Minute,
+	}}
+	if m := srv.Mappings(); !reflect.DeepEqual(m, want) {
+		t.Fatalf("wrong mappings:\n got %+v\nwant %+v", m, want)
+	}
+
+	// Another client can't take over the mapping.
+	if err := newPCP(srv.Addr()).AddMapping("TCP", 30303, 30304, "test", time.Minute); err != pcpError(2) { // NOT_AUTHORIZED
+		t.Errorf("wrong error for mapping of other client: %v", err)
+	}
+
+	// Delete it.
+	if err := n.DeleteMapping("TCP", 30303, 30304); err != nil {
+		t.Fatal("DeleteMapping failed:", err)
+	}
+	if m := srv.Mappings(); len(m) != 0 {
+		t.Fatalf("mapping wasn't deleted: %v", m)
+	}
+}
+
+func TestPCPTimeout(t *testing.T) {
+	// Nothing answers on this socket.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	defer func(old []time.Duration) { pcpTimeouts = old }(pcpTimeouts)
+	pcpTimeouts = []time.Duration{20 * time.Millisecond, 40 * time.Millisecond}
+	n := newPCP(conn.LocalAddr().(*net.UDPAddr))
+	if _, err := n.ExternalIP(); err != errPCPTimeout {
+		t.Fatalf("wrong error %v, want %v", err, errPCPTimeout)
+	}
+}
+
+// Tests that error responses to other requests are ignored.
+func TestPCPDecodeOtherNonce(t *testing.T) {
+	var nonce, other [12]byte
+	nonce[0], other[0] = 1, 2
+	m := pcpMapping{proto: 6, intport: 30304}
+	resp := encodePCPMap(net.IP{127, 0, 0, 1}, m, other, 30303, time.Minute)
+	resp[1] |= pcpResponse
+	resp[3] = 2 // NOT_AUTHORIZED
+
+	if _, err := decodePCPMap(resp, nonce); err != errPCPIgnore {
+		t.Errorf("wrong error for response with other nonce: %v", err)
+	}
+	if _, err := decodePCPMap(resp, other); err != pcpError(2) {
+		t.Errorf("wrong error for response with matching nonce: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package nattest provides stand-in port mapping servers for testing NAT
+// traversal without a router. The servers keep track of the mappings requested
+// by clients, but don't forward any traffic.
+package nattest
+
+import (
+	"net"
+	"time"
+)
+
+// Mapping is a port mapping held by a stand-in server.
+type Mapping struct {
+	Protocol     string // "TCP" or "UDP"
+	InternalIP   net.IP
+	InternalPort int
+	ExternalPort int
+	Lifetime     time.Duration // requested lifetime, mappings don't expire
+}
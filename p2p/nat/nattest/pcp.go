@@ -0,0 +1,259 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nattest
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PCPPort is the port on which Port Control Protocol clients expect the server.
+const PCPPort = 5351
+
+// Port Control Protocol (RFC 6887) constants.
+const (
+	pcpVersion    = 2
+	pcpOpMap      = 1
+	pcpResponse   = 0x80
+	pcpHeaderSize = 24
+	pcpMapSize    = 36
+	pcpMaxPacket  = 1100
+
+	pcpProtoTCP = 6
+	pcpProtoUDP = 17
+)
+
+// PCP result codes.
+const (
+	pcpSuccess          = 0
+	pcpUnsuppVersion    = 1
+	pcpNotAuthorized    = 2
+	pcpMalformedRequest = 3
+	pcpUnsuppOpcode     = 4
+	pcpUnsuppProtocol   = 9
+	pcpAddressMismatch  = 12
+)
+
+// PCPServer is a stand-in Port Control Protocol server. It answers MAP
+// requests, assigning ports on its configured external IP.
+type PCPServer struct {
+	conn       *net.UDPConn
+	externalIP net.IP
+	epoch      time.Time
+	wg         sync.WaitGroup
+
+	mu       sync.Mutex
+	mappings map[pcpKey]*pcpMapping
+}
+
+// pcpKey identifies a mapping like a PCP server does: by internal address,
+// protocol and internal port.
+type pcpKey struct {
+	ip      string
+	proto   uint8
+	intport uint16
+}
+
+type pcpMapping struct {
+	Mapping
+	nonce [12]byte
+}
+
+// NewPCPServer starts a PCP server listening on the given UDP address. Clients
+// send requests to PCPPort of their gateway, so the server must usually listen
+// on that port, e.g. on "127.0.0.1:5351".
+func NewPCPServer(addr string, externalIP net.IP) (*PCPServer, error) {
+	uaddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", uaddr)
+	if err != nil {
+		return nil, err
+	}
+	s := &PCPServer{
+		conn:       conn,
+		externalIP: externalIP,
+		epoch:      time.Now(),
+		mappings:   make(map[pcpKey]*pcpMapping),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the listening address of the server.
+func (s *PCPServer) Addr() *net.UDPAddr {
+	return s.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// Close stops the server.
+func (s *PCPServer) Close() error {
+	err := s.conn.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Mappings returns the current mappings, ordered by external port.
+func (s *PCPServer) Mappings() []Mapping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Mapping, 0, len(s.mappings))
+	for _, m := range s.mappings {
+		list = append(list, m.Mapping)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ExternalPort < list[j].ExternalPort })
+	return list
+}
+
+func (s *PCPServer) serve() {
+	defer s.wg.Done()
+	buf := make([]byte, pcpMaxPacket)
+	for {
+		nbytes, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.handle(buf[:nbytes], from); resp != nil {
+			s.conn.WriteToUDP(resp, from)
+		}
+	}
+}
+
+// handle processes a request and returns the response.
+func (s *PCPServer) handle(req []byte, from *net.UDPAddr) []byte {
+	if len(req) < 2 || req[1]&pcpResponse != 0 {
+		return nil // ignore responses and garbage
+	}
+	switch {
+	case len(req) < pcpHeaderSize:
+		return s.errorResponse(req, pcpMalformedRequest)
+	case req[0] != pcpVersion:
+		return s.errorResponse(req, pcpUnsuppVersion)
+	case req[1] != pcpOpMap:
+		return s.errorResponse(req, pcpUnsuppOpcode)
+	case len(req) < pcpHeaderSize+pcpMapSize:
+		return s.errorResponse(req, pcpMalformedRequest)
+	case !net.IP(req[8:24]).Equal(from.IP):
+		return s.errorResponse(req, pcpAddressMismatch)
+	}
+
+	var (
+		body     = req[pcpHeaderSize : pcpHeaderSize+pcpMapSize]
+		lifetime = binary.BigEndian.Uint32(req[4:])
+		proto    = body[12]
+		key      = pcpKey{from.IP.String(), proto, binary.BigEndian.Uint16(body[16:])}
+		suggest  = binary.BigEndian.Uint16(body[18:])
+		nonce    [12]byte
+	)
+	copy(nonce[:], body[0:12])
+	if proto != pcpProtoTCP && proto != pcpProtoUDP {
+		return s.errorResponse(req, pcpUnsuppProtocol)
+	}
+
+	clientIP := from.IP
+	if ip4 := clientIP.To4(); ip4 != nil {
+		clientIP = ip4
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.mappings[key]
+	if m != nil && m.nonce != nonce {
+		return s.errorResponse(req, pcpNotAuthorized)
+	}
+	var extport uint16
+	switch {
+	case lifetime == 0:
+		// Delete the mapping.
+		delete(s.mappings, key)
+	case m != nil:
+		// Renew the mapping.
+		m.Lifetime = time.Duration(lifetime) * time.Second
+		extport = uint16(m.ExternalPort)
+	default:
+		// Create the mapping, using the suggested port if it's free.
+		if suggest == 0 {
+			suggest = key.intport
+		}
+		extport = s.freePort(proto, suggest)
+		s.mappings[key] = &pcpMapping{
+			Mapping: Mapping{
+				Protocol:     protoName(proto),
+				InternalIP:   clientIP,
+				InternalPort: int(key.intport),
+				ExternalPort: int(extport),
+				Lifetime:     time.Duration(lifetime) * time.Second,
+			},
+			nonce: nonce,
+		}
+	}
+
+	resp := s.responseHeader(req, pcpSuccess, lifetime)
+	resp = append(resp, body...)
+	rbody := resp[pcpHeaderSize:]
+	binary.BigEndian.PutUint16(rbody[18:], extport)
+	copy(rbody[20:36], s.externalIP.To16())
+	return resp
+}
+
+// freePort returns the first port, starting at port, which isn't the external
+// port of any mapping of the given protocol.
+func (s *PCPServer) freePort(proto uint8, port uint16) uint16 {
+	used := make(map[int]bool)
+	for key, m := range s.mappings {
+		if key.proto == proto {
+			used[m.ExternalPort] = true
+		}
+	}
+	for used[int(port)] || port == 0 {
+		port++
+	}
+	return port
+}
+
+func (s *PCPServer) errorResponse(req []byte, code uint8) []byte {
+	resp := s.responseHeader(req, code, 0)
+	// The opcode-specific data of the request is returned unchanged.
+	if len(req) > pcpHeaderSize {
+		resp = append(resp, req[pcpHeaderSize:]...)
+	}
+	if len(resp) > pcpMaxPacket {
+		resp = resp[:pcpMaxPacket]
+	}
+	return resp
+}
+
+func (s *PCPServer) responseHeader(req []byte, code uint8, lifetime uint32) []byte {
+	resp := make([]byte, pcpHeaderSize)
+	resp[0] = pcpVersion
+	resp[1] = req[1] | pcpResponse
+	resp[3] = code
+	binary.BigEndian.PutUint32(resp[4:], lifetime)
+	binary.BigEndian.PutUint32(resp[8:], uint32(time.Since(s.epoch)/time.Second))
+	return resp
+}
+
+func protoName(proto uint8) string {
+	if proto == pcpProtoTCP {
+		return "TCP"
+	}
+	return "UDP"
+}
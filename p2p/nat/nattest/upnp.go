@@ -0,0 +1,299 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nattest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huin/goupnp/httpu"
+)
+
+// UPnP constants of an Internet Gateway Device v1 with a WANIPConnection service.
+const (
+	upnpDeviceType  = "urn:schemas-upnp-org:device:WANConnectionDevice:1"
+	upnpServiceType = "urn:schemas-upnp-org:service:WANIPConnection:1"
+	upnpDeviceUUID  = "uuid:6e5d5a9c-1f0e-4c8b-9d7a-0e3f8f6c2b10"
+	upnpControlPath = "/ctl/IPConn"
+	upnpDescPath    = "/rootDesc.xml"
+)
+
+// ssdpAddr is the multicast address on which UPnP devices are discovered.
+var ssdpAddr = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+// UPnPServer is a stand-in UPnP Internet Gateway Device. It answers SSDP
+// searches for IGDv1 devices and implements the WANIPConnection actions used
+// for port mapping.
+//
+// Discovery uses the SSDP multicast port, so only one UPnPServer on a host
+// can be discovered at a time. If the port can't be joined, the device is only
+// reachable through its HTTP address.
+type UPnPServer struct {
+	externalIP net.IP
+	listener   net.Listener
+	mcast      *net.UDPConn // nil if SSDP is unavailable
+
+	mu       sync.Mutex
+	mappings map[upnpKey]Mapping
+}
+
+// upnpKey identifies a mapping like an IGD does: by protocol and external port.
+type upnpKey struct {
+	proto   string
+	extport int
+}
+
+// NewUPnPServer starts a UPnP device whose HTTP server listens on the given TCP
+// address, e.g. "127.0.0.1:0".
+func NewUPnPServer(addr string, externalIP net.IP) (*UPnPServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &UPnPServer{
+		externalIP: externalIP,
+		listener:   listener,
+		mappings:   make(map[upnpKey]Mapping),
+	}
+	if mcast, err := net.ListenMulticastUDP("udp4", nil, ssdpAddr); err == nil {
+		s.mcast = mcast
+		go httpu.Serve(mcast, httpu.HandlerFunc(s.serveSSDP))
+	}
+	go http.Serve(listener, s)
+	return s, nil
+}
+
+// Addr returns the listening address of the device's HTTP server.
+func (s *UPnPServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops the server.
+func (s *UPnPServer) Close() error {
+	if s.mcast != nil {
+		s.mcast.Close()
+	}
+	return s.listener.Close()
+}
+
+// Mappings returns the current mappings, ordered by external port.
+func (s *UPnPServer) Mappings() []Mapping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Mapping, 0, len(s.mappings))
+	for _, m := range s.mappings {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ExternalPort < list[j].ExternalPort })
+	return list
+}
+
+// serveSSDP answers search requests for the device.
+func (s *UPnPServer) serveSSDP(r *http.Request) {
+	st := r.Header.Get("ST")
+	if r.Method != "M-SEARCH" || (st != upnpDeviceType && st != "ssdp:all") {
+		return
+	}
+	if st == "ssdp:all" {
+		st = upnpDeviceType
+	}
+	conn, err := net.Dial("udp4", r.RemoteAddr)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\n"+
+		"Cache-Control: max-age=300\r\n"+
+		"Ext: \r\n"+
+		"Location: http://%s%s\r\n"+
+		"Server: nattest UPnP/1.0\r\n"+
+		"ST: %s\r\n"+
+		"USN: %s::%s\r\n"+
+		"\r\n", s.listener.Addr(), upnpDescPath, st, upnpDeviceUUID, st)
+}
+
+// ServeHTTP serves the device description and the control endpoint.
+func (s *UPnPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "GET" && r.URL.Path == upnpDescPath:
+		w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+		io.WriteString(w, upnpDeviceDesc)
+	case r.Method == "POST" && r.URL.Path == upnpControlPath:
+		s.serveControl(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// soapRequest is the envelope of a SOAP action request.
+type soapRequest struct {
+	Body struct {
+		Action struct {
+			XMLName xml.Name
+			Args    []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+func (s *UPnPServer) serveControl(w http.ResponseWriter, r *http.Request) {
+	var req soapRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	args := make(map[string]string)
+	for _, arg := range req.Body.Action.Args {
+		args[arg.XMLName.Local] = strings.TrimSpace(arg.Value)
+	}
+	from, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	action := req.Body.Action.XMLName.Local
+	var out map[string]string
+	var code int
+	switch action {
+	case "GetExternalIPAddress":
+		out = map[string]string{"NewExternalIPAddress": s.externalIP.String()}
+	case "GetNATRSIPStatus":
+		out = map[string]string{"NewRSIPAvailable": "0", "NewNATEnabled": "1"}
+	case "AddPortMapping":
+		code = s.addMapping(args, net.ParseIP(from))
+	case "DeletePortMapping":
+		code = s.deleteMapping(args)
+	default:
+		code = 401 // Invalid Action
+	}
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	if code != 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, soapFault, code)
+		return
+	}
+	var outXML strings.Builder
+	for name, value := range out {
+		fmt.Fprintf(&outXML, "<%s>%s</%s>", name, value, name)
+	}
+	fmt.Fprintf(w, soapResponse, action, upnpServiceType, outXML.String(), action)
+}
+
+// addMapping handles AddPortMapping and returns the UPnP error code.
+func (s *UPnPServer) addMapping(args map[string]string, from net.IP) int {
+	proto := strings.ToUpper(args["NewProtocol"])
+	extport, err1 := strconv.Atoi(args["NewExternalPort"])
+	intport, err2 := strconv.Atoi(args["NewInternalPort"])
+	lifetime, err3 := strconv.Atoi(args["NewLeaseDuration"])
+	client := net.ParseIP(args["NewInternalClient"])
+	if err1 != nil || err2 != nil || err3 != nil || client == nil || (proto != "TCP" && proto != "UDP") {
+		return 402 // Invalid Args
+	}
+	if !client.Equal(from) {
+		return 606 // Action not authorized
+	}
+	if ip4 := client.To4(); ip4 != nil {
+		client = ip4
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := upnpKey{proto, extport}
+	if m, ok := s.mappings[key]; ok && (!m.InternalIP.Equal(client) || m.InternalPort != intport) {
+		return 718 // ConflictInMappingEntry
+	}
+	s.mappings[key] = Mapping{
+		Protocol:     proto,
+		InternalIP:   client,
+		InternalPort: intport,
+		ExternalPort: extport,
+		Lifetime:     time.Duration(lifetime) * time.Second,
+	}
+	return 0
+}
+
+// deleteMapping handles DeletePortMapping and returns the UPnP error code.
+func (s *UPnPServer) deleteMapping(args map[string]string) int {
+	proto := strings.ToUpper(args["NewProtocol"])
+	extport, err := strconv.Atoi(args["NewExternalPort"])
+	if err != nil {
+		return 402 // Invalid Args
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := upnpKey{proto, extport}
+	if _, ok := s.mappings[key]; !ok {
+		return 714 // NoSuchEntryInArray
+	}
+	delete(s.mappings, key)
+	return 0
+}
+
+const soapResponse = `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body><u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body>
+</s:Envelope>`
+
+const soapFault = `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body><s:Fault>
+<faultcode>s:Client</faultcode>
+<faultstring>UPnPError</faultstring>
+<detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode></UPnPError></detail>
+</s:Fault></s:Body>
+</s:Envelope>`
+
+const upnpDeviceDesc = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+<specVersion><major>1</major><minor>0</minor></specVersion>
+<device>
+	<deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+	<friendlyName>nattest gateway</friendlyName>
+	<UDN>uuid:6e5d5a9c-1f0e-4c8b-9d7a-0e3f8f6c2b0e</UDN>
+	<deviceList>
+		<device>
+			<deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+			<friendlyName>WANDevice</friendlyName>
+			<UDN>uuid:6e5d5a9c-1f0e-4c8b-9d7a-0e3f8f6c2b0f</UDN>
+			<deviceList>
+				<device>
+					<deviceType>` + upnpDeviceType + `</deviceType>
+					<friendlyName>WANConnectionDevice</friendlyName>
+					<UDN>` + upnpDeviceUUID + `</UDN>
+					<serviceList>
+						<service>
+							<serviceType>` + upnpServiceType + `</serviceType>
+							<serviceId>urn:upnp-org:serviceId:WANIPConn1</serviceId>
+							<SCPDURL>/WANIPCn.xml</SCPDURL>
+							<controlURL>` + upnpControlPath + `</controlURL>
+							<eventSubURL>/evt/IPConn</eventSubURL>
+						</service>
+					</serviceList>
+				</device>
+			</deviceList>
+		</device>
+	</deviceList>
+</device>
+</root>`
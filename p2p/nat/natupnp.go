@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
@@ -101,28 +102,8 @@ func (n *upnp) String() string {
 // and returns the first one it can find on the local network.
 func discoverUPnP() Interface {
 	found := make(chan *upnp, 2)
-	// IGDv1
-	go discover(found, internetgateway1.URN_WANConnectionDevice_1, func(dev *goupnp.RootDevice, sc goupnp.ServiceClient) *upnp {
-		switch sc.Service.ServiceType {
-		case internetgateway1.URN_WANIPConnection_1:
-			return &upnp{dev, "IGDv1-IP1", &internetgateway1.WANIPConnection1{ServiceClient: sc}}
-		case internetgateway1.URN_WANPPPConnection_1:
-			return &upnp{dev, "IGDv1-PPP1", &internetgateway1.WANPPPConnection1{ServiceClient: sc}}
-		}
-		return nil
-	})
-	// IGDv2
-	go discover(found, internetgateway2.URN_WANConnectionDevice_2, func(dev *goupnp.RootDevice, sc goupnp.ServiceClient) *upnp {
-		switch sc.Service.ServiceType {
-		case internetgateway2.URN_WANIPConnection_1:
-			return &upnp{dev, "IGDv2-IP1", &internetgateway2.WANIPConnection1{ServiceClient: sc}}
-		case internetgateway2.URN_WANIPConnection_2:
-			return &upnp{dev, "IGDv2-IP2", &internetgateway2.WANIPConnection2{ServiceClient: sc}}
-		case internetgateway2.URN_WANPPPConnection_1:
-			return &upnp{dev, "IGDv2-PPP1", &internetgateway2.WANPPPConnection1{ServiceClient: sc}}
-		}
-		return nil
-	})
+	go discover(found, internetgateway1.URN_WANConnectionDevice_1, matchIGDv1)
+	go discover(found, internetgateway2.URN_WANConnectionDevice_2, matchIGDv2)
 	for i := 0; i < cap(found); i++ {
 		if c := <-found; c != nil {
 			return c
@@ -131,6 +112,30 @@ func discoverUPnP() Interface {
 	return nil
 }
 
+// matchIGDv1 returns a client for the IGDv1 connection services.
+func matchIGDv1(dev *goupnp.RootDevice, sc goupnp.ServiceClient) *upnp {
+	switch sc.Service.ServiceType {
+	case internetgateway1.URN_WANIPConnection_1:
+		return &upnp{dev, "IGDv1-IP1", &internetgateway1.WANIPConnection1{ServiceClient: sc}}
+	case internetgateway1.URN_WANPPPConnection_1:
+		return &upnp{dev, "IGDv1-PPP1", &internetgateway1.WANPPPConnection1{ServiceClient: sc}}
+	}
+	return nil
+}
+
+// matchIGDv2 returns a client for the IGDv2 connection services.
+func matchIGDv2(dev *goupnp.RootDevice, sc goupnp.ServiceClient) *upnp {
+	switch sc.Service.ServiceType {
+	case internetgateway2.URN_WANIPConnection_1:
+		return &upnp{dev, "IGDv2-IP1", &internetgateway2.WANIPConnection1{ServiceClient: sc}}
+	case internetgateway2.URN_WANIPConnection_2:
+		return &upnp{dev, "IGDv2-IP2", &internetgateway2.WANIPConnection2{ServiceClient: sc}}
+	case internetgateway2.URN_WANPPPConnection_1:
+		return &upnp{dev, "IGDv2-PPP1", &internetgateway2.WANPPPConnection1{ServiceClient: sc}}
+	}
+	return nil
+}
+
 // finds devices matching the given target and calls matcher for all
 // advertised services of each device. The first non-nil service found
 // is sent into out. If no service matched, nil is sent.
@@ -140,36 +145,43 @@ func discover(out chan<- *upnp, target string, matcher func(*goupnp.RootDevice,
 		out <- nil
 		return
 	}
-	found := false
-	for i := 0; i < len(devs) && !found; i++ {
+	for i := 0; i < len(devs); i++ {
 		if devs[i].Root == nil {
 			continue
 		}
-		devs[i].Root.Device.VisitServices(func(service *goupnp.Service) {
-			if found {
-				return
-			}
-			// check for a matching IGD service
-			sc := goupnp.ServiceClient{
-				SOAPClient: service.NewSOAPClient(),
-				RootDevice: devs[i].Root,
-				Location:   devs[i].Location,
-				Service:    service,
-			}
-			sc.SOAPClient.HTTPClient.Timeout = soapRequestTimeout
-			upnp := matcher(devs[i].Root, sc)
-			if upnp == nil {
-				return
-			}
-			// check whether port mapping is enabled
-			if _, nat, err := upnp.client.GetNATRSIPStatus(); err != nil || !nat {
-				return
-			}
+		if upnp := matchDevice(devs[i].Root, devs[i].Location, matcher); upnp != nil {
 			out <- upnp
-			found = true
-		})
-	}
-	if !found {
-		out <- nil
+			return
+		}
 	}
+	out <- nil
+}
+
+// matchDevice calls matcher for all advertised services of the device located
+// at loc and returns the first matching service which has port mapping enabled.
+func matchDevice(root *goupnp.RootDevice, loc *url.URL, matcher func(*goupnp.RootDevice, goupnp.ServiceClient) *upnp) *upnp {
+	var found *upnp
+	root.Device.VisitServices(func(service *goupnp.Service) {
+		if found != nil {
+			return
+		}
+		// check for a matching IGD service
+		sc := goupnp.ServiceClient{
+			SOAPClient: service.NewSOAPClient(),
+			RootDevice: root,
+			Location:   loc,
+			Service:    service,
+		}
+		sc.SOAPClient.HTTPClient.Timeout = soapRequestTimeout
+		upnp := matcher(root, sc)
+		if upnp == nil {
+			return
+		}
+		// check whether port mapping is enabled
+		if _, nat, err := upnp.client.GetNATRSIPStatus(); err != nil || !nat {
+			return
+		}
+		found = upnp
+	})
+	return found
 }
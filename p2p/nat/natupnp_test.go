@@ -21,10 +21,15 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/p2p/nat/nattest"
+	"github.com/huin/goupnp"
 	"github.com/huin/goupnp/httpu"
 )
 
@@ -174,6 +179,56 @@ func TestUPNP_DDWRT(t *testing.T) {
 	}
 }
 
+func TestUPnPMapping(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("disabled to avoid firewall prompt")
+	}
+	extIP := net.IP{33, 44, 55, 66}
+	srv, err := nattest.NewUPnPServer("127.0.0.1:0", extIP)
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer srv.Close()
+
+	// Point the client at the device directly instead of relying on SSDP
+	// multicast discovery.
+	loc := &url.URL{Scheme: "http", Host: srv.Addr().String(), Path: "/rootDesc.xml"}
+	root, err := goupnp.DeviceByURL(loc)
+	if err != nil {
+		t.Fatal("can't fetch device description:", err)
+	}
+	n := matchDevice(root, loc, matchIGDv1)
+	if n == nil {
+		t.Fatal("no port mapping service found")
+	}
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal("ExternalIP failed:", err)
+	}
+	if !ip.Equal(extIP) {
+		t.Errorf("wrong external IP %v, want %v", ip, extIP)
+	}
+	if err := n.AddMapping("udp", 30303, 30304, "test", 10*time.Minute); err != nil {
+		t.Fatal("AddMapping failed:", err)
+	}
+	want := []nattest.Mapping{{
+		Protocol:     "UDP",
+		InternalIP:   net.IP{127, 0, 0, 1},
+		InternalPort: 30304,
+		ExternalPort: 30303,
+		Lifetime:     10 * time.Minute,
+	}}
+	if m := srv.Mappings(); !reflect.DeepEqual(m, want) {
+		t.Fatalf("wrong mappings:\n got %+v\nwant %+v", m, want)
+	}
+	if err := n.DeleteMapping("udp", 30303, 30304); err != nil {
+		t.Fatal("DeleteMapping failed:", err)
+	}
+	if m := srv.Mappings(); len(m) != 0 {
+		t.Fatalf("mapping wasn't deleted: %v", m)
+	}
+}
+
 // fakeIGD presents itself as a discoverable UPnP device which sends
 // canned responses to HTTPU and HTTP requests.
 type fakeIGD struct {
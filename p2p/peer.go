@@ -113,6 +113,12 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// scores tracks the reputation of the peer if set
+	scores *peerScores
+
+	// stats counts the traffic of each protocol message type
+	stats trafficStats
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -190,6 +196,25 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 	return p
 }
 
+// Report adjusts the reputation score of the peer by the given amount. Protocols
+// should report positive amounts for useful and negative amounts for useless or
+// invalid data, using the scale of ScoreUseful, ScoreUseless and ScoreInvalid.
+// Scores decay over time. A peer whose score drops too low is disconnected and
+// isn't accepted or dialed again until the score has recovered, unless it is a
+// trusted or static peer.
+func (p *Peer) Report(delta int) {
+	score := p.scores.add(p.ID(), int64(delta))
+	if delta < 0 && score < lowPeerScore && !p.rw.is(trustedConn|staticDialedConn) {
+		p.log.Debug("Disconnecting peer with low score", "score", score)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
+// Score returns the current reputation score of the peer.
+func (p *Peer) Score() int64 {
+	return p.scores.score(p.ID())
+}
+
 func (p *Peer) Log() log.Logger {
 	return p.log
 }
@@ -298,6 +323,7 @@ func (p *Peer) handle(msg Msg) error {
 		if err != nil {
 			return fmt.Errorf("msg code out of range: %v", msg.Code)
 		}
+		p.stats.ingress(proto, msg.Code-proto.offset, msg.Size)
 		select {
 		case proto.in <- msg:
 			return nil
@@ -352,6 +378,10 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
+		proto.stats = &p.stats
+		if t, ok := p.rw.transport.(*rlpx); ok {
+			proto.limiter = t.limiter
+		}
 		var rw MsgReadWriter = proto
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name)
@@ -390,16 +420,34 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+	stats  *trafficStats // counts the traffic of the peer, may be nil
+
+	limiter *connLimiter // bandwidth limiter of the connection, may be nil
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled")
 	}
+	code := msg.Code
 	msg.Code += rw.offset
+	// Wait for the bandwidth share of the protocol before taking the write
+	// slot, so a throttled protocol doesn't block the others.
+	if d := rw.limiter.shareDelay(msg.Code); d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-rw.closed:
+			timer.Stop()
+			return ErrShuttingDown
+		}
+	}
 	select {
 	case <-rw.wstart:
 		err = rw.w.WriteMsg(msg)
+		if err == nil && rw.stats != nil {
+			rw.stats.egress(rw, code, msg.Size)
+		}
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
 		// otherwise. The calling protocol code should exit for errors
@@ -421,6 +469,15 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 	}
 }
 
+// Stats returns the traffic counters of the peer for each protocol message type.
+func (p *Peer) Stats() *PeerStats {
+	return &PeerStats{
+		ID:        p.ID().String(),
+		Name:      p.Name(),
+		Protocols: p.stats.snapshot(),
+	}
+}
+
 // PeerInfo represents a short summary of the information known about a connected
 // peer. Sub-protocol independent fields are contained and initialized here, with
 // protocol specifics delegated to all connected sub-protocols.
@@ -429,6 +486,7 @@ type PeerInfo struct {
 	ID      string   `json:"id"`    // Unique node identifier
 	Name    string   `json:"name"`  // Name of the node, including client type, version, OS, custom data
 	Caps    []string `json:"caps"`  // Protocols advertised by this peer
+	Score   int64    `json:"score"` // Reputation score of the peer
 	Network struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
@@ -452,6 +510,7 @@ func (p *Peer) Info() *PeerInfo {
 		ID:        p.ID().String(),
 		Name:      p.Name(),
 		Caps:      caps,
+		Score:     p.Score(),
 		Protocols: make(map[string]interface{}),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
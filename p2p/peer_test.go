@@ -24,6 +24,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 var discard = Protocol{
@@ -98,6 +100,51 @@ func TestPeerProtoReadMsg(t *testing.T) {
 	}
 }
 
+func TestPeerTrafficStats(t *testing.T) {
+	proto := Protocol{
+		Name:    "a",
+		Version: 1,
+		Length:  5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for i := uint(1); i <= 2; i++ {
+				if err := ExpectMsg(rw, 2, []uint{i}); err != nil {
+					t.Error(err)
+				}
+			}
+			return SendItems(rw, 4, "foo")
+		},
+	}
+
+	closer, rw, peer, errc := testPeer([]Protocol{proto})
+	defer closer()
+
+	Send(rw, baseProtocolLength+2, []uint{1})
+	Send(rw, baseProtocolLength+2, []uint{2})
+	if err := ExpectMsg(rw, baseProtocolLength+4, []interface{}{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err != errProtocolReturned {
+			t.Errorf("peer returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("receive timeout")
+	}
+
+	inSize, _ := rlp.EncodeToBytes([]uint{1})
+	outSize, _ := rlp.EncodeToBytes([]interface{}{"foo"})
+	want := map[string]map[string]MsgStats{
+		"a/1": {
+			"0x02": {IngressPackets: 2, IngressBytes: 2 * uint64(len(inSize))},
+			"0x04": {EgressPackets: 1, EgressBytes: uint64(len(outSize))},
+		},
+	}
+	if stats := peer.Stats(); !reflect.DeepEqual(stats.Protocols, want) {
+		t.Errorf("wrong traffic stats:\nhave %+v\nwant %+v", stats.Protocols, want)
+	}
+}
+
 func TestPeerProtoEncodeMsg(t *testing.T) {
 	proto := Protocol{
 		Name:   "a",
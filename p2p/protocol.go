@@ -56,6 +56,17 @@ type Protocol struct {
 
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
+
+	// DialCandidates, if non-nil, is a way to tell Server about protocol-specific
+	// nodes that should be dialed. The server continuously reads nodes from the
+	// iterator and attempts to create connections to them. The iterator is
+	// closed when the server stops.
+	DialCandidates enode.Iterator
+
+	// DialFilter, if non-nil, is consulted before dialing any node found by the
+	// discovery sources of the server. Nodes for which it returns false are not
+	// dialed, e.g. because their record announces an incompatible chain.
+	DialFilter func(*enode.Node) bool
 }
 
 func (p Protocol) cap() Cap {
@@ -234,12 +234,28 @@ func (p *Peer) Run(handler func(ctx context.Context, msg interface{}) error) err
 				metrics.GetOrRegisterCounter("peer.handleincoming.error", nil).Inc(1)
 				log.Error("peer.handleIncoming", "err", err)
 			}
+			if isInvalidMsgError(err) {
+				p.Report(p2p.ScoreInvalid)
+			}
 
 			return err
 		}
 	}
 }
 
+// isInvalidMsgError reports whether err was caused by a malformed message.
+func isInvalidMsgError(err error) bool {
+	perr, ok := err.(*Error)
+	if !ok {
+		return false
+	}
+	switch perr.Code {
+	case ErrMsgTooLong, ErrDecode, ErrInvalidMsgCode, ErrInvalidMsgType:
+		return true
+	}
+	return false
+}
+
 // Drop disconnects a peer.
 // TODO: may need to implement protocol drop only? don't want to kick off the peer
 // if they are useful for other protocols
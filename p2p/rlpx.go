@@ -74,6 +74,10 @@ const (
 // the allowed 24 bits (i.e. length >= 16MB).
 var errPlainMessageTooLarge = errors.New("message length >= 16MB")
 
+// errConnClosed is returned by WriteMsg if the connection is closed while the
+// message is held back by the bandwidth limits.
+var errConnClosed = errors.New("connection closed")
+
 // rlpx is the transport protocol used by actual (non-test) connections.
 // It wraps the frame encoder with locks and read/write deadlines.
 type rlpx struct {
@@ -81,28 +85,63 @@ type rlpx struct {
 
 	rmu, wmu sync.Mutex
 	rw       *rlpxFrameRW
+
+	limiter   *connLimiter  // throttles traffic, set after the protocol handshake
+	closing   chan struct{} // closed when the connection is closed
+	closeOnce sync.Once
 }
 
 func newRLPX(fd net.Conn) transport {
 	fd.SetDeadline(time.Now().Add(handshakeTimeout))
-	return &rlpx{fd: fd}
+	return &rlpx{fd: fd, closing: make(chan struct{})}
 }
 
 func (t *rlpx) ReadMsg() (Msg, error) {
 	t.rmu.Lock()
 	defer t.rmu.Unlock()
 	t.fd.SetReadDeadline(time.Now().Add(frameReadTimeout))
-	return t.rw.ReadMsg()
+	msg, err := t.rw.ReadMsg()
+	if err == nil {
+		// Delaying the next read keeps the remote end from sending
+		// faster than the download limit.
+		t.throttle(t.limiter.ingressDelay(msg.Code, msg.Size))
+	}
+	return msg, err
 }
 
 func (t *rlpx) WriteMsg(msg Msg) error {
+	if !t.throttle(t.limiter.egressDelay(msg.Code)) {
+		return errConnClosed
+	}
 	t.wmu.Lock()
 	defer t.wmu.Unlock()
 	t.fd.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
-	return t.rw.WriteMsg(msg)
+	if err := t.rw.WriteMsg(msg); err != nil {
+		return err
+	}
+	// Only messages actually sent count towards the upload limits.
+	t.limiter.sent(msg.Code, msg.Size)
+	return nil
+}
+
+// throttle waits for the given time. It returns false if the connection was
+// closed while waiting.
+func (t *rlpx) throttle(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-t.closing:
+		return false
+	}
 }
 
 func (t *rlpx) close(err error) {
+	t.closeOnce.Do(func() { close(t.closing) })
 	t.wmu.Lock()
 	defer t.wmu.Unlock()
 	// Tell the remote end why we're disconnecting if possible.
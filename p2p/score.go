@@ -0,0 +1,171 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Score adjustments for common peer events. Protocols may report other amounts
+// through Peer.Report, these values merely define the scale.
+const (
+	ScoreUseful  = 1   // peer delivered useful data, e.g. new transactions
+	ScoreUseless = -5  // peer sent data that wasn't useful, e.g. timed out or unrequested replies
+	ScoreInvalid = -20 // peer sent invalid data, e.g. a bad block or a malformed message
+)
+
+const (
+	scoreHalfLife      = time.Hour       // time after which a score has decayed halfway to zero
+	scoreFlushInterval = 5 * time.Minute // maximum time score updates are kept in memory only
+	maxPeerScore       = 100             // upper bound of scores, limits how much good behaviour is remembered
+	minPeerScore       = -1000           // lower bound of scores
+
+	// Nodes scoring below this threshold are disconnected, not dialed and
+	// not accepted. Trusted and static nodes are exempt.
+	lowPeerScore = -50
+)
+
+// peerScores tracks the reputation scores of nodes. The scores decay exponentially
+// towards zero over time. Updated scores are kept in memory and written to the
+// node database when the peer is dropped or when the flush interval has passed.
+type peerScores struct {
+	db  *enode.DB
+	now func() time.Time // for testing
+
+	mu        sync.Mutex
+	pending   map[enode.ID]peerScore // updated scores not yet stored
+	lastFlush time.Time
+}
+
+// peerScore is a score and the time it was computed at.
+type peerScore struct {
+	score   float64
+	updated time.Time
+}
+
+func newPeerScores(db *enode.DB) *peerScores {
+	return &peerScores{db: db, now: time.Now, pending: make(map[enode.ID]peerScore)}
+}
+
+// score returns the current score of a node, rounded towards zero.
+func (s *peerScores) score(id enode.ID) int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(s.current(id))
+}
+
+// isLow reports whether the score of a node is below the acceptance threshold.
+func (s *peerScores) isLow(id enode.ID) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.current(id) < lowPeerScore
+}
+
+// current returns the decayed score of a node. The caller must hold s.mu.
+func (s *peerScores) current(id enode.ID) float64 {
+	entry, ok := s.pending[id]
+	if !ok {
+		entry.score, entry.updated = s.db.PeerScore(id)
+	}
+	return decayScore(entry.score, s.now().Sub(entry.updated))
+}
+
+// add adjusts the score of a node and returns the new score, rounded towards zero.
+func (s *peerScores) add(id enode.ID, delta int64) int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	score := s.current(id) + float64(delta)
+	if score > maxPeerScore {
+		score = maxPeerScore
+	} else if score < minPeerScore {
+		score = minPeerScore
+	}
+	now := s.now()
+	s.pending[id] = peerScore{score, now}
+
+	if s.lastFlush.IsZero() {
+		s.lastFlush = now
+	} else if now.Sub(s.lastFlush) >= scoreFlushInterval {
+		s.flushAll()
+	}
+	return int64(score)
+}
+
+// flush stores the pending score of a node, e.g. when the peer is dropped.
+func (s *peerScores) flush(id enode.ID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.pending[id]; ok {
+		s.store(id, entry)
+		delete(s.pending, id)
+	}
+}
+
+// close stores all pending scores.
+func (s *peerScores) close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushAll()
+}
+
+// flushAll stores all pending scores. The caller must hold s.mu.
+func (s *peerScores) flushAll() {
+	for id, entry := range s.pending {
+		s.store(id, entry)
+		delete(s.pending, id)
+	}
+	s.lastFlush = s.now()
+}
+
+func (s *peerScores) store(id enode.ID, entry peerScore) {
+	if err := s.db.UpdatePeerScore(id, entry.score, entry.updated); err != nil {
+		log.Warn("Failed to store peer score", "id", id, "err", err)
+	}
+}
+
+// decayScore returns the remaining score after the given time has elapsed.
+func decayScore(score float64, elapsed time.Duration) float64 {
+	if score == 0 || elapsed <= 0 {
+		return score
+	}
+	return score * math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+func TestDecayScore(t *testing.T) {
+	tests := []struct {
+		score   float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, time.Hour, 0},
+		{100, 0, 100},
+		{100, -time.Hour, 100},
+		{100, scoreHalfLife, 50},
+		{-100, scoreHalfLife, -50},
+		{-100, 2 * scoreHalfLife, -25},
+		{-1, scoreHalfLife, -0.5},
+	}
+	for _, test := range tests {
+		if have := decayScore(test.score, test.elapsed); have != test.want {
+			t.Errorf("decayScore(%v, %v) = %v, want %v", test.score, test.elapsed, have, test.want)
+		}
+	}
+}
+
+func TestPeerScores(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		now    = time.Unix(1000000, 0)
+		scores = newPeerScores(db)
+		id     = randomID()
+	)
+	scores.now = func() time.Time { return now }
+
+	if score := scores.add(id, ScoreInvalid); score != ScoreInvalid {
+		t.Fatalf("wrong score after first report: %d", score)
+	}
+	if score := scores.add(id, 2*ScoreInvalid); score != 3*ScoreInvalid {
+		t.Fatalf("wrong score after second report: %d", score)
+	}
+	if !scores.isLow(id) {
+		t.Fatal("score not low")
+	}
+	// The score recovers over time.
+	now = now.Add(scoreHalfLife)
+	if score := scores.score(id); score != 3*ScoreInvalid/2 {
+		t.Fatalf("wrong score after decay: %d", score)
+	}
+	if scores.isLow(id) {
+		t.Fatal("score still low after decay")
+	}
+	// Scores are bounded.
+	scores.add(id, 10*maxPeerScore)
+	if score := scores.score(id); score != maxPeerScore {
+		t.Fatalf("score %d exceeds bound %d", score, maxPeerScore)
+	}
+	scores.add(id, 10*minPeerScore)
+	if score := scores.score(id); score != minPeerScore {
+		t.Fatalf("score %d exceeds bound %d", score, minPeerScore)
+	}
+}
+
+// This test checks that frequent small updates accumulate instead of being lost
+// to rounding, and that they are only stored when flushed.
+func TestPeerScoresFrequentUpdates(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		now    = time.Unix(1000000, 0)
+		scores = newPeerScores(db)
+		id     = randomID()
+	)
+	scores.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		scores.add(id, ScoreUseful)
+		now = now.Add(100 * time.Millisecond)
+	}
+	if score := scores.score(id); score != 99 {
+		t.Fatalf("wrong score after frequent updates: %d", score)
+	}
+	if score, _ := db.PeerScore(id); score != 0 {
+		t.Fatalf("score stored before flush: %v", score)
+	}
+	scores.flush(id)
+
+	// The stored score must be exact, reload it into a fresh tracker.
+	want := scores.current(id)
+	reloaded := newPeerScores(db)
+	reloaded.now = scores.now
+	if have := reloaded.current(id); have != want {
+		t.Fatalf("wrong score after reload: have %v, want %v", have, want)
+	}
+	// Pending scores are stored once the flush interval has passed.
+	other := randomID()
+	scores.add(other, ScoreInvalid)
+	now = now.Add(scoreFlushInterval)
+	scores.add(id, ScoreUseful)
+	if score, _ := db.PeerScore(other); score != ScoreInvalid {
+		t.Fatalf("pending score not stored after flush interval: %v", score)
+	}
+}
+
+// This test checks that connections from nodes with a low score are rejected,
+// unless the node is trusted.
+func TestServerRejectLowScore(t *testing.T) {
+	trustedNode := newkey()
+	trustedID := enode.PubkeyToIDV4(&trustedNode.PublicKey)
+	srv := &Server{
+		Config: Config{
+			PrivateKey:   newkey(),
+			MaxPeers:     10,
+			NoDial:       true,
+			TrustedNodes: []*enode.Node{newNode(trustedID, nil)},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&trustedNode.PublicKey, fd)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+
+	badID := randomID()
+	srv.scores.add(badID, 5*ScoreInvalid)
+	srv.scores.add(trustedID, 5*ScoreInvalid)
+
+	if err := srv.checkpoint(newconn(badID), srv.posthandshake); err != DiscUselessPeer {
+		t.Error("wrong error for low score conn:", err)
+	}
+	if err := srv.checkpoint(newconn(trustedID), srv.posthandshake); err != nil {
+		t.Error("unexpected error for trusted conn:", err)
+	}
+	if err := srv.checkpoint(newconn(randomID()), srv.posthandshake); err != nil {
+		t.Error("unexpected error for unscored conn:", err)
+	}
+	var r enr.Record
+	r.Set(enr.IP{127, 0, 0, 1})
+	r.Set(enr.TCP(30303))
+	if srv.isDialCandidate(enode.SignNull(&r, badID)) {
+		t.Error("low score node is dial candidate")
+	}
+	if !srv.isDialCandidate(enode.SignNull(&r, randomID())) {
+		t.Error("unscored node is not dial candidate")
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/p2p/nat"
@@ -96,6 +97,11 @@ type Config struct {
 	// with the rest of the network.
 	BootstrapNodes []*enode.Node
 
+	// DiscoveryDNS contains the enrtree:// URLs of DNS discovery trees. The
+	// nodes of these trees and of all trees linked from them are dialed
+	// alongside the nodes found by the discovery table.
+	DiscoveryDNS []string `toml:",omitempty"`
+
 	// BootstrapNodesV5 are used to establish connectivity
 	// with the rest of the network using the V5 discovery
 	// protocol.
@@ -143,6 +149,10 @@ type Config struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
+	// Bandwidth limits the traffic of peer connections. The limits can be
+	// changed while the server is running using SetBandwidthLimits.
+	Bandwidth BandwidthLimits `toml:",omitempty"`
+
 	// If EnableMsgEvents is set then the server will emit PeerEvents
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
@@ -165,8 +175,12 @@ type Server struct {
 	running bool
 
 	nodedb       *enode.DB
+	scores       *peerScores
+	bandwidth    *bandwidth
 	localnode    *enode.LocalNode
 	ntab         discoverTable
+	discmix      *enode.FairMix   // mix of all discovery sources
+	candidates   chan *enode.Node // dial candidates drawn from discmix
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
@@ -362,6 +376,13 @@ func (srv *Server) Self() *enode.Node {
 	return ln.Node()
 }
 
+// LocalNode returns the local node record. It is nil before the server is started.
+func (srv *Server) LocalNode() *enode.LocalNode {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.localnode
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
@@ -433,6 +454,10 @@ func (srv *Server) Start() (err error) {
 	if srv.Dialer == nil {
 		srv.Dialer = TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	if err := srv.Bandwidth.validate(); err != nil {
+		return err
+	}
+	srv.bandwidth = newBandwidth(srv.Bandwidth, mclock.System{})
 	srv.quit = make(chan struct{})
 	srv.addpeer = make(chan *conn)
 	srv.delpeer = make(chan peerDrop)
@@ -458,7 +483,9 @@ func (srv *Server) Start() (err error) {
 
 	dynPeers := srv.maxDialedConns()
 	dialer := newDialState(srv.localnode.ID(), srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
-	srv.loopWG.Add(1)
+	srv.candidates = make(chan *enode.Node, lookupBatchSize)
+	srv.loopWG.Add(2)
+	go srv.feedCandidates(enode.Filter(srv.discmix, srv.isDialCandidate))
 	go srv.run(dialer)
 	return nil
 }
@@ -478,6 +505,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.scores = newPeerScores(db)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	srv.localnode.Set(capsByNameAndVersion(srv.ourHandshake.Caps))
@@ -496,12 +524,14 @@ func (srv *Server) setupLocalNode() error {
 		srv.localnode.SetStaticIP(ip)
 	default:
 		// Ask the router about the IP. This takes a while and blocks startup,
-		// do it in the background.
+		// do it in the background. The router's answer is only used as the
+		// fallback: endpoint statements made by peers tell us how we are
+		// actually reached and take precedence.
 		srv.loopWG.Add(1)
 		go func() {
 			defer srv.loopWG.Done()
 			if ip, err := srv.NAT.ExternalIP(); err == nil {
-				srv.localnode.SetStaticIP(ip)
+				srv.localnode.SetFallbackIP(ip)
 			}
 		}()
 	}
@@ -509,6 +539,25 @@ func (srv *Server) setupLocalNode() error {
 }
 
 func (srv *Server) setupDiscovery() error {
+	srv.discmix = enode.NewFairMix(discmixTimeout)
+
+	// Add protocol-specific discovery sources.
+	added := make(map[string]bool)
+	for _, proto := range srv.Protocols {
+		if proto.DialCandidates != nil && !added[proto.Name] {
+			srv.discmix.AddSource(proto.DialCandidates)
+			added[proto.Name] = true
+		}
+	}
+	// Add the DNS discovery trees.
+	if len(srv.DiscoveryDNS) > 0 {
+		client := dnsdisc.NewClient(dnsdisc.Config{Logger: srv.log})
+		it, err := client.NewIterator(srv.DiscoveryDNS...)
+		if err != nil {
+			return err
+		}
+		srv.discmix.AddSource(it)
+	}
 	if srv.NoDiscovery && !srv.DiscoveryV5 {
 		return nil
 	}
@@ -549,6 +598,7 @@ func (srv *Server) setupDiscovery() error {
 			return err
 		}
 		srv.ntab = ntab
+		srv.discmix.AddSource(ntab.RandomNodes())
 	}
 	// Discovery V5
 	if srv.DiscoveryV5 {
@@ -606,6 +656,7 @@ func (srv *Server) run(dialstate dialer) {
 	srv.log.Info("Started P2P networking", "self", srv.localnode.Node())
 	defer srv.loopWG.Done()
 	defer srv.nodedb.Close()
+	defer srv.scores.close()
 
 	var (
 		peers        = make(map[enode.ID]*Peer)
@@ -730,6 +781,7 @@ running:
 				if srv.EnableMsgEvents {
 					p.events = &srv.peerFeed
 				}
+				p.scores = srv.scores
 				name := truncateName(c.name)
 				srv.log.Debug("Adding p2p peer", "name", name, "addr", c.fd.RemoteAddr(), "peers", len(peers)+1)
 				go srv.runPeer(p)
@@ -751,6 +803,7 @@ running:
 			d := common.PrettyDuration(mclock.Now() - pd.created)
 			pd.log.Debug("Removing p2p peer", "duration", d, "peers", len(peers)-1, "req", pd.requested, "err", pd.err)
 			delete(peers, pd.ID())
+			srv.scores.flush(pd.ID())
 			if pd.Inbound() {
 				inboundCount--
 			}
@@ -763,6 +816,9 @@ running:
 	if srv.ntab != nil {
 		srv.ntab.Close()
 	}
+	if srv.discmix != nil {
+		srv.discmix.Close()
+	}
 	if srv.DiscV5 != nil {
 		srv.DiscV5.Close()
 	}
@@ -800,6 +856,8 @@ func (srv *Server) encHandshakeChecks(peers map[enode.ID]*Peer, inboundCount int
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case !c.is(trustedConn|staticDialedConn) && srv.scores.isLow(c.node.ID()):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -809,7 +867,7 @@ func (srv *Server) maxInboundConns() int {
 	return srv.MaxPeers - srv.maxDialedConns()
 }
 func (srv *Server) maxDialedConns() int {
-	if srv.NoDiscovery || srv.NoDial {
+	if !srv.hasDiscoverySources() || srv.NoDial {
 		return 0
 	}
 	r := srv.DialRatio
@@ -819,6 +877,55 @@ func (srv *Server) maxDialedConns() int {
 	return srv.MaxPeers / r
 }
 
+// hasDiscoverySources reports whether the server has any source of dynamic
+// dial candidates.
+func (srv *Server) hasDiscoverySources() bool {
+	if !srv.NoDiscovery || len(srv.DiscoveryDNS) > 0 {
+		return true
+	}
+	for _, proto := range srv.Protocols {
+		if proto.DialCandidates != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// feedCandidates runs in its own goroutine and moves nodes from the discovery
+// sources to the candidate queue read by discover tasks.
+func (srv *Server) feedCandidates(it enode.Iterator) {
+	defer srv.loopWG.Done()
+
+	for it.Next() {
+		select {
+		case srv.candidates <- it.Node():
+		case <-srv.quit:
+			return
+		}
+	}
+}
+
+// isDialCandidate filters the nodes of the discovery sources, accepting nodes
+// which announce a TCP endpoint within the NetRestrict list, don't have a low
+// score and pass the dial filters of all protocols.
+func (srv *Server) isDialCandidate(n *enode.Node) bool {
+	if n.IP() == nil || n.TCP() == 0 {
+		return false
+	}
+	if srv.NetRestrict != nil && !srv.NetRestrict.Contains(n.IP()) {
+		return false
+	}
+	if srv.scores.isLow(n.ID()) {
+		return false
+	}
+	for _, proto := range srv.Protocols {
+		if proto.DialFilter != nil && !proto.DialFilter(n) {
+			return false
+		}
+	}
+	return true
+}
+
 // listenLoop runs in its own goroutine and accepts
 // inbound connections.
 func (srv *Server) listenLoop() {
@@ -941,6 +1048,9 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *enode.Node) erro
 		return DiscUnexpectedIdentity
 	}
 	c.caps, c.name = phs.Caps, phs.Name
+	if t, ok := c.transport.(*rlpx); ok {
+		t.limiter = srv.bandwidth.newConnLimiter(matchProtocols(srv.Protocols, c.caps, nil))
+	}
 	err = srv.checkpoint(c, srv.addpeer)
 	if err != nil {
 		clog.Trace("Rejected peer", "err", err)
@@ -1079,3 +1189,37 @@ func (srv *Server) PeersInfo() []*PeerInfo {
 	}
 	return infos
 }
+
+// PeersStats returns the per message type traffic counters of all connected
+// peers, sorted by node identifier.
+func (srv *Server) PeersStats() []*PeerStats {
+	var stats []*PeerStats
+	for _, peer := range srv.Peers() {
+		if peer != nil {
+			stats = append(stats, peer.Stats())
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
+}
+
+// BandwidthLimits returns the current bandwidth limits of the server.
+func (srv *Server) BandwidthLimits() BandwidthLimits {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if srv.bandwidth == nil {
+		return srv.Bandwidth.copy()
+	}
+	return srv.bandwidth.getLimits()
+}
+
+// SetBandwidthLimits changes the bandwidth limits of the running server. The new
+// limits apply to all connections, including existing ones.
+func (srv *Server) SetBandwidthLimits(limits BandwidthLimits) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if !srv.running {
+		return errServerStopped
+	}
+	return srv.bandwidth.setLimits(limits)
+}
@@ -19,6 +19,7 @@ package p2p
 import (
 	"crypto/ecdsa"
 	"errors"
+	"fmt"
 	"math/rand"
 	"net"
 	"reflect"
@@ -30,6 +31,8 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/ethereum/go-ethereum/p2p/nat/nattest"
 )
 
 // func init() {
@@ -616,3 +619,50 @@ func randomID() (id enode.ID) {
 	}
 	return id
 }
+
+// This test checks that the external IP reported by a router is only used as
+// the fallback IP of the local node, so endpoint statements of peers can
+// override it.
+func TestServerNATFallbackIP(t *testing.T) {
+	var (
+		routerIP    = net.IP{33, 44, 55, 66}
+		predictedIP = net.IP{33, 44, 55, 77}
+	)
+	pcp, err := nattest.NewPCPServer(fmt.Sprintf("127.0.0.1:%d", nattest.PCPPort), routerIP)
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer pcp.Close()
+
+	srv := &Server{Config: Config{
+		PrivateKey:  newkey(),
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		NoDiscovery: true,
+		NAT:         nat.PCP(net.IP{127, 0, 0, 1}),
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatal("can't start server:", err)
+	}
+	defer srv.Stop()
+
+	ln := srv.LocalNode()
+	waitForIP := func(want net.IP) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !ln.Node().IP().Equal(want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("local node has IP %v, want %v", ln.Node().IP(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForIP(routerIP)
+
+	// Peers observing a different endpoint win over the router.
+	for i := 0; i < 10; i++ {
+		from := &net.UDPAddr{IP: net.IP{10, 0, 0, byte(i)}, Port: 30303}
+		ln.UDPEndpointStatement(from, &net.UDPAddr{IP: predictedIP, Port: 30303})
+	}
+	waitForIP(predictedIP)
+}
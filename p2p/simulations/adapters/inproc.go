@@ -38,6 +38,7 @@ type SimAdapter struct {
 	pipe     func() (net.Conn, net.Conn, error)
 	mtx      sync.RWMutex
 	nodes    map[enode.ID]*SimNode
+	links    map[linkKey]*link
 	services map[string]ServiceFunc
 }
 
@@ -49,6 +50,7 @@ func NewSimAdapter(services map[string]ServiceFunc) *SimAdapter {
 	return &SimAdapter{
 		pipe:     pipes.NetPipe,
 		nodes:    make(map[enode.ID]*SimNode),
+		links:    make(map[linkKey]*link),
 		services: services,
 	}
 }
@@ -57,6 +59,7 @@ func NewTCPAdapter(services map[string]ServiceFunc) *SimAdapter {
 	return &SimAdapter{
 		pipe:     pipes.TCPPipe,
 		nodes:    make(map[enode.ID]*SimNode),
+		links:    make(map[linkKey]*link),
 		services: services,
 	}
 }
@@ -92,7 +95,7 @@ func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 			PrivateKey:      config.PrivateKey,
 			MaxPeers:        math.MaxInt32,
 			NoDiscovery:     true,
-			Dialer:          s,
+			Dialer:          &simDialer{adapter: s, id: id},
 			EnableMsgEvents: config.EnableMsgEvents,
 		},
 		NoUSB:  true,
@@ -136,6 +139,90 @@ func (s *SimAdapter) Dial(dest *enode.Node) (conn net.Conn, err error) {
 	return pipe2, nil
 }
 
+// simDialer dials the connections of a single node, shaping them according to
+// the links between the node and its peers.
+type simDialer struct {
+	adapter *SimAdapter
+	id      enode.ID
+}
+
+// Dial implements the p2p.NodeDialer interface.
+func (d *simDialer) Dial(dest *enode.Node) (net.Conn, error) {
+	return d.adapter.dial(d.id, dest)
+}
+
+// dial connects node src to the destination node over the simulated link
+// between them.
+func (s *SimAdapter) dial(src enode.ID, dest *enode.Node) (net.Conn, error) {
+	node, ok := s.GetNode(dest.ID())
+	if !ok {
+		return nil, fmt.Errorf("unknown node: %s", dest.ID())
+	}
+	srv := node.Server()
+	if srv == nil {
+		return nil, fmt.Errorf("node not running: %s", dest.ID())
+	}
+	link := s.link(src, dest.ID())
+	pipe1, pipe2, err := s.pipe()
+	if err != nil {
+		return nil, err
+	}
+	conn1, err := link.wrap(pipe1)
+	if err != nil {
+		pipe1.Close()
+		pipe2.Close()
+		return nil, err
+	}
+	conn2, err := link.wrap(pipe2)
+	if err != nil {
+		conn1.Close()
+		pipe2.Close()
+		return nil, err
+	}
+	go srv.SetupConn(conn1, 0, nil)
+	return conn2, nil
+}
+
+// link returns the link between two nodes, creating it if necessary.
+func (s *SimAdapter) link(one, other enode.ID) *link {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	key := newLinkKey(one, other)
+	l := s.links[key]
+	if l == nil {
+		l = newLink()
+		s.links[key] = l
+	}
+	return l
+}
+
+// SetLink implements LinkAdapter, setting the model of the link between two
+// nodes.
+func (s *SimAdapter) SetLink(one, other enode.ID, model LinkModel) error {
+	if err := model.validate(); err != nil {
+		return err
+	}
+	l := s.link(one, other)
+	l.mu.Lock()
+	l.model = model
+	l.mu.Unlock()
+	return nil
+}
+
+// Link implements LinkAdapter, returning the model of the link between two
+// nodes.
+func (s *SimAdapter) Link(one, other enode.ID) LinkModel {
+	model, _ := s.link(one, other).state()
+	return model
+}
+
+// SetBlocked implements LinkAdapter, blocking or unblocking the link between
+// two nodes.
+func (s *SimAdapter) SetBlocked(one, other enode.ID, blocked bool) {
+	s.link(one, other).setBlocked(blocked)
+}
+
 // DialRPC implements the RPCDialer interface by creating an in-memory RPC
 // client of the given node
 func (s *SimAdapter) DialRPC(id enode.ID) (*rpc.Client, error) {
@@ -0,0 +1,258 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// lossPenalty is the delay added to a write for each time it is lost. Simulated
+// connections are reliable streams, so a lost packet is modelled as a
+// retransmission after a timeout, like TCP would do.
+const lossPenalty = 200 * time.Millisecond
+
+// linkQueueSize is the number of writes which may be in flight on a link
+// before writers block.
+const linkQueueSize = 64
+
+var (
+	errLinkBlocked = errors.New("link blocked")
+	errLinkClosed  = errors.New("link closed")
+)
+
+// LinkModel describes the quality of the simulated network link between two
+// nodes. The model applies to both directions of the link. The zero value is a
+// perfect link.
+type LinkModel struct {
+	Latency   time.Duration `json:"latency"`   // Delay of each write
+	Jitter    time.Duration `json:"jitter"`    // Maximum random delay added to the latency
+	Loss      float64       `json:"loss"`      // Probability of a write being lost and retransmitted
+	Bandwidth int           `json:"bandwidth"` // Throughput in bytes per second, zero means unlimited
+}
+
+func (m LinkModel) validate() error {
+	switch {
+	case m.Latency < 0 || m.Jitter < 0:
+		return fmt.Errorf("negative link delay")
+	case m.Loss < 0 || m.Loss >= 1:
+		return fmt.Errorf("link loss %v out of range [0, 1)", m.Loss)
+	case m.Bandwidth < 0:
+		return fmt.Errorf("negative link bandwidth")
+	}
+	return nil
+}
+
+// delay returns the time it takes for a write to arrive at the other end,
+// excluding the transmission time.
+func (m LinkModel) delay() time.Duration {
+	d := m.Latency
+	if m.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(m.Jitter)))
+	}
+	for m.Loss > 0 && rand.Float64() < m.Loss {
+		d += lossPenalty
+	}
+	return d
+}
+
+// LinkAdapter is a NodeAdapter which can model the quality of the links between
+// its nodes and block links to partition the network.
+type LinkAdapter interface {
+	NodeAdapter
+
+	// SetLink sets the model of the link between two nodes. The model applies
+	// to existing connections immediately.
+	SetLink(one, other enode.ID, model LinkModel) error
+
+	// Link returns the model of the link between two nodes.
+	Link(one, other enode.ID) LinkModel
+
+	// SetBlocked blocks or unblocks the link between two nodes. Blocking a
+	// link closes all connections between the nodes and makes dials fail.
+	SetBlocked(one, other enode.ID, blocked bool)
+}
+
+// linkKey identifies the link between two nodes, regardless of direction.
+type linkKey [2]enode.ID
+
+func newLinkKey(one, other enode.ID) linkKey {
+	for i := range one {
+		if one[i] != other[i] {
+			if one[i] > other[i] {
+				one, other = other, one
+			}
+			break
+		}
+	}
+	return linkKey{one, other}
+}
+
+// link is the simulated network link between two nodes.
+type link struct {
+	mu      sync.Mutex
+	model   LinkModel
+	blocked bool
+	conns   map[*linkConn]struct{}
+}
+
+func newLink() *link {
+	return &link{conns: make(map[*linkConn]struct{})}
+}
+
+func (l *link) state() (LinkModel, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.model, l.blocked
+}
+
+func (l *link) setBlocked(blocked bool) {
+	l.mu.Lock()
+	l.blocked = blocked
+	var conns []*linkConn
+	if blocked {
+		for c := range l.conns {
+			conns = append(conns, c)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+// wrap returns a connection whose writes are shaped by the link model.
+func (l *link) wrap(conn net.Conn) (net.Conn, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.blocked {
+		return nil, errLinkBlocked
+	}
+	c := &linkConn{
+		Conn:   conn,
+		link:   l,
+		queue:  make(chan linkPacket, linkQueueSize),
+		closed: make(chan struct{}),
+	}
+	l.conns[c] = struct{}{}
+	go c.pump()
+	return c, nil
+}
+
+// linkConn is one end of a connection running over a simulated link. Writes
+// are queued and delivered to the other end when the link model says they
+// arrive.
+type linkConn struct {
+	net.Conn
+	link *link
+
+	queue     chan linkPacket
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	err      error     // error of the underlying connection
+	sendDone time.Time // time at which the link has transmitted all queued writes
+	last     time.Time // arrival time of the last queued write
+}
+
+type linkPacket struct {
+	data []byte
+	at   time.Time // arrival time at the other end
+}
+
+func (c *linkConn) Write(b []byte) (int, error) {
+	model, blocked := c.link.state()
+	if blocked {
+		return 0, errLinkBlocked
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return 0, c.err
+	}
+	// Writes are transmitted one after another, taking time depending on the
+	// bandwidth. They arrive in order after the link delay.
+	start := time.Now()
+	if c.sendDone.After(start) {
+		start = c.sendDone
+	}
+	if model.Bandwidth > 0 {
+		start = start.Add(time.Duration(len(b)) * time.Second / time.Duration(model.Bandwidth))
+	}
+	c.sendDone = start
+	at := start.Add(model.delay())
+	if at.Before(c.last) {
+		at = c.last
+	}
+	c.last = at
+	c.mu.Unlock()
+
+	select {
+	case c.queue <- linkPacket{data: append([]byte(nil), b...), at: at}:
+		return len(b), nil
+	case <-c.closed:
+		return 0, errLinkClosed
+	}
+}
+
+// pump delivers queued writes to the underlying connection.
+func (c *linkConn) pump() {
+	for {
+		select {
+		case p := <-c.queue:
+			if d := time.Until(p.at); d > 0 {
+				timer := time.NewTimer(d)
+				select {
+				case <-timer.C:
+				case <-c.closed:
+					timer.Stop()
+					return
+				}
+			}
+			if _, err := c.Conn.Write(p.data); err != nil {
+				c.mu.Lock()
+				c.err = err
+				c.mu.Unlock()
+				c.Close()
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *linkConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.link.mu.Lock()
+		delete(c.link.conns, c)
+		c.link.mu.Unlock()
+		err = c.Conn.Close()
+	})
+	return err
+}
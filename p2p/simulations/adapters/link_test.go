@@ -0,0 +1,84 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLinkConn(t *testing.T) {
+	l := newLink()
+	l.model = LinkModel{Latency: 100 * time.Millisecond}
+	c1, c2 := net.Pipe()
+	lc, err := l.wrap(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lc.Close()
+
+	// Writes arrive in order after the link latency.
+	start := time.Now()
+	for _, msg := range []string{"foo", "bar"} {
+		if _, err := lc.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := make([]byte, 6)
+	if _, err := io.ReadFull(c2, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte("foobar")) {
+		t.Fatalf("wrong data received: %q", buf)
+	}
+	if elapsed := time.Since(start); elapsed < l.model.Latency {
+		t.Fatalf("data arrived too early: %v", elapsed)
+	}
+
+	// Blocking the link closes the connection and prevents new ones.
+	l.setBlocked(true)
+	if _, err := c2.Read(buf); err != io.EOF {
+		t.Fatalf("wrong read error on blocked link: %v", err)
+	}
+	if _, err := lc.Write([]byte("baz")); err != errLinkBlocked {
+		t.Fatalf("wrong write error on blocked link: %v", err)
+	}
+	if _, err := l.wrap(c2); err != errLinkBlocked {
+		t.Fatalf("wrong wrap error on blocked link: %v", err)
+	}
+}
+
+func TestLinkModelValidate(t *testing.T) {
+	tests := []struct {
+		model LinkModel
+		ok    bool
+	}{
+		{LinkModel{}, true},
+		{LinkModel{Latency: time.Second, Jitter: time.Second, Loss: 0.5, Bandwidth: 1000}, true},
+		{LinkModel{Latency: -1}, false},
+		{LinkModel{Loss: 1}, false},
+		{LinkModel{Bandwidth: -1}, false},
+	}
+	for i, test := range tests {
+		if err := test.model.validate(); (err == nil) != test.ok {
+			t.Errorf("test %d: wrong result %v", i, err)
+		}
+	}
+}
@@ -205,6 +205,27 @@ func (c *Client) DisconnectNode(nodeID, peerID string) error {
 	return c.Delete(fmt.Sprintf("/nodes/%s/conn/%s", nodeID, peerID))
 }
 
+// GetLink returns the model of the link between a node and a peer node
+func (c *Client) GetLink(nodeID, peerID string) (*adapters.LinkModel, error) {
+	model := &adapters.LinkModel{}
+	return model, c.Get(fmt.Sprintf("/nodes/%s/link/%s", nodeID, peerID), model)
+}
+
+// SetLink sets the model of the link between a node and a peer node
+func (c *Client) SetLink(nodeID, peerID string, model adapters.LinkModel) error {
+	return c.Post(fmt.Sprintf("/nodes/%s/link/%s", nodeID, peerID), model, nil)
+}
+
+// Partition splits the network into the given groups of nodes
+func (c *Client) Partition(groups ...[]enode.ID) error {
+	return c.Post("/partition", groups, nil)
+}
+
+// Heal removes the current network partition
+func (c *Client) Heal() error {
+	return c.Delete("/partition")
+}
+
 // RPCClient returns an RPC client connected to a node
 func (c *Client) RPCClient(ctx context.Context, nodeID string) (*rpc.Client, error) {
 	baseURL := strings.Replace(c.URL, "http", "ws", 1)
@@ -295,6 +316,10 @@ func NewServer(network *Network) *Server {
 	s.POST("/nodes/:nodeid/stop", s.StopNode)
 	s.POST("/nodes/:nodeid/conn/:peerid", s.ConnectNode)
 	s.DELETE("/nodes/:nodeid/conn/:peerid", s.DisconnectNode)
+	s.GET("/nodes/:nodeid/link/:peerid", s.GetLink)
+	s.POST("/nodes/:nodeid/link/:peerid", s.SetLink)
+	s.POST("/partition", s.Partition)
+	s.DELETE("/partition", s.Heal)
 	s.GET("/nodes/:nodeid/rpc", s.NodeRPC)
 
 	return s
@@ -647,6 +672,66 @@ func (s *Server) DisconnectNode(w http.ResponseWriter, req *http.Request) {
 	s.JSON(w, http.StatusOK, node.NodeInfo())
 }
 
+// GetLink returns the model of the link between a node and a peer node
+func (s *Server) GetLink(w http.ResponseWriter, req *http.Request) {
+	node := req.Context().Value("node").(*Node)
+	peer := req.Context().Value("peer").(*Node)
+
+	model, err := s.network.GetLink(node.ID(), peer.ID())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.JSON(w, http.StatusOK, model)
+}
+
+// SetLink sets the model of the link between a node and a peer node
+func (s *Server) SetLink(w http.ResponseWriter, req *http.Request) {
+	node := req.Context().Value("node").(*Node)
+	peer := req.Context().Value("peer").(*Node)
+
+	var model adapters.LinkModel
+	if err := json.NewDecoder(req.Body).Decode(&model); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.network.SetLink(node.ID(), peer.ID(), model); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.JSON(w, http.StatusOK, model)
+}
+
+// Partition splits the network into groups of nodes given as a list of lists
+// of node IDs
+func (s *Server) Partition(w http.ResponseWriter, req *http.Request) {
+	var groups [][]enode.ID
+	if err := json.NewDecoder(req.Body).Decode(&groups); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.network.Partition(groups...); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Heal removes the current network partition
+func (s *Server) Heal(w http.ResponseWriter, req *http.Request) {
+	if err := s.network.Heal(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // Options responds to the OPTIONS HTTP method by returning a 200 OK response
 // with the "Access-Control-Allow-Headers" header set to "Content-Type"
 func (s *Server) Options(w http.ResponseWriter, req *http.Request) {
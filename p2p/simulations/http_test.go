@@ -581,6 +581,56 @@ func TestHTTPNodeRPC(t *testing.T) {
 	}
 }
 
+// TestHTTPLinks tests setting link models and partitioning the network via the
+// HTTP API
+func TestHTTPLinks(t *testing.T) {
+	// start the server
+	_, s := testHTTPServer(t)
+	defer s.Close()
+
+	// create two nodes
+	client := NewClient(s.URL)
+	nodes := make([]*p2p.NodeInfo, 2)
+	for i := range nodes {
+		node, err := client.CreateNode(adapters.RandomNodeConfig())
+		if err != nil {
+			t.Fatalf("error creating node: %s", err)
+		}
+		nodes[i] = node
+	}
+
+	// set and get the link model
+	model := adapters.LinkModel{Latency: 50 * time.Millisecond, Loss: 0.1, Bandwidth: 1024}
+	if err := client.SetLink(nodes[0].ID, nodes[1].ID, model); err != nil {
+		t.Fatalf("error setting link: %s", err)
+	}
+	link, err := client.GetLink(nodes[1].ID, nodes[0].ID)
+	if err != nil {
+		t.Fatalf("error getting link: %s", err)
+	}
+	if *link != model {
+		t.Fatalf("wrong link model: got %+v, want %+v", *link, model)
+	}
+	if err := client.SetLink(nodes[0].ID, nodes[1].ID, adapters.LinkModel{Loss: 2}); err == nil {
+		t.Fatal("expected error setting invalid link model")
+	}
+
+	// partition and heal the network
+	one, other := enode.HexID(nodes[0].ID), enode.HexID(nodes[1].ID)
+	if err := client.Partition([]enode.ID{one}, []enode.ID{other}); err != nil {
+		t.Fatalf("error partitioning network: %s", err)
+	}
+	if err := client.Partition([]enode.ID{one}, []enode.ID{other}); err == nil {
+		t.Fatal("expected error partitioning partitioned network")
+	}
+	if err := client.Heal(); err != nil {
+		t.Fatalf("error healing network: %s", err)
+	}
+	if err := client.Heal(); err == nil {
+		t.Fatal("expected error healing network without partition")
+	}
+}
+
 // TestHTTPSnapshot tests creating and loading network snapshots
 func TestHTTPSnapshot(t *testing.T) {
 	// start the server
@@ -0,0 +1,170 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+)
+
+var (
+	errNoLinkAdapter  = errors.New("node adapter does not support link models")
+	errPartitioned    = errors.New("network is already partitioned")
+	errNotPartitioned = errors.New("network is not partitioned")
+)
+
+// cutLink is a link blocked by a network partition.
+type cutLink struct {
+	one, other enode.ID
+	wasUp      bool // whether the nodes were connected when the link was cut
+}
+
+func (net *Network) linkAdapter() (adapters.LinkAdapter, error) {
+	la, ok := net.nodeAdapter.(adapters.LinkAdapter)
+	if !ok {
+		return nil, errNoLinkAdapter
+	}
+	return la, nil
+}
+
+// SetLink sets the latency, jitter, loss and bandwidth of the link between two
+// nodes. The model applies to existing connections immediately.
+func (net *Network) SetLink(oneID, otherID enode.ID, model adapters.LinkModel) error {
+	la, err := net.linkAdapter()
+	if err != nil {
+		return err
+	}
+	if net.GetNode(oneID) == nil || net.GetNode(otherID) == nil {
+		return fmt.Errorf("unknown node %v or %v", oneID, otherID)
+	}
+	return la.SetLink(oneID, otherID, model)
+}
+
+// GetLink returns the model of the link between two nodes.
+func (net *Network) GetLink(oneID, otherID enode.ID) (adapters.LinkModel, error) {
+	la, err := net.linkAdapter()
+	if err != nil {
+		return adapters.LinkModel{}, err
+	}
+	return la.Link(oneID, otherID), nil
+}
+
+// Partition splits the network into the given groups of nodes. All links between
+// nodes of different groups are blocked: existing connections are closed and
+// new ones can't be established until the partition is healed. Nodes which are
+// not part of any group are unaffected.
+func (net *Network) Partition(groups ...[]enode.ID) error {
+	la, err := net.linkAdapter()
+	if err != nil {
+		return err
+	}
+	net.lock.Lock()
+	defer net.lock.Unlock()
+
+	if net.cut != nil {
+		return errPartitioned
+	}
+	for _, group := range groups {
+		for _, id := range group {
+			if net.getNode(id) == nil {
+				return fmt.Errorf("unknown node %v", id)
+			}
+		}
+	}
+	cut := make([]*cutLink, 0)
+	for i, group := range groups {
+		for _, other := range groups[i+1:] {
+			for _, oneID := range group {
+				for _, otherID := range other {
+					c := &cutLink{one: oneID, other: otherID}
+					if conn := net.getConn(oneID, otherID); conn != nil && conn.Up {
+						c.one, c.other, c.wasUp = conn.One, conn.Other, true
+					}
+					cut = append(cut, c)
+				}
+			}
+		}
+	}
+	for _, c := range cut {
+		la.SetBlocked(c.one, c.other, true)
+	}
+	net.cut = cut
+	log.Debug("Partitioned network", "groups", len(groups), "links", len(cut))
+	return nil
+}
+
+// Heal removes the current partition. Connections which were closed by the
+// partition are re-established.
+func (net *Network) Heal() error {
+	la, err := net.linkAdapter()
+	if err != nil {
+		return err
+	}
+	net.lock.Lock()
+	cut := net.cut
+	net.cut = nil
+	net.lock.Unlock()
+
+	if cut == nil {
+		return errNotPartitioned
+	}
+	for _, c := range cut {
+		la.SetBlocked(c.one, c.other, false)
+	}
+	for _, c := range cut {
+		if !c.wasUp {
+			continue
+		}
+		if conn := net.GetConn(c.one, c.other); conn != nil && conn.Up {
+			continue // already reconnected by the nodes
+		}
+		if err := net.reconnect(c.one, c.other); err != nil {
+			log.Debug("Failed to restore connection after partition", "one", c.one, "other", c.other, "err", err)
+		}
+	}
+	log.Debug("Healed network partition", "links", len(cut))
+	return nil
+}
+
+// reconnect makes a node dial a peer it was connected to before the partition.
+// The peer is removed and added again, which clears the failed dials made during
+// the partition from the dial history of the node so it redials immediately.
+func (net *Network) reconnect(oneID, otherID enode.ID) error {
+	one, other := net.GetNode(oneID), net.GetNode(otherID)
+	if one == nil || other == nil {
+		return fmt.Errorf("unknown node %v or %v", oneID, otherID)
+	}
+	client, err := one.Client()
+	if err != nil {
+		return err
+	}
+	if err := client.Call(nil, "admin_removePeer", string(other.Addr())); err != nil {
+		return err
+	}
+	return client.Call(nil, "admin_addPeer", string(other.Addr()))
+}
+
+// Partitioned reports whether the network is currently partitioned.
+func (net *Network) Partitioned() bool {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+	return net.cut != nil
+}
@@ -56,6 +56,8 @@ type Network struct {
 	Conns   []*Conn `json:"conns"`
 	connMap map[string]int
 
+	cut []*cutLink // links blocked by the current partition
+
 	nodeAdapter adapters.NodeAdapter
 	events      event.Feed
 	lock        sync.RWMutex
@@ -516,6 +518,7 @@ func (net *Network) Reset() {
 
 	net.Nodes = nil
 	net.Conns = nil
+	net.cut = nil
 }
 
 // Node is a wrapper around adapters.Node which is used to track the status
@@ -22,8 +22,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // TestNetworkSimulation creates a multi-node simulation network with each node
@@ -158,3 +161,95 @@ func triggerChecks(ctx context.Context, ids []enode.ID, trigger chan enode.ID, i
 		}
 	}
 }
+
+// TestNetworkPartition checks that partitioning the network closes the
+// connections between the groups and that healing restores them
+func TestNetworkPartition(t *testing.T) {
+	adapter := adapters.NewSimAdapter(adapters.Services{
+		"noop": newNoopService,
+	})
+	network := NewNetwork(adapter, &NetworkConfig{
+		DefaultService: "noop",
+	})
+	defer network.Shutdown()
+
+	ids := make([]enode.ID, 3)
+	for i := range ids {
+		node, err := network.NewNodeWithConfig(adapters.RandomNodeConfig())
+		if err != nil {
+			t.Fatalf("error creating node: %s", err)
+		}
+		if err := network.Start(node.ID()); err != nil {
+			t.Fatalf("error starting node: %s", err)
+		}
+		ids[i] = node.ID()
+	}
+	if err := network.Connect(ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.Connect(ids[1], ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	waitConn := func(one, other enode.ID, up bool) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for time.Now().Before(deadline) {
+			if conn := network.GetConn(one, other); conn != nil && conn.Up == up {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for connection %s-%s to be up=%t", one, other, up)
+	}
+	waitConn(ids[0], ids[1], true)
+	waitConn(ids[1], ids[2], true)
+
+	if err := network.Partition([]enode.ID{ids[0]}, []enode.ID{ids[1], ids[2]}); err != nil {
+		t.Fatal(err)
+	}
+	if !network.Partitioned() {
+		t.Fatal("network not partitioned")
+	}
+	if err := network.Partition([]enode.ID{ids[0]}); err != errPartitioned {
+		t.Fatalf("wrong error for second partition: %v", err)
+	}
+	waitConn(ids[0], ids[1], false)
+	if conn := network.GetConn(ids[1], ids[2]); !conn.Up {
+		t.Fatal("connection within group closed by partition")
+	}
+
+	if err := network.Heal(); err != nil {
+		t.Fatal(err)
+	}
+	if network.Partitioned() {
+		t.Fatal("network still partitioned after heal")
+	}
+	waitConn(ids[0], ids[1], true)
+}
+
+// noopService is a service running a protocol which does nothing but wait for
+// the peer to disconnect. Unlike testService, it supports reconnecting peers.
+type noopService struct{}
+
+func newNoopService(ctx *adapters.ServiceContext) (node.Service, error) {
+	return noopService{}, nil
+}
+
+func (noopService) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    "noop",
+		Version: 1,
+		Length:  1,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			for {
+				if _, err := rw.ReadMsg(); err != nil {
+					return err
+				}
+			}
+		},
+	}}
+}
+
+func (noopService) APIs() []rpc.API         { return nil }
+func (noopService) Start(*p2p.Server) error { return nil }
+func (noopService) Stop() error             { return nil }
@@ -0,0 +1,117 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// MsgStats contains the traffic counters of a single message type. Byte counts
+// are payload sizes, excluding the framing overhead of the connection.
+type MsgStats struct {
+	IngressPackets uint64 `json:"ingressPackets"`
+	IngressBytes   uint64 `json:"ingressBytes"`
+	EgressPackets  uint64 `json:"egressPackets"`
+	EgressBytes    uint64 `json:"egressBytes"`
+}
+
+// PeerStats contains the traffic counters of a connected peer, keyed by protocol
+// (e.g. "eth/63") and message code (e.g. "0x05").
+type PeerStats struct {
+	ID        string                         `json:"id"`   // Unique node identifier
+	Name      string                         `json:"name"` // Name of the node, including client type, version, OS, custom data
+	Protocols map[string]map[string]MsgStats `json:"protocols"`
+}
+
+// msgKey identifies a message type of a protocol.
+type msgKey struct {
+	proto string // protocol name and version, e.g. "eth/63"
+	code  uint64 // message code relative to the protocol offset
+}
+
+// trafficStats accumulates the per message type traffic of a peer.
+type trafficStats struct {
+	mu   sync.Mutex
+	msgs map[msgKey]*MsgStats
+}
+
+// ingress accounts for a message received from the peer.
+func (s *trafficStats) ingress(proto *protoRW, code uint64, size uint32) {
+	key := msgKey{proto.cap().String(), code}
+	markMsgMeters(MetricsInboundMessages, key, size)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.get(key)
+	st.IngressPackets++
+	st.IngressBytes += uint64(size)
+}
+
+// egress accounts for a message sent to the peer.
+func (s *trafficStats) egress(proto *protoRW, code uint64, size uint32) {
+	key := msgKey{proto.cap().String(), code}
+	markMsgMeters(MetricsOutboundMessages, key, size)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.get(key)
+	st.EgressPackets++
+	st.EgressBytes += uint64(size)
+}
+
+// get returns the counters of a message type, creating them if necessary.
+// It must be called with s.mu held.
+func (s *trafficStats) get(key msgKey) *MsgStats {
+	if s.msgs == nil {
+		s.msgs = make(map[msgKey]*MsgStats)
+	}
+	st := s.msgs[key]
+	if st == nil {
+		st = new(MsgStats)
+		s.msgs[key] = st
+	}
+	return st
+}
+
+// snapshot returns a copy of all counters, keyed by protocol and message code.
+func (s *trafficStats) snapshot() map[string]map[string]MsgStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	protos := make(map[string]map[string]MsgStats)
+	for key, st := range s.msgs {
+		if protos[key.proto] == nil {
+			protos[key.proto] = make(map[string]MsgStats)
+		}
+		protos[key.proto][fmt.Sprintf("0x%02x", key.code)] = *st
+	}
+	return protos
+}
+
+// markMsgMeters updates the global meters of a message type, counting both its
+// bytes and packets.
+func markMsgMeters(prefix string, key msgKey, size uint32) {
+	if !metrics.Enabled {
+		return
+	}
+	name := fmt.Sprintf("%s/%s/0x%02x", prefix, key.proto, key.code)
+	metrics.GetOrRegisterMeter(name, nil).Mark(int64(size))
+	metrics.GetOrRegisterMeter(name+"/packets", nil).Mark(1)
+}
@@ -20,10 +20,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 
 	"bytes"
 	"os"
@@ -61,6 +65,98 @@ func (cd decodedCallData) String() string {
 	return fmt.Sprintf("%s(%s)", cd.name, strings.Join(args, ","))
 }
 
+// DecodedCall is the ABI-decoded calldata of a transaction, in a form that can
+// be handed to the UI and the rule engine as JSON. Integers are represented as
+// decimal strings, byte arrays as hex strings and tuples as objects.
+type DecodedCall struct {
+	Signature string       `json:"signature"`
+	Name      string       `json:"name"`
+	Args      []DecodedArg `json:"args"`
+}
+
+// DecodedArg is a single decoded argument of a call.
+type DecodedArg struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// export converts the decoded calldata into its JSON-friendly representation.
+func (cd decodedCallData) export() *DecodedCall {
+	call := &DecodedCall{
+		Signature: cd.signature,
+		Name:      cd.name,
+		Args:      make([]DecodedArg, len(cd.inputs)),
+	}
+	for i, arg := range cd.inputs {
+		call.Args[i] = DecodedArg{
+			Name:  arg.soltype.Name,
+			Type:  arg.soltype.Type.String(),
+			Value: exportValue(reflect.ValueOf(arg.value)),
+		}
+	}
+	return call
+}
+
+// exportValue converts a value unpacked by the abi package into a form which
+// survives a JSON roundtrip into javascript without losing precision.
+func exportValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch val := v.Interface().(type) {
+	case *big.Int:
+		if val == nil {
+			return nil
+		}
+		return val.String()
+	case common.Address:
+		return val.Hex()
+	case []byte:
+		return hexutil.Encode(val)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return exportValue(v.Elem())
+	case reflect.Array:
+		// Fixed size byte arrays (bytesN, function) are rendered as hex
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			blob := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(blob), v)
+			return hexutil.Encode(blob)
+		}
+		fallthrough
+	case reflect.Slice:
+		list := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list[i] = exportValue(v.Index(i))
+		}
+		return list
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+			if tag := v.Type().Field(i).Tag.Get("json"); tag != "" {
+				name = tag
+			}
+			fields[name] = exportValue(v.Field(i))
+		}
+		return fields
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
 // parseCallData matches the provided call data against the abi definition,
 // and returns a struct containing the actual go-typed values
 func parseCallData(calldata []byte, abidata string) (*decodedCallData, error) {
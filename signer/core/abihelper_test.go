@@ -17,6 +17,7 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -249,3 +250,26 @@ func TestMaliciousAbiStrings(t *testing.T) {
 		}
 	}
 }
+
+// Tests that decoded calldata is exported into a JSON-friendly form.
+func TestDecodedCallExport(t *testing.T) {
+	calldata := common.Hex2Bytes("8be65246" + "00000000000000000000000000000000000000000000000000000000000001230000000000000000000000000000000000000000000000000000000000000080313233343536373839300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000e0000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000004560000000000000000000000000000000000000000000000000000000000000789000000000000000000000000000000000000000000000000000000000000000d48656c6c6f2c20776f726c642100000000000000000000000000000000000000")
+	abidata := `[{"type":"function","name":"f","inputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint32[]"},{"name":"c","type":"bytes10"},{"name":"d","type":"bytes"}]}]`
+
+	info, err := parseCallData(calldata, abidata)
+	if err != nil {
+		t.Fatalf("failed to decode calldata: %v", err)
+	}
+	have, err := json.Marshal(info.export())
+	if err != nil {
+		t.Fatalf("failed to marshal decoded call: %v", err)
+	}
+	want := `{"signature":"f(uint256,uint32[],bytes10,bytes)","name":"f","args":[` +
+		`{"name":"a","type":"uint256","value":"291"},` +
+		`{"name":"b","type":"uint32[]","value":["1110","1929"]},` +
+		`{"name":"c","type":"bytes10","value":"0x31323334353637383930"},` +
+		`{"name":"d","type":"bytes","value":"0x48656c6c6f2c20776f726c6421"}]}`
+	if string(have) != want {
+		t.Errorf("export mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
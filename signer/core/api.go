@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/signer/core/typeddata"
 )
 
 // numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
@@ -49,6 +50,8 @@ type ExternalAPI interface {
 	SignTransaction(ctx context.Context, args SendTxArgs, methodSelector *string) (*ethapi.SignTransactionResult, error)
 	// Sign - request to sign the given data (plus prefix)
 	Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error)
+	// SignTypedData - request to sign the given EIP-712 typed structured data
+	SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data typeddata.TypedData) (hexutil.Bytes, error)
 	// Export - request to export an account
 	Export(ctx context.Context, addr common.Address) (json.RawMessage, error)
 	// Import - request to import an account
@@ -142,6 +145,7 @@ type (
 	SignTxRequest struct {
 		Transaction SendTxArgs       `json:"transaction"`
 		Callinfo    []ValidationInfo `json:"call_info"`
+		Call        *DecodedCall     `json:"call,omitempty"`
 		Meta        Metadata         `json:"meta"`
 	}
 	// SignTxResponse result from SignTxRequest
@@ -170,11 +174,13 @@ type (
 		NewPassword string `json:"new_password"`
 	}
 	SignDataRequest struct {
-		Address common.MixedcaseAddress `json:"address"`
-		Rawdata hexutil.Bytes           `json:"raw_data"`
-		Message string                  `json:"message"`
-		Hash    hexutil.Bytes           `json:"hash"`
-		Meta    Metadata                `json:"meta"`
+		ContentType string                     `json:"content_type"`
+		Address     common.MixedcaseAddress    `json:"address"`
+		Rawdata     hexutil.Bytes              `json:"raw_data"`
+		Message     string                     `json:"message"`
+		Messages    []*typeddata.NameValueType `json:"messages,omitempty"`
+		Hash        hexutil.Bytes              `json:"hash"`
+		Meta        Metadata                   `json:"meta"`
 	}
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
@@ -218,6 +224,12 @@ type (
 
 var ErrRequestDenied = errors.New("Request denied")
 
+// Content types of the data in a SignDataRequest
+const (
+	TextPlain = "text/plain" // Arbitrary data signed with the personal message prefix
+	DataTyped = "data/typed" // EIP-712 typed structured data
+)
+
 // NewSignerAPI creates a new API that can be used for Account management.
 // ksLocation specifies the directory where to store the password protected private
 // key that is generated when a new Account is created.
@@ -470,6 +482,7 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 		Transaction: args,
 		Meta:        MetadataFromContext(ctx),
 		Callinfo:    msgs.Messages,
+		Call:        msgs.call,
 	}
 	// Process approval
 	result, err = api.UI.ApproveTx(&req)
@@ -521,9 +534,34 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 // https://github.com/ethereum/go-ethereum/wiki/Management-APIs#personal_sign
 func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
 	sighash, msg := SignHash(data)
+	req := &SignDataRequest{ContentType: TextPlain, Address: addr, Rawdata: data, Message: msg, Hash: sighash, Meta: MetadataFromContext(ctx)}
+	return api.sign(req)
+}
+
+// SignTypedData calculates an Ethereum ECDSA signature for the EIP-712 hash of the
+// given typed structured data:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+//
+// The domain and every field of the message are rendered for the UI, so the user
+// can review them before approving. The V value of the signature will be 27 or 28.
+func (api *SignerAPI) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data typeddata.TypedData) (hexutil.Bytes, error) {
+	sighash, rawData, err := data.Hash()
+	if err != nil {
+		return nil, err
+	}
+	messages, err := data.Format()
+	if err != nil {
+		return nil, err
+	}
+	req := &SignDataRequest{ContentType: DataTyped, Address: addr, Rawdata: rawData, Messages: messages, Hash: sighash.Bytes(), Meta: MetadataFromContext(ctx)}
+	return api.sign(req)
+}
+
+// sign asks the UI to approve the signing request, and signs the request hash
+// with the account's key if approved.
+func (api *SignerAPI) sign(req *SignDataRequest) (hexutil.Bytes, error) {
 	// We make the request prior to looking up if we actually have the account, to prevent
 	// account-enumeration via the API
-	req := &SignDataRequest{Address: addr, Rawdata: data, Message: msg, Hash: sighash, Meta: MetadataFromContext(ctx)}
 	res, err := api.UI.ApproveSignData(req)
 
 	if err != nil {
@@ -533,13 +571,13 @@ func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 		return nil, ErrRequestDenied
 	}
 	// Look up the wallet containing the requested signer
-	account := accounts.Account{Address: addr.Address()}
+	account := accounts.Account{Address: req.Address.Address()}
 	wallet, err := api.am.Find(account)
 	if err != nil {
 		return nil, err
 	}
 	// Assemble sign the data with the wallet
-	signature, err := wallet.SignHashWithPassphrase(account, res.Password, sighash)
+	signature, err := wallet.SignHashWithPassphrase(account, res.Password, req.Hash)
 	if err != nil {
 		api.UI.ShowError(err.Error())
 		return nil, err
@@ -19,6 +19,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -33,8 +34,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/signer/core/typeddata"
 )
 
 //Used for testing
@@ -284,6 +287,66 @@ func TestSignData(t *testing.T) {
 		t.Errorf("Expected 65 byte signature (got %d bytes)", len(h))
 	}
 }
+
+func TestSignTypedData(t *testing.T) {
+	api, control := setup(t)
+	//Create two accounts
+	createAccount(control, api, t)
+	createAccount(control, api, t)
+	control <- "1"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+
+	var data typeddata.TypedData
+	err = json.Unmarshal([]byte(`{
+		"types": {
+			"EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}],
+			"Order": [{"name": "maker", "type": "address"}, {"name": "amount", "type": "uint256"}]
+		},
+		"primaryType": "Order",
+		"domain": {"name": "Exchange", "chainId": "0x1"},
+		"message": {"maker": "0x0000000000000000000000000000000000001337", "amount": "1000000000000000000000"}
+	}`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	control <- "No way"
+	h, err := api.SignTypedData(context.Background(), a, data)
+	if h != nil {
+		t.Errorf("Expected nil-data, got %x", h)
+	}
+	if err != ErrRequestDenied {
+		t.Errorf("Expected ErrRequestDenied! %v", err)
+	}
+	control <- "Y"
+	control <- "a_long_password"
+	h, err = api.SignTypedData(context.Background(), a, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h == nil || len(h) != 65 {
+		t.Fatalf("Expected 65 byte signature (got %d bytes)", len(h))
+	}
+	// Verify that the signature recovers to the account over the EIP-712 hash
+	hash, _, _ := data.Hash()
+	h[64] -= 27
+	pub, err := crypto.SigToPub(hash[:], h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != a.Address() {
+		t.Errorf("Signer mismatch: have %x, want %x", signer, a.Address())
+	}
+	// Invalid typed data should be rejected before reaching the UI
+	data.Message["amount"] = "-1"
+	if _, err := api.SignTypedData(context.Background(), a, data); err == nil {
+		t.Errorf("Expected error for invalid message")
+	}
+}
+
 func mkTestTx(from common.MixedcaseAddress) SendTxArgs {
 	to := common.NewMixedcaseAddress(common.HexToAddress("0x1337"))
 	gas := hexutil.Uint64(21000)
@@ -0,0 +1,691 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package typeddata implements the hashing of typed structured data as specified
+// by EIP-712, used to sign off-chain messages which can be verified on-chain.
+//
+// https://eips.ethereum.org/EIPS/eip-712
+package typeddata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// maxDepth is the maximum nesting of structs and arrays accepted in a message,
+// protecting the signer against stack exhaustion on malicious input.
+const maxDepth = 64
+
+// domainType is the name of the type describing the signing domain.
+const domainType = "EIP712Domain"
+
+var (
+	identifierRegexp  = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z_$0-9]*$`)
+	arraySuffixRegexp = regexp.MustCompile(`^(\[[0-9]*\])+$`)
+	fixedBytesRegexp  = regexp.MustCompile(`^bytes([0-9]+)$`)
+	integerRegexp     = regexp.MustCompile(`^(u?)int([0-9]+)$`)
+)
+
+// Type is a single field of a struct type definition.
+type Type struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// isArray returns whether the field holds an array.
+func (t *Type) isArray() bool {
+	return strings.HasSuffix(t.Type, "]")
+}
+
+// typeName returns the type of the field without any array suffixes.
+func (t *Type) typeName() string {
+	if i := strings.Index(t.Type, "["); i >= 0 {
+		return t.Type[:i]
+	}
+	return t.Type
+}
+
+// Types maps the names of struct types to their field definitions.
+type Types map[string][]Type
+
+// TypedDataMessage is the data of a struct, keyed by field name.
+type TypedDataMessage = map[string]interface{}
+
+// TypedDataDomain is the signing domain of a message, used to prevent messages
+// meant for one dapp from being replayed on another.
+type TypedDataDomain struct {
+	Name              string                `json:"name"`
+	Version           string                `json:"version"`
+	ChainId           *math.HexOrDecimal256 `json:"chainId"`
+	VerifyingContract string                `json:"verifyingContract"`
+	Salt              string                `json:"salt"`
+}
+
+// TypedData is a message with its type definitions and signing domain, as it
+// is submitted to eth_signTypedData.
+type TypedData struct {
+	Types       Types            `json:"types"`
+	PrimaryType string           `json:"primaryType"`
+	Domain      TypedDataDomain  `json:"domain"`
+	Message     TypedDataMessage `json:"message"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, retaining numbers in the message
+// verbatim as the integers they encode may exceed the precision of a float.
+func (typedData *TypedData) UnmarshalJSON(input []byte) error {
+	type typedDataJSON TypedData
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+
+	var data typedDataJSON
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+	*typedData = TypedData(data)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the chain id both as a
+// number and as a (hex or decimal) string.
+func (domain *TypedDataDomain) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		Name              string          `json:"name"`
+		Version           string          `json:"version"`
+		ChainId           json.RawMessage `json:"chainId"`
+		VerifyingContract string          `json:"verifyingContract"`
+		Salt              string          `json:"salt"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	domain.Name, domain.Version = dec.Name, dec.Version
+	domain.VerifyingContract, domain.Salt = dec.VerifyingContract, dec.Salt
+	domain.ChainId = nil
+
+	if len(dec.ChainId) > 0 && string(dec.ChainId) != "null" {
+		text := strings.Trim(string(dec.ChainId), `"`)
+		id, ok := math.ParseBig256(text)
+		if !ok || id.Sign() < 0 {
+			return fmt.Errorf("invalid chain id %s", dec.ChainId)
+		}
+		domain.ChainId = (*math.HexOrDecimal256)(id)
+	}
+	return nil
+}
+
+// Map returns the fields of the domain which are set, keyed by their name in
+// the EIP712Domain type.
+func (domain *TypedDataDomain) Map() map[string]interface{} {
+	fields := make(map[string]interface{})
+	if domain.Name != "" {
+		fields["name"] = domain.Name
+	}
+	if domain.Version != "" {
+		fields["version"] = domain.Version
+	}
+	if domain.ChainId != nil {
+		fields["chainId"] = domain.ChainId
+	}
+	if domain.VerifyingContract != "" {
+		fields["verifyingContract"] = domain.VerifyingContract
+	}
+	if domain.Salt != "" {
+		fields["salt"] = domain.Salt
+	}
+	return fields
+}
+
+// domainFields are the field types the EIP712Domain type may declare.
+var domainFields = map[string]string{
+	"name":              "string",
+	"version":           "string",
+	"chainId":           "uint256",
+	"verifyingContract": "address",
+	"salt":              "bytes32",
+}
+
+// validate checks that the domain sets exactly the fields declared in its type.
+func (domain *TypedDataDomain) validate(fields []Type) error {
+	values := domain.Map()
+	for _, field := range fields {
+		typ, ok := domainFields[field.Name]
+		if !ok {
+			return fmt.Errorf("unknown domain field %q", field.Name)
+		}
+		if field.Type != typ {
+			return fmt.Errorf("domain field %q must be of type %s, not %s", field.Name, typ, field.Type)
+		}
+		if _, ok := values[field.Name]; !ok {
+			return fmt.Errorf("domain field %q is declared but not set", field.Name)
+		}
+		delete(values, field.Name)
+	}
+	for name := range values {
+		return fmt.Errorf("domain field %q is set but not declared", name)
+	}
+	return nil
+}
+
+// Validate checks that the type definitions are well formed and only reference
+// known types, and that the domain matches its declared type. The message values
+// are checked while being encoded.
+func (typedData *TypedData) Validate() error {
+	if err := typedData.Types.validate(); err != nil {
+		return err
+	}
+	domain, ok := typedData.Types[domainType]
+	if !ok {
+		return fmt.Errorf("missing %s type definition", domainType)
+	}
+	if typedData.PrimaryType == "" {
+		return errors.New("missing primary type")
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return fmt.Errorf("primary type %q is not defined", typedData.PrimaryType)
+	}
+	if typedData.Message == nil {
+		return errors.New("missing message")
+	}
+	return typedData.Domain.validate(domain)
+}
+
+// validate checks the struct definitions for invalid names and unknown types.
+func (t Types) validate() error {
+	for name, fields := range t {
+		if !identifierRegexp.MatchString(name) {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if !identifierRegexp.MatchString(field.Name) {
+				return fmt.Errorf("invalid field name %q in type %s", field.Name, name)
+			}
+			if seen[field.Name] {
+				return fmt.Errorf("duplicate field %q in type %s", field.Name, name)
+			}
+			seen[field.Name] = true
+
+			base := field.typeName()
+			if suffix := field.Type[len(base):]; suffix != "" && !arraySuffixRegexp.MatchString(suffix) {
+				return fmt.Errorf("invalid array type %q in type %s", field.Type, name)
+			}
+			if _, ok := t[base]; ok {
+				if base == name && !field.isArray() {
+					return fmt.Errorf("type %s references itself in field %q", name, field.Name)
+				}
+				continue
+			}
+			if !isPrimitiveType(base) {
+				return fmt.Errorf("unknown type %q of field %q in type %s", base, field.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// isPrimitiveType returns whether the type is an atomic or dynamic EIP-712 type.
+func isPrimitiveType(typ string) bool {
+	switch typ {
+	case "address", "bool", "string", "bytes":
+		return true
+	}
+	if match := fixedBytesRegexp.FindStringSubmatch(typ); match != nil {
+		size, err := strconv.Atoi(match[1])
+		return err == nil && size >= 1 && size <= 32
+	}
+	if match := integerRegexp.FindStringSubmatch(typ); match != nil {
+		size, err := strconv.Atoi(match[2])
+		return err == nil && size >= 8 && size <= 256 && size%8 == 0
+	}
+	return false
+}
+
+// Hash validates the typed data and calculates the digest to sign, returning
+// it together with the raw data it was derived from:
+//
+//   keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func (typedData *TypedData) Hash() (hash common.Hash, raw []byte, err error) {
+	if err := typedData.Validate(); err != nil {
+		return common.Hash{}, nil, err
+	}
+	domainSeparator, err := typedData.HashStruct(domainType, typedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	message, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	raw = append([]byte{0x19, 0x01}, domainSeparator...)
+	raw = append(raw, message...)
+	return crypto.Keccak256Hash(raw), raw, nil
+}
+
+// HashStruct calculates hashStruct(data) = keccak256(typeHash ‖ encodeData(data))
+// for data of the given struct type.
+func (typedData *TypedData) HashStruct(primaryType string, data TypedDataMessage) (hexutil.Bytes, error) {
+	encoded, err := typedData.EncodeData(primaryType, data, 1)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// Dependencies returns the struct types referenced by the given one, directly
+// or indirectly, including itself as the first item. Types already in found are
+// skipped.
+func (typedData *TypedData) Dependencies(primaryType string, found []string) []string {
+	for _, typ := range found {
+		if typ == primaryType {
+			return found
+		}
+	}
+	if typedData.Types[primaryType] == nil {
+		return found
+	}
+	found = append(found, primaryType)
+	for _, field := range typedData.Types[primaryType] {
+		found = typedData.Dependencies(field.typeName(), found)
+	}
+	return found
+}
+
+// EncodeType returns the signature of a struct type, followed by the signatures
+// of all the types it references in alphabetical order, e.g.
+//
+//   Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (typedData *TypedData) EncodeType(primaryType string) hexutil.Bytes {
+	deps := typedData.Dependencies(primaryType, nil)
+	if len(deps) > 0 {
+		sort.Strings(deps[1:])
+	}
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		buffer.WriteString(dep)
+		buffer.WriteString("(")
+		for i, field := range typedData.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(field.Type)
+			buffer.WriteString(" ")
+			buffer.WriteString(field.Name)
+		}
+		buffer.WriteString(")")
+	}
+	return buffer.Bytes()
+}
+
+// TypeHash calculates the hash of the encoded type.
+func (typedData *TypedData) TypeHash(primaryType string) hexutil.Bytes {
+	return crypto.Keccak256(typedData.EncodeType(primaryType))
+}
+
+// EncodeData returns typeHash ‖ enc(value₁) ‖ … ‖ enc(valueₙ) for the fields
+// of the given struct type, in their declaration order.
+func (typedData *TypedData) EncodeData(primaryType string, data map[string]interface{}, depth int) (hexutil.Bytes, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("message nested deeper than %d levels", maxDepth)
+	}
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", primaryType)
+	}
+	if len(data) > len(fields) {
+		return nil, fmt.Errorf("extra data provided for type %s (%d fields, %d values)", primaryType, len(fields), len(data))
+	}
+	var buffer bytes.Buffer
+	buffer.Write(typedData.TypeHash(primaryType))
+
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for field %q of type %s", field.Name, primaryType)
+		}
+		encoded, err := typedData.encodeValue(field.Type, value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("field %q of type %s: %v", field.Name, primaryType, err)
+		}
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodeValue encodes a single value into its 32 byte representation. Structs
+// and arrays are referenced by the hash of their encoding.
+func (typedData *TypedData) encodeValue(typ string, value interface{}, depth int) ([]byte, error) {
+	if strings.HasSuffix(typ, "]") {
+		elemType, size, err := splitArrayType(typ)
+		if err != nil {
+			return nil, err
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+		if size >= 0 && len(items) != size {
+			return nil, fmt.Errorf("expected %d array items, got %d", size, len(items))
+		}
+		var buffer bytes.Buffer
+		for _, item := range items {
+			encoded, err := typedData.encodeValue(elemType, item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			buffer.Write(encoded)
+		}
+		return crypto.Keccak256(buffer.Bytes()), nil
+	}
+	if _, ok := typedData.Types[typ]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected struct %s, got %T", typ, value)
+		}
+		encoded, err := typedData.EncodeData(typ, fields, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	return encodePrimitiveValue(typ, value)
+}
+
+// splitArrayType returns the element type of an array type and its size, which
+// is -1 for dynamically sized arrays.
+func splitArrayType(typ string) (string, int, error) {
+	i := strings.LastIndex(typ, "[")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid array type %q", typ)
+	}
+	if typ[i+1:len(typ)-1] == "" {
+		return typ[:i], -1, nil
+	}
+	size, err := strconv.Atoi(typ[i+1 : len(typ)-1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid array type %q", typ)
+	}
+	return typ[:i], size, nil
+}
+
+// encodePrimitiveValue encodes an atomic value padded to 32 bytes, and dynamic
+// values by their hash.
+func encodePrimitiveValue(typ string, value interface{}) ([]byte, error) {
+	switch typ {
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", value)
+		}
+		if b {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return math.PaddedBigBytes(common.Big0, 32), nil
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case "bytes":
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+	}
+	if match := fixedBytesRegexp.FindStringSubmatch(typ); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) > size {
+			return nil, fmt.Errorf("%d bytes exceed %s", len(blob), typ)
+		}
+		return common.RightPadBytes(blob, 32), nil
+	}
+	if match := integerRegexp.FindStringSubmatch(typ); match != nil {
+		size, _ := strconv.Atoi(match[2])
+		n, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if !integerFits(n, match[1] == "", size) {
+			return nil, fmt.Errorf("integer %v overflows %s", n, typ)
+		}
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(n)), 32), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+// integerFits returns whether n fits into an integer of the given signedness
+// and size in bits.
+func integerFits(n *big.Int, signed bool, size int) bool {
+	if !signed {
+		return n.Sign() >= 0 && n.BitLen() <= size
+	}
+	limit := new(big.Int).Lsh(common.Big1, uint(size-1))
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
+
+// parseInteger converts the integer representations that may appear in a
+// message into a big integer.
+func parseInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return parseIntegerString(string(v))
+	case string:
+		return parseIntegerString(v)
+	case float64:
+		// Floats only appear in programmatically assembled messages, reject
+		// anything which could have lost precision.
+		if v != float64(int64(v)) || v > 1<<53 || v < -(1<<53) {
+			return nil, fmt.Errorf("invalid integer %v", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case *big.Int:
+		return v, nil
+	case *math.HexOrDecimal256:
+		return (*big.Int)(v), nil
+	}
+	return nil, fmt.Errorf("invalid integer %v", value)
+}
+
+// parseIntegerString parses a decimal or 0x prefixed hexadecimal integer.
+func parseIntegerString(s string) (*big.Int, error) {
+	negative := strings.HasPrefix(s, "-")
+	n, ok := math.ParseBig256(strings.TrimPrefix(s, "-"))
+	if !ok || strings.HasPrefix(s, "--") {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// parseBytes converts a 0x prefixed hex string or a byte slice into bytes.
+func parseBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		blob, err := hexutil.Decode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %q: %v", v, err)
+		}
+		return blob, nil
+	case []byte:
+		return v, nil
+	case hexutil.Bytes:
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid bytes %v", value)
+}
+
+// NameValueType is a human readable rendering of a field and its value. Struct
+// and array values are rendered as a list of their fields or items.
+type NameValueType struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Typ   string      `json:"type"`
+}
+
+// Pprint returns the field and its nested fields as indented lines.
+func (nvt *NameValueType) Pprint(depth int) string {
+	var output bytes.Buffer
+	output.WriteString(strings.Repeat("  ", depth))
+	output.WriteString(fmt.Sprintf("%s [%s]:", nvt.Name, nvt.Typ))
+
+	if nvts, ok := nvt.Value.([]*NameValueType); ok {
+		output.WriteString("\n")
+		for _, next := range nvts {
+			output.WriteString(next.Pprint(depth + 1))
+		}
+	} else {
+		output.WriteString(fmt.Sprintf(" %q\n", nvt.Value))
+	}
+	return output.String()
+}
+
+// Format renders the domain and the message for display to the user, who has
+// to be able to review every field before approving a signature.
+func (typedData *TypedData) Format() ([]*NameValueType, error) {
+	domain, err := typedData.formatData(domainType, typedData.Domain.Map(), 1)
+	if err != nil {
+		return nil, err
+	}
+	message, err := typedData.formatData(typedData.PrimaryType, typedData.Message, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []*NameValueType{
+		{Name: domainType, Value: domain, Typ: "domain"},
+		{Name: typedData.PrimaryType, Value: message, Typ: "primary type"},
+	}, nil
+}
+
+// formatData renders the fields of a struct in their declaration order.
+func (typedData *TypedData) formatData(primaryType string, data map[string]interface{}, depth int) ([]*NameValueType, error) {
+	var output []*NameValueType
+	for _, field := range typedData.Types[primaryType] {
+		value, err := typedData.formatValue(field.Type, data[field.Name], depth)
+		if err != nil {
+			return nil, fmt.Errorf("field %q of type %s: %v", field.Name, primaryType, err)
+		}
+		output = append(output, &NameValueType{Name: field.Name, Value: value, Typ: field.Type})
+	}
+	return output, nil
+}
+
+// formatValue renders a single value, recursing into structs and arrays.
+func (typedData *TypedData) formatValue(typ string, value interface{}, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("message nested deeper than %d levels", maxDepth)
+	}
+	if strings.HasSuffix(typ, "]") {
+		elemType, _, err := splitArrayType(typ)
+		if err != nil {
+			return nil, err
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+		output := make([]*NameValueType, 0, len(items))
+		for i, item := range items {
+			formatted, err := typedData.formatValue(elemType, item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, &NameValueType{Name: fmt.Sprintf("[%d]", i), Value: formatted, Typ: elemType})
+		}
+		return output, nil
+	}
+	if _, ok := typedData.Types[typ]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected struct %s, got %T", typ, value)
+		}
+		return typedData.formatData(typ, fields, depth+1)
+	}
+	return formatPrimitiveValue(typ, value)
+}
+
+// formatPrimitiveValue renders an atomic or dynamic value as a string.
+func formatPrimitiveValue(typ string, value interface{}) (string, error) {
+	switch typ {
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return "", fmt.Errorf("invalid address %v", value)
+		}
+		return common.HexToAddress(str).Hex(), nil
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("invalid bool %v", value)
+		}
+		return strconv.FormatBool(b), nil
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("invalid string %v", value)
+		}
+		return str, nil
+	}
+	if typ == "bytes" || fixedBytesRegexp.MatchString(typ) {
+		blob, err := parseBytes(value)
+		if err != nil {
+			return "", err
+		}
+		return hexutil.Encode(blob), nil
+	}
+	if integerRegexp.MatchString(typ) {
+		n, err := parseInteger(value)
+		if err != nil {
+			return "", err
+		}
+		return n.String(), nil
+	}
+	return "", fmt.Errorf("unknown type %q", typ)
+}
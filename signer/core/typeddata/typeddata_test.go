@@ -0,0 +1,237 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package typeddata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// mailJSON is the example message of the EIP-712 specification.
+const mailJSON = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {
+			"name": "Cow",
+			"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+		},
+		"to": {
+			"name": "Bob",
+			"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"
+		},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func loadMail(t *testing.T) *TypedData {
+	var typedData TypedData
+	if err := json.Unmarshal([]byte(mailJSON), &typedData); err != nil {
+		t.Fatalf("failed to unmarshal typed data: %v", err)
+	}
+	return &typedData
+}
+
+// Tests the intermediate and final hashes of the specification example.
+func TestMailHashing(t *testing.T) {
+	typedData := loadMail(t)
+
+	if have, want := string(typedData.EncodeType("Mail")), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; have != want {
+		t.Errorf("type encoding mismatch: have %s, want %s", have, want)
+	}
+	if have, want := typedData.TypeHash("Mail").String(), "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"; have != want {
+		t.Errorf("type hash mismatch: have %s, want %s", have, want)
+	}
+	domain, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		t.Fatalf("failed to hash domain: %v", err)
+	}
+	if have, want := domain.String(), "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; have != want {
+		t.Errorf("domain separator mismatch: have %s, want %s", have, want)
+	}
+	message, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		t.Fatalf("failed to hash message: %v", err)
+	}
+	if have, want := message.String(), "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"; have != want {
+		t.Errorf("message hash mismatch: have %s, want %s", have, want)
+	}
+	hash, raw, err := typedData.Hash()
+	if err != nil {
+		t.Fatalf("failed to hash typed data: %v", err)
+	}
+	if have, want := hash.Hex(), "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; have != want {
+		t.Errorf("signing hash mismatch: have %s, want %s", have, want)
+	}
+	if have, want := hexutil.Encode(raw), "0x1901"+domain.String()[2:]+message.String()[2:]; have != want {
+		t.Errorf("raw data mismatch: have %s, want %s", have, want)
+	}
+	// Sign with the private key of the example and check against the specification
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	if addr := crypto.PubkeyToAddress(key.PublicKey); addr != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Fatalf("signer mismatch: have %x", addr)
+	}
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if have, want := hexutil.Encode(sig[:32]), "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d"; have != want {
+		t.Errorf("signature R mismatch: have %s, want %s", have, want)
+	}
+	if have, want := hexutil.Encode(sig[32:64]), "0x07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562"; have != want {
+		t.Errorf("signature S mismatch: have %s, want %s", have, want)
+	}
+	if have, want := sig[64]+27, byte(28); have != want {
+		t.Errorf("signature V mismatch: have %d, want %d", have, want)
+	}
+}
+
+// Tests that arrays, integers and fixed bytes are encoded as specified.
+func TestEncodeValues(t *testing.T) {
+	typedData := loadMail(t)
+	typedData.Types["Values"] = []Type{
+		{Name: "small", Type: "int8"},
+		{Name: "large", Type: "uint256"},
+		{Name: "blob", Type: "bytes4"},
+		{Name: "flag", Type: "bool"},
+	}
+	tests := []struct {
+		typ   string
+		value interface{}
+		want  string
+	}{
+		{"int8", json.Number("-1"), "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"uint256", "0x100", "0x0000000000000000000000000000000000000000000000000000000000000100"},
+		{"uint256", json.Number("115792089237316195423570985008687907853269984665640564039457584007913129639935"), "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"bytes4", "0xdeadbeef", "0xdeadbeef00000000000000000000000000000000000000000000000000000000"},
+		{"bool", true, "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"string[]", []interface{}{"a", "b"}, hexutil.Encode(crypto.Keccak256(crypto.Keccak256([]byte("a")), crypto.Keccak256([]byte("b"))))},
+	}
+	for i, tt := range tests {
+		encoded, err := typedData.encodeValue(tt.typ, tt.value, 1)
+		if err != nil {
+			t.Errorf("test %d: failed to encode %v: %v", i, tt.value, err)
+			continue
+		}
+		if have := hexutil.Encode(encoded); have != tt.want {
+			t.Errorf("test %d: encoding mismatch: have %s, want %s", i, have, tt.want)
+		}
+	}
+	invalid := []struct {
+		typ   string
+		value interface{}
+	}{
+		{"int8", json.Number("128")},
+		{"uint8", json.Number("-1")},
+		{"uint256", 1.5},
+		{"bytes4", "0xdeadbeef00"},
+		{"address", "0x1234"},
+		{"bool", "true"},
+		{"string[2]", []interface{}{"a"}},
+	}
+	for i, tt := range invalid {
+		if _, err := typedData.encodeValue(tt.typ, tt.value, 1); err == nil {
+			t.Errorf("test %d: expected error for %v as %s", i, tt.value, tt.typ)
+		}
+	}
+}
+
+// Tests that malformed type definitions and messages are rejected.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		modify func(*TypedData)
+		err    string
+	}{
+		{func(td *TypedData) { delete(td.Types, "EIP712Domain") }, "missing EIP712Domain type definition"},
+		{func(td *TypedData) { td.PrimaryType = "Letter" }, `primary type "Letter" is not defined`},
+		{func(td *TypedData) { td.Types["Mail"][2].Type = "text" }, `unknown type "text"`},
+		{func(td *TypedData) { td.Types["Mail"][2].Type = "uint7" }, `unknown type "uint7"`},
+		{func(td *TypedData) { td.Types["Mail"][2].Type = "string[x]" }, `invalid array type "string[x]"`},
+		{func(td *TypedData) { td.Types["Mail"][2].Name = "from" }, `duplicate field "from"`},
+		{func(td *TypedData) { td.Types["Person"][0].Type = "Person" }, "references itself"},
+		{func(td *TypedData) { td.Domain.Salt = "0x01" }, `domain field "salt" is set but not declared`},
+		{func(td *TypedData) { td.Domain.Version = "" }, `domain field "version" is declared but not set`},
+		{func(td *TypedData) { td.Message["extra"] = "field" }, "extra data provided"},
+		{func(td *TypedData) { delete(td.Message, "contents") }, `missing value for field "contents"`},
+		{func(td *TypedData) { td.Message["to"] = "Bob" }, "expected struct Person"},
+	}
+	for i, tt := range tests {
+		typedData := loadMail(t)
+		tt.modify(typedData)
+		if _, _, err := typedData.Hash(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that the rendering for the user shows every field of the message.
+func TestFormat(t *testing.T) {
+	typedData := loadMail(t)
+
+	nvts, err := typedData.Format()
+	if err != nil {
+		t.Fatalf("failed to format typed data: %v", err)
+	}
+	var output string
+	for _, nvt := range nvts {
+		output += nvt.Pprint(0)
+	}
+	want := `EIP712Domain [domain]:
+  name [string]: "Ether Mail"
+  version [string]: "1"
+  chainId [uint256]: "1"
+  verifyingContract [address]: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+Mail [primary type]:
+  from [Person]:
+    name [string]: "Cow"
+    wallet [address]: "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+  to [Person]:
+    name [string]: "Bob"
+    wallet [address]: "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"
+  contents [string]: "Hello, Bob!"
+`
+	if output != want {
+		t.Errorf("rendering mismatch:\nhave:\n%s\nwant:\n%s", output, want)
+	}
+}
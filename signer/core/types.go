@@ -59,6 +59,8 @@ type ValidationInfo struct {
 }
 type ValidationMessages struct {
 	Messages []ValidationInfo
+
+	call *DecodedCall // Decoded calldata, if it could be matched against a known ABI
 }
 
 const (
@@ -77,6 +77,7 @@ func (v *Validator) validateCallData(msgs *ValidationMessages, data []byte, meth
 			msgs.warn(fmt.Sprintf("Tx contains data, but provided ABI signature could not be matched: %v", err))
 		} else {
 			msgs.info(info.String())
+			msgs.call = info.export()
 			//Successfull match. add to db if not there already (ignore errors there)
 			v.db.AddSignature(*methodSelector, data[:4])
 		}
@@ -93,6 +94,7 @@ func (v *Validator) validateCallData(msgs *ValidationMessages, data []byte, meth
 		msgs.warn(fmt.Sprintf("Tx contains data, but provided ABI signature could not be matched: %v", err))
 	} else {
 		msgs.info(info.String())
+		msgs.call = info.export()
 	}
 }
 
@@ -0,0 +1,179 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/storage"
+	"github.com/robertkrimen/otto"
+)
+
+// ledgerRetention is the maximum age of the entries kept in the spend ledger.
+// Older entries are pruned whenever an account's ledger is updated, so rules
+// can't query windows longer than this.
+const ledgerRetention = 31 * 24 * time.Hour
+
+// ledgerEntry is a single signed transaction recorded in the spend ledger.
+type ledgerEntry struct {
+	Time  int64           `json:"time"` // Unix timestamp of the signing, in seconds
+	To    *common.Address `json:"to"`   // Recipient, nil for contract creations
+	Value *hexutil.Big    `json:"value"`
+}
+
+// spendLedger keeps track of the value sent from each account. The entries are
+// persisted into a storage of their own, out of reach of the rules, so that the
+// limits enforced by them hold across restarts of the signer.
+type spendLedger struct {
+	storage storage.Storage
+	now     func() time.Time // Overridable for testing
+}
+
+func newSpendLedger(db storage.Storage) *spendLedger {
+	return &spendLedger{storage: db, now: time.Now}
+}
+
+// ledgerKey returns the storage key of the ledger of an account.
+func ledgerKey(addr common.Address) string {
+	return "ledger:" + strings.ToLower(addr.Hex())
+}
+
+// entries returns the recorded transactions of an account which were signed
+// within the last window. A ledger which can't be decoded is reported as an
+// error instead of being treated as empty, since that would reset the limits
+// enforced by the rules.
+func (l *spendLedger) entries(addr common.Address, window time.Duration) ([]ledgerEntry, error) {
+	var entries []ledgerEntry
+	if blob := l.storage.Get(ledgerKey(addr)); blob != "" {
+		if err := json.Unmarshal([]byte(blob), &entries); err != nil {
+			return nil, fmt.Errorf("corrupt spend ledger of %s: %v", addr.Hex(), err)
+		}
+	}
+	cutoff := l.now().Add(-window).Unix()
+
+	recent := entries[:0]
+	for _, entry := range entries {
+		if entry.Time > cutoff {
+			recent = append(recent, entry)
+		}
+	}
+	return recent, nil
+}
+
+// record adds a signed transaction to the ledger of its sender.
+func (l *spendLedger) record(tx *types.Transaction) error {
+	if tx == nil {
+		return errors.New("missing transaction")
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	entries, err := l.entries(from, ledgerRetention)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, ledgerEntry{
+		Time:  l.now().Unix(),
+		To:    tx.To(),
+		Value: (*hexutil.Big)(tx.Value()),
+	})
+	blob, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	l.storage.Put(ledgerKey(from), string(blob))
+	return nil
+}
+
+// spent returns the total value sent from an account within the last window.
+func (l *spendLedger) spent(addr common.Address, window time.Duration) (*big.Int, error) {
+	entries, err := l.entries(addr, window)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	for _, entry := range entries {
+		total.Add(total, entry.Value.ToInt())
+	}
+	return total, nil
+}
+
+// install adds the ledger object to the javascript VM, exposing the methods:
+//
+//   ledger.spent(address, seconds)   - the total wei sent from an account during
+//                                      the last seconds, as a decimal string
+//   ledger.history(address, seconds) - the list of {time, to, value} transactions
+//                                      sent from an account during the last seconds
+func (l *spendLedger) install(vm *otto.Otto) error {
+	obj, err := vm.Object(`({})`)
+	if err != nil {
+		return err
+	}
+	obj.Set("spent", func(call otto.FunctionCall) otto.Value {
+		addr, window := ledgerArgs(call)
+		spent, err := l.spent(addr, window)
+		if err != nil {
+			panic(call.Otto.MakeCustomError("LedgerError", err.Error()))
+		}
+		v, _ := call.Otto.ToValue(spent.String())
+		return v
+	})
+	obj.Set("history", func(call otto.FunctionCall) otto.Value {
+		addr, window := ledgerArgs(call)
+		entries, err := l.entries(addr, window)
+		if err != nil {
+			panic(call.Otto.MakeCustomError("LedgerError", err.Error()))
+		}
+		if entries == nil {
+			entries = []ledgerEntry{}
+		}
+		blob, _ := json.Marshal(entries)
+		v, err := call.Otto.Call("JSON.parse", nil, string(blob))
+		if err != nil {
+			panic(err)
+		}
+		return v
+	})
+	return vm.Set("ledger", obj)
+}
+
+// ledgerArgs parses the (address, seconds) arguments of the ledger methods,
+// throwing a javascript exception if they are invalid.
+func ledgerArgs(call otto.FunctionCall) (common.Address, time.Duration) {
+	addr := call.Argument(0).String()
+	if !common.IsHexAddress(addr) {
+		panic(call.Otto.MakeTypeError("invalid address: " + addr))
+	}
+	seconds, err := call.Argument(1).ToInteger()
+	if err != nil || seconds < 0 {
+		panic(call.Otto.MakeTypeError("invalid time window: " + call.Argument(1).String()))
+	}
+	return common.HexToAddress(addr), time.Duration(seconds) * time.Second
+}
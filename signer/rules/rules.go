@@ -52,14 +52,16 @@ type rulesetUI struct {
 	next        core.SignerUI // The next handler, for manual processing
 	storage     storage.Storage
 	credentials storage.Storage
-	jsRules     string // The rules to use
+	ledger      *spendLedger // Value sent per account, maintained for the rules
+	jsRules     string       // The rules to use
 }
 
-func NewRuleEvaluator(next core.SignerUI, jsbackend, credentialsBackend storage.Storage) (*rulesetUI, error) {
+func NewRuleEvaluator(next core.SignerUI, jsbackend, credentialsBackend, ledgerBackend storage.Storage) (*rulesetUI, error) {
 	c := &rulesetUI{
 		next:        next,
 		storage:     jsbackend,
 		credentials: credentialsBackend,
+		ledger:      newSpendLedger(ledgerBackend),
 		jsRules:     "",
 	}
 
@@ -79,6 +81,10 @@ func (r *rulesetUI) execute(jsfunc string, jsarg interface{}) (otto.Value, error
 	consoleObj.Object().Set("log", consoleOutput)
 	consoleObj.Object().Set("error", consoleOutput)
 	vm.Set("storage", r.storage)
+	if err := r.ledger.install(vm); err != nil {
+		log.Warn("Failed installing spend ledger", "err", err)
+		return otto.UndefinedValue(), err
+	}
 
 	// Load bootstrap libraries
 	script, err := vm.Compile("bignumber.js", BigNumber_JS)
@@ -140,6 +146,14 @@ func (r *rulesetUI) checkApproval(jsfunc string, jsarg []byte, err error) (bool,
 }
 
 func (r *rulesetUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	// Reject outright if the sender's spend ledger is unreadable, the rules
+	// can't enforce their limits without it
+	if request != nil {
+		if _, err := r.ledger.entries(request.Transaction.From.Address(), ledgerRetention); err != nil {
+			log.Warn("Rejecting transaction, spend ledger unavailable", "err", err)
+			return core.SignTxResponse{Approved: false}, err
+		}
+	}
 	jsonreq, err := json.Marshal(request)
 	approved, err := r.checkApproval("ApproveTx", jsonreq, err)
 	if err != nil {
@@ -242,6 +256,11 @@ func (r *rulesetUI) OnSignerStartup(info core.StartupInfo) {
 }
 
 func (r *rulesetUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	// Record the transaction in the ledger before notifying the rules, so that
+	// they see a consistent state
+	if err := r.ledger.record(tx.Tx); err != nil {
+		log.Warn("Failed recording transaction in spend ledger", "err", err)
+	}
 	jsonTx, err := json.Marshal(tx)
 	if err != nil {
 		log.Warn("failed marshalling transaction", "tx", tx)
@@ -21,11 +21,13 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/signer/core"
 	"github.com/ethereum/go-ethereum/signer/storage"
@@ -122,7 +124,7 @@ func (alwaysDenyUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
 }
 
 func initRuleEngine(js string) (*rulesetUI, error) {
-	r, err := NewRuleEvaluator(&alwaysDenyUI{}, storage.NewEphemeralStorage(), storage.NewEphemeralStorage())
+	r, err := NewRuleEvaluator(&alwaysDenyUI{}, storage.NewEphemeralStorage(), storage.NewEphemeralStorage(), storage.NewEphemeralStorage())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create js engine: %v", err)
 	}
@@ -269,7 +271,7 @@ func TestForwarding(t *testing.T) {
 	ui := &dummyUI{make([]string, 0)}
 	jsBackend := storage.NewEphemeralStorage()
 	credBackend := storage.NewEphemeralStorage()
-	r, err := NewRuleEvaluator(ui, jsBackend, credBackend)
+	r, err := NewRuleEvaluator(ui, jsBackend, credBackend, storage.NewEphemeralStorage())
 	if err != nil {
 		t.Fatalf("Failed to create js engine: %v", err)
 	}
@@ -601,7 +603,7 @@ func TestContextIsCleared(t *testing.T) {
 	}
 	`
 	ui := &dontCallMe{t}
-	r, err := NewRuleEvaluator(ui, storage.NewEphemeralStorage(), storage.NewEphemeralStorage())
+	r, err := NewRuleEvaluator(ui, storage.NewEphemeralStorage(), storage.NewEphemeralStorage(), storage.NewEphemeralStorage())
 	if err != nil {
 		t.Fatalf("Failed to create js engine: %v", err)
 	}
@@ -656,3 +658,162 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+const ExampleLedger = `
+	function big(str){
+		if(str.slice(0,2) == "0x"){ return new BigNumber(str.slice(2),16)}
+		return new BigNumber(str)
+	}
+	var token = "0x000000000000000000000000000000000000babe"
+
+	function ApproveTx(r){
+		var tx = r.transaction
+		// Only allow transfer() on the token contract
+		if(tx.to.toLowerCase() == token){
+			if(r.call && r.call.name == "transfer"){
+				return "Approve"
+			}
+			return "Reject"
+		}
+		// Allow at most 1 ether per day to anyone else
+		var spent = big(ledger.spent(tx.from, 24*3600))
+		if(spent.plus(big(tx.value)).lte(new BigNumber("1e18"))){
+			return "Approve"
+		}
+		return "Reject"
+	}
+	function history(addr){
+		return ledger.history(addr, 30*24*3600).length
+	}
+`
+
+func TestSpendLedger(t *testing.T) {
+	r, err := initRuleEngine(ExampleLedger)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	now := time.Now()
+	r.ledger.now = func() time.Time { return now }
+
+	key, _ := crypto.GenerateKey()
+	from := common.NewMixedcaseAddress(crypto.PubkeyToAddress(key.PublicKey))
+
+	// sign approves a request and records the signed transaction in the ledger
+	sign := func(req *core.SignTxRequest) bool {
+		resp, err := r.ApproveTx(req)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if resp.Approved {
+			signer := types.NewEIP155Signer(big.NewInt(1))
+			tx, _ := types.SignTx(dummySigned(req.Transaction.Value.ToInt()), signer, key)
+			r.OnApprovedTx(ethapi.SignTransactionResult{Tx: tx})
+		}
+		return resp.Approved
+	}
+	// 0.6 ether
+	v := hexutil.Big(*new(big.Int).Mul(big.NewInt(6), big.NewInt(1e17)))
+
+	req := dummyTx(v)
+	req.Transaction.From = from
+	if !sign(req) {
+		t.Errorf("Expected first transfer to be approved")
+	}
+	if sign(req) {
+		t.Errorf("Expected second transfer to exceed the daily limit")
+	}
+	now = now.Add(25 * time.Hour)
+	if !sign(req) {
+		t.Errorf("Expected transfer to be approved after the window passed")
+	}
+	// Check the calls to the token contract
+	token, _ := mixAddr("0x000000000000000000000000000000000000babe")
+	req = dummyTx(hexutil.Big{})
+	req.Transaction.To = token
+
+	for i, tt := range []struct {
+		call *core.DecodedCall
+		want bool
+	}{
+		{nil, false},
+		{&core.DecodedCall{Name: "approve", Signature: "approve(address,uint256)"}, false},
+		{&core.DecodedCall{Name: "transfer", Signature: "transfer(address,uint256)"}, true},
+	} {
+		req.Call = tt.call
+		resp, err := r.ApproveTx(req)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if resp.Approved != tt.want {
+			t.Errorf("test %d: approval mismatch: have %v, want %v", i, resp.Approved, tt.want)
+		}
+	}
+	// Check that the history contains both signed transactions
+	v2, err := r.execute("history", fmt.Sprintf("%q", from.Address().Hex()))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if n, _ := v2.ToInteger(); n != 2 {
+		t.Errorf("History length mismatch: have %d, want %d", n, 2)
+	}
+	if _, err := r.execute("history", `"nonsense"`); err == nil {
+		t.Errorf("Expected error for invalid address")
+	}
+}
+
+func TestSpendLedgerCorrupt(t *testing.T) {
+	r, err := initRuleEngine(ExampleLedger)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	r.ledger.storage.Put(ledgerKey(addr), "not json")
+
+	// A small transfer would be approved with an empty ledger
+	req := dummyTx(hexutil.Big(*big.NewInt(1)))
+	req.Transaction.From = common.NewMixedcaseAddress(addr)
+	resp, err := r.ApproveTx(req)
+	if err == nil {
+		t.Errorf("Expected error for corrupt ledger")
+	}
+	if resp.Approved {
+		t.Errorf("Expected transaction to be rejected")
+	}
+	// The corrupt ledger must not be overwritten by a new entry
+	tx, _ := types.SignTx(dummySigned(big.NewInt(1)), types.NewEIP155Signer(big.NewInt(1)), key)
+	if err := r.ledger.record(tx); err == nil {
+		t.Errorf("Expected error recording into corrupt ledger")
+	}
+	if blob := r.ledger.storage.Get(ledgerKey(addr)); blob != "not json" {
+		t.Errorf("Ledger overwritten: %q", blob)
+	}
+	if _, err := r.execute("history", fmt.Sprintf("%q", addr.Hex())); err == nil {
+		t.Errorf("Expected error querying corrupt ledger")
+	}
+}
+
+func TestSpendLedgerIsolated(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	// The rules try to wipe the ledger of the sender after each signing
+	js := ExampleLedger + fmt.Sprintf(`
+	function OnApprovedTx(resp){
+		storage.Put(%q, "[]")
+	}`, ledgerKey(addr))
+
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	tx, _ := types.SignTx(dummySigned(big.NewInt(1)), types.NewEIP155Signer(big.NewInt(1)), key)
+	r.OnApprovedTx(ethapi.SignTransactionResult{Tx: tx})
+
+	if blob := r.storage.Get(ledgerKey(addr)); blob != "[]" {
+		t.Errorf("Rule storage mismatch: have %q, want %q", blob, "[]")
+	}
+	if spent, err := r.ledger.spent(addr, time.Hour); err != nil || spent.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Spent value mismatch: have %v (%v), want %d", spent, err, 1)
+	}
+}